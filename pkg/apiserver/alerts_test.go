@@ -270,7 +270,7 @@ func TestAlertListFilters(t *testing.T) {
 	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", loginResp.Token))
 	router.ServeHTTP(w, req)
 	assert.Equal(t, 500, w.Code)
-	assert.Equal(t, `{"message":"unable to parse 'gruueq': %!s(\u003cnil\u003e): invalid ip address / range"}`, w.Body.String())
+	assert.Equal(t, `{"message":"unable to convert 'gruueq' to int interval: 'gruueq' is not a valid IP: invalid ip address / range"}`, w.Body.String())
 
 	//test range (ok)
 	w = httptest.NewRecorder()
@@ -298,7 +298,7 @@ func TestAlertListFilters(t *testing.T) {
 	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", loginResp.Token))
 	router.ServeHTTP(w, req)
 	assert.Equal(t, 500, w.Code)
-	assert.Equal(t, `{"message":"unable to convert 'ratata' to int interval: 'ratata' is not a valid CIDR: invalid ip address / range"}`, w.Body.String())
+	assert.Equal(t, `{"message":"unable to convert 'ratata' to int interval: 'ratata' is not a valid CIDR: missing prefix length (ie. '/24'): invalid ip address / range"}`, w.Body.String())
 
 	//test since (ok)
 	w = httptest.NewRecorder()
@@ -55,6 +55,11 @@ func FormatOneAlert(alert *ent.Alert) *models.Alert {
 			Longitude: alert.SourceLongitude,
 		},
 	}
+	if alert.Tags != "" {
+		if err := json.Unmarshal([]byte(alert.Tags), &outputAlert.Labels); err != nil {
+			log.Errorf("unable to unmarshall alert tags '%s' : %s", alert.Tags, err)
+		}
+	}
 	for _, eventItem := range alert.Edges.Events {
 		var Metas models.Meta
 		timestamp := eventItem.Time.String()
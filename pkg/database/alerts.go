@@ -1,8 +1,11 @@
 package database
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -11,21 +14,50 @@ import (
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent/alert"
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent/decision"
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent/event"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/machine"
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent/meta"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/predicate"
 	"github.com/crowdsecurity/crowdsec/pkg/models"
 	"github.com/crowdsecurity/crowdsec/pkg/types"
 	"github.com/davecgh/go-spew/spew"
+	"github.com/facebook/ent/dialect"
+	"github.com/facebook/ent/dialect/sql"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
 const (
-	paginationSize = 100 // used to queryAlert to avoid 'too many SQL variable'
-	defaultLimit   = 100 // default limit of element to returns when query alerts
-	bulkSize       = 50  // bulk size when create alerts
+	paginationSize       = 100 // used to queryAlert to avoid 'too many SQL variable'
+	defaultLimit         = 100 // default limit of element to returns when query alerts
+	deleteBatchSize      = 500 // number of alerts deleted by each statement of DeleteAlertGraphBatch
+	refreshBatchSize     = 500 // number of values updated by each statement of RefreshDecisionsExpiry
+	bulkSize             = 50  // bulk size when create alerts
+	defaultAlertBulkSize = 20  // number of alerts inserted per transaction by CreateAlertBulk, unless Client.AlertBulkSize is set
 )
 
-func formatAlertAsString(machineId string, alert *models.Alert) []string {
+// AlertInsertError is the reason why CreateAlertBulk skipped the alert at Index in its input
+type AlertInsertError struct {
+	Index int
+	Err   error
+}
+
+// BulkInsertErrors is returned by CreateAlertBulk, along with the ids of the inserted alerts, when some alerts couldn't be written
+type BulkInsertErrors []AlertInsertError
+
+func (e BulkInsertErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, item := range e {
+		msgs = append(msgs, fmt.Sprintf("alert %d: %s", item.Index, item.Err))
+	}
+	return fmt.Sprintf("%d alerts skipped: %s", len(e), strings.Join(msgs, ", "))
+}
+
+// Cause allows errors.Cause() to report the skipped alerts as a BulkError
+func (e BulkInsertErrors) Cause() error {
+	return BulkError
+}
+
+func formatAlertAsString(logger *log.Entry, machineId string, alert *models.Alert) []string {
 	var retStr []string
 
 	/**/
@@ -74,9 +106,9 @@ func formatAlertAsString(machineId string, alert *models.Alert) []string {
 			} else if decisionItem.Simulated != nil && *decisionItem.Simulated {
 				decision = "(simulated decision)"
 			}
-			if log.GetLevel() >= log.DebugLevel {
+			if logger.Logger.IsLevelEnabled(log.DebugLevel) {
 				/*spew is expensive*/
-				log.Debugf("%s", spew.Sdump(decisionItem))
+				logger.Debugf("%s", spew.Sdump(decisionItem))
 			}
 			decision += fmt.Sprintf("%s %s on %s %s", *decisionItem.Duration,
 				*decisionItem.Type, *decisionItem.Scope, *decisionItem.Value)
@@ -90,6 +122,57 @@ func formatAlertAsString(machineId string, alert *models.Alert) []string {
 	return retStr
 }
 
+// checkClockSkew detects timestamps too far in the future (ie. agent with a wrong clock) : they are either rejected or clamped to now()
+func (c *Client) checkClockSkew(machineId string, field string, ts time.Time) (time.Time, error) {
+	if c.MaxClockSkew <= 0 {
+		return ts, nil
+	}
+	now := time.Now()
+	if ts.Sub(now) <= c.MaxClockSkew {
+		return ts, nil
+	}
+	if c.ClampClockSkew {
+		c.logger().Warningf("machine '%s' sent %s '%s' in the future (max skew %s), using now()", machineId, field, ts, c.MaxClockSkew)
+		return now, nil
+	}
+	c.logger().Warningf("machine '%s' sent %s '%s' in the future (max skew %s), rejecting alert", machineId, field, ts, c.MaxClockSkew)
+	return ts, errors.Wrapf(TimeInFuture, "%s field time '%s' from machine '%s'", field, ts, machineId)
+}
+
+// checkDecisionDuration refuses the decision durations that would create an already expired (or too short lived) decision,
+// or raises them to MinDecisionDuration if ClampDecisionDuration is set
+func (c *Client) checkDecisionDuration(decisionItem *models.Decision, duration time.Duration) (time.Duration, error) {
+	if duration > 0 && duration >= c.MinDecisionDuration {
+		return duration, nil
+	}
+	if c.ClampDecisionDuration && c.MinDecisionDuration > 0 {
+		c.logger().Warningf("decision on %s '%s' (scenario %s) has a duration of %s, using %s instead", *decisionItem.Scope, *decisionItem.Value, *decisionItem.Scenario, duration, c.MinDecisionDuration)
+		return c.MinDecisionDuration, nil
+	}
+	c.logger().Warningf("decision on %s '%s' (scenario %s) has a duration of %s, refusing it", *decisionItem.Scope, *decisionItem.Value, *decisionItem.Scenario, duration)
+	return 0, errors.Wrapf(InvalidDuration, "decision duration '%s' on '%s' : must be positive and at least %s", *decisionItem.Duration, *decisionItem.Value, c.MinDecisionDuration)
+}
+
+// KnownDecisionTypes are the decision types the bouncers act on
+var KnownDecisionTypes = []string{"ban", "captcha", "throttle"}
+
+// normalizeDecisionType lowercases and trims the decision type, so that "Ban " is stored as the "ban" the bouncers filter on.
+// Types that aren't in KnownDecisionTypes are kept with a warning, or refused if RejectUnknownDecisionTypes is set
+func (c *Client) normalizeDecisionType(decisionItem *models.Decision) (string, error) {
+	decisionType := strings.ToLower(strings.TrimSpace(*decisionItem.Type))
+	for _, known := range KnownDecisionTypes {
+		if decisionType == known {
+			return decisionType, nil
+		}
+	}
+	if !c.RejectUnknownDecisionTypes {
+		c.logger().Warningf("decision on %s '%s' (scenario %s) has an unknown type '%s'", *decisionItem.Scope, *decisionItem.Value, *decisionItem.Scenario, decisionType)
+		return decisionType, nil
+	}
+	c.logger().Warningf("decision on %s '%s' (scenario %s) has an unknown type '%s', refusing it", *decisionItem.Scope, *decisionItem.Value, *decisionItem.Scenario, *decisionItem.Type)
+	return "", errors.Wrapf(InvalidDecisionType, "decision type '%s' on '%s' : must be one of %s", *decisionItem.Type, *decisionItem.Value, strings.Join(KnownDecisionTypes, ", "))
+}
+
 func (c *Client) CreateAlert(machineID string, alertList []*models.Alert) ([]string, error) {
 	pageStart := 0
 	pageEnd := bulkSize
@@ -115,25 +198,126 @@ func (c *Client) CreateAlert(machineID string, alertList []*models.Alert) ([]str
 }
 
 func (c *Client) CreateAlertBulk(machineId string, alertList []*models.Alert) ([]string, error) {
+	return c.CreateAlertBulkCtx(c.CTX, machineId, alertList)
+}
+
+// CreateAlertBulkCtx writes each batch of alerts (with their events, metas and decisions) in its own transaction.
+// If ctx is cancelled, the batch in progress is rolled back and ctx.Err() is returned : only the batches committed before are kept, and their ids returned.
+func (c *Client) CreateAlertBulkCtx(ctx context.Context, machineId string, alertList []*models.Alert) ([]string, error) {
+	ret, err := c.createAlertBulk(ctx, machineId, alertList, false)
+	if err != nil && ctx.Err() != nil {
+		return ret, errors.Wrapf(ctx.Err(), "creating alerts: %s", err)
+	}
+	return ret, err
+}
+
+/*keepIDs inserts the alerts with the id they carry (see ImportAlerts) instead of letting the database pick it*/
+func (c *Client) createAlertBulk(ctx context.Context, machineId string, alertList []*models.Alert, keepIDs bool) ([]string, error) {
+	var skipped BulkInsertErrors
 
 	ret := []string{}
-	bulkSize := 20
+	bulkSize := c.AlertBulkSize
+	if bulkSize <= 0 {
+		bulkSize = defaultAlertBulkSize
+	}
 
-	c.Log.Debugf("writting %d items", len(alertList))
-	bulk := make([]*ent.AlertCreate, 0, bulkSize)
-	for i, alertItem := range alertList {
-		var decisions []*ent.Decision
-		var metas []*ent.Meta
-		var events []*ent.Event
+	/*all the alerts of this call share the same batch id, to be able to retrieve (or delete) them with the batch_id filter*/
+	batchID, err := newBatchID()
+	if err != nil {
+		return []string{}, errors.Wrapf(InsertFail, "generating batch id: %s", err)
+	}
 
-		owner, err := c.QueryMachineByID(machineId)
+	/*all the alerts come from the same machine, look it up once*/
+	var owner *ent.Machine
+	if !c.SkipOwnerLookup && len(alertList) > 0 {
+		owner, err = c.cachedMachineByID(machineId)
 		if err != nil {
 			if errors.Cause(err) != UserNotExists {
-				return []string{}, errors.Wrapf(QueryFail, "machine '%s': %s", alertItem.MachineID, err)
+				return []string{}, errors.Wrapf(QueryFail, "machine '%s': %s", machineId, err)
 			}
-			log.Debugf("CreateAlertBulk: Machine Id %s doesn't exist", machineId)
+			c.logger().Debugf("CreateAlertBulk: Machine Id %s doesn't exist", machineId)
 			owner = nil
 		}
+	}
+
+	c.logger().Debugf("writting %d items (batch %s)", len(alertList), batchID)
+	for start := 0; start < len(alertList); start += bulkSize {
+		end := start + bulkSize
+		if end > len(alertList) {
+			end = len(alertList)
+		}
+		ids, err := c.insertAlertBatch(ctx, machineId, owner, batchID, alertList[start:end], keepIDs)
+		if err == nil {
+			ret = append(ret, ids...)
+			continue
+		}
+		/*invalid alerts, cancellation and a database still locked abort the whole call, only the other database errors are worth a retry.
+		The alerts of the previous batches are committed : their ids are returned with the error, for the caller to know where to resume*/
+		if errors.Cause(err) != BulkError || ctx.Err() != nil || isLockedError(err) {
+			return ret, err
+		}
+		if end-start == 1 {
+			skipped = append(skipped, AlertInsertError{Index: start, Err: err})
+			continue
+		}
+		/*a single bad row makes the whole batch fail : insert its alerts one by one to only skip the offending ones*/
+		c.logger().Warningf("CreateAlertBulk : batch of %d alerts failed, retrying them one by one: %s", end-start, err)
+		for i := start; i < end; i++ {
+			ids, err := c.insertAlertBatch(ctx, machineId, owner, batchID, alertList[i:i+1], keepIDs)
+			if err != nil {
+				if errors.Cause(err) != BulkError || ctx.Err() != nil {
+					return ret, err
+				}
+				c.logger().Warningf("CreateAlertBulk : skipping alert %d: %s", i, err)
+				skipped = append(skipped, AlertInsertError{Index: i, Err: err})
+				continue
+			}
+			ret = append(ret, ids...)
+		}
+	}
+	if len(skipped) > 0 {
+		return ret, skipped
+	}
+	return ret, nil
+}
+
+// insertAlertBatch writes the alerts, with their events, metas and decisions, in a single transaction.
+// The whole transaction is tried again while the database is locked by another writer.
+func (c *Client) insertAlertBatch(ctx context.Context, machineId string, owner *ent.Machine, batchID string, alertList []*models.Alert, keepIDs bool) ([]string, error) {
+	var ids []string
+	err := c.retryLocked(ctx, func() error {
+		var err error
+		ids, err = c.insertAlertBatchTx(ctx, machineId, owner, batchID, alertList, keepIDs)
+		return err
+	})
+	return ids, err
+}
+
+func (c *Client) insertAlertBatchTx(ctx context.Context, machineId string, owner *ent.Machine, batchID string, alertList []*models.Alert, keepIDs bool) ([]string, error) {
+	tx, err := c.Ent.Tx(ctx)
+	if err != nil {
+		return []string{}, wrapDBError(err, BulkError, "starting transaction: %s", err)
+	}
+	/*the batch is discarded if we bail out before its commit*/
+	defer func() {
+		if tx != nil {
+			if err := tx.Rollback(); err != nil {
+				c.logger().Warningf("CreateAlertBulk : rollback failed: %s", err)
+			}
+		}
+	}()
+
+	bulk := make([]*ent.AlertCreate, 0, len(alertList))
+	for _, alertItem := range alertList {
+		var decisions []*ent.Decision
+		var metas []*ent.Meta
+		var events []*ent.Event
+		var err error
+
+		if err := validateAlert(alertItem); err != nil {
+			return []string{}, err
+		}
+
 		startAtTime, err := time.Parse(time.RFC3339, *alertItem.StartAt)
 		if err != nil {
 			return []string{}, errors.Wrapf(ParseTimeFail, "start_at field time '%s': %s", *alertItem.StartAt, err)
@@ -143,9 +327,19 @@ func (c *Client) CreateAlertBulk(machineId string, alertList []*models.Alert) ([
 		if err != nil {
 			return []string{}, errors.Wrapf(ParseTimeFail, "stop_at field time '%s': %s", *alertItem.StopAt, err)
 		}
+		startAtTime, err = c.checkClockSkew(machineId, "start_at", startAtTime)
+		if err != nil {
+			return []string{}, err
+		}
+		stopAtTime, err = c.checkClockSkew(machineId, "stop_at", stopAtTime)
+		if err != nil {
+			return []string{}, err
+		}
+		/*times are stored in UTC whatever the offset of the machine, to be compared consistently*/
+		startAtTime, stopAtTime = startAtTime.UTC(), stopAtTime.UTC()
 		/*display proper alert in logs*/
-		for _, disp := range formatAlertAsString(machineId, alertItem) {
-			log.Info(disp)
+		for _, disp := range formatAlertAsString(c.logger(), machineId, alertItem) {
+			c.logger().Info(disp)
 		}
 
 		if len(alertItem.Events) > 0 {
@@ -160,61 +354,95 @@ func (c *Client) CreateAlertBulk(machineId string, alertList []*models.Alert) ([
 					return []string{}, errors.Wrapf(MarshalFail, "event meta '%v' : %s", eventItem.Meta, err)
 				}
 
-				eventBulk[i] = c.Ent.Event.Create().
-					SetTime(ts).
+				eventBulk[i] = tx.Event.Create().
+					SetTime(ts.UTC()).
 					SetSerialized(string(marshallMetas))
 			}
-			events, err = c.Ent.Event.CreateBulk(eventBulk...).Save(c.CTX)
+			events, err = tx.Event.CreateBulk(eventBulk...).Save(ctx)
 			if err != nil {
-				return []string{}, errors.Wrapf(BulkError, "creating alert events: %s", err)
+				return []string{}, wrapDBError(err, BulkError, "creating alert events: %s", err)
 			}
 		}
 
 		if len(alertItem.Meta) > 0 {
 			metaBulk := make([]*ent.MetaCreate, len(alertItem.Meta))
 			for i, metaItem := range alertItem.Meta {
-				metaBulk[i] = c.Ent.Meta.Create().
+				metaBulk[i] = tx.Meta.Create().
 					SetKey(metaItem.Key).
 					SetValue(metaItem.Value)
 			}
-			metas, err = c.Ent.Meta.CreateBulk(metaBulk...).Save(c.CTX)
+			metas, err = tx.Meta.CreateBulk(metaBulk...).Save(ctx)
 			if err != nil {
-				return []string{}, errors.Wrapf(BulkError, "creating alert meta: %s", err)
+				return []string{}, wrapDBError(err, BulkError, "creating alert meta: %s", err)
 			}
 		}
 
-		ts, err := time.Parse(time.RFC3339, *alertItem.StopAt)
-		if err != nil {
-			log.Errorf("While parsing StartAt of item %s : %s", *alertItem.StopAt, err)
-			ts = time.Now()
-		}
+		ts := stopAtTime
 		if len(alertItem.Decisions) > 0 {
-			decisionBulk := make([]*ent.DecisionCreate, len(alertItem.Decisions))
-			for i, decisionItem := range alertItem.Decisions {
+			decisionBulk := make([]*ent.DecisionCreate, 0, len(alertItem.Decisions))
+			for _, decisionItem := range alertItem.Decisions {
 
 				duration, err := time.ParseDuration(*decisionItem.Duration)
 				if err != nil {
 					return []string{}, errors.Wrapf(ParseDurationFail, "decision duration '%v' : %s", decisionItem.Duration, err)
 				}
-				decisionBulk[i] = c.Ent.Decision.Create().
-					SetUntil(ts.Add(duration)).
+				var until time.Time
+				/*when provided (ie. import of existing decisions), the absolute expiration is kept as is*/
+				if decisionItem.Until != "" {
+					until, err = time.Parse(time.RFC3339, decisionItem.Until)
+					if err != nil {
+						return []string{}, errors.Wrapf(ParseTimeFail, "decision until '%s' : %s", decisionItem.Until, err)
+					}
+				} else {
+					if duration, err = c.checkDecisionDuration(decisionItem, duration); err != nil {
+						return []string{}, err
+					}
+					until = ts.Add(duration)
+				}
+				decisionType, err := c.normalizeDecisionType(decisionItem)
+				if err != nil {
+					return []string{}, err
+				}
+				decisionB, err := c.setDecisionIPBounds(tx.Decision.Create(), decisionItem)
+				if err != nil {
+					return []string{}, err
+				}
+				if c.DedupDecisions {
+					extended, err := extendActiveDecision(ctx, tx, decisionItem, decisionType, until.UTC())
+					if err != nil {
+						return []string{}, err
+					}
+					if extended {
+						continue
+					}
+				} else if c.UpsertDecisions {
+					updated, err := upsertActiveDecision(ctx, tx, owner, decisionItem, decisionType, *alertItem.Simulated, until.UTC())
+					if err != nil {
+						return []string{}, err
+					}
+					if updated {
+						continue
+					}
+				}
+				decisionBulk = append(decisionBulk, decisionB.
+					SetUntil(until.UTC()).
 					SetScenario(*decisionItem.Scenario).
-					SetType(*decisionItem.Type).
-					SetStartIP(decisionItem.StartIP).
-					SetEndIP(decisionItem.EndIP).
+					SetType(decisionType).
 					SetValue(*decisionItem.Value).
 					SetScope(*decisionItem.Scope).
 					SetOrigin(*decisionItem.Origin).
-					SetSimulated(*alertItem.Simulated)
+					SetSimulated(*alertItem.Simulated))
 			}
-			decisions, err = c.Ent.Decision.CreateBulk(decisionBulk...).Save(c.CTX)
-			if err != nil {
-				return []string{}, errors.Wrapf(BulkError, "creating alert decisions: %s", err)
+			if len(decisionBulk) > 0 {
+				decisions, err = tx.Decision.CreateBulk(decisionBulk...).Save(ctx)
+				if err != nil {
+					return []string{}, wrapDBError(err, BulkError, "creating alert decisions: %s", err)
 
+				}
 			}
 		}
 
-		alertB := c.Ent.Alert.
+		alertB := tx.Alert.
 			Create().
 			SetScenario(*alertItem.Scenario).
 			SetMessage(*alertItem.Message).
@@ -235,47 +463,245 @@ func (c *Client) CreateAlertBulk(machineId string, alertList []*models.Alert) ([
 			SetSimulated(*alertItem.Simulated).
 			SetScenarioVersion(*alertItem.ScenarioVersion).
 			SetScenarioHash(*alertItem.ScenarioHash).
+			SetBatchId(batchID).
 			AddDecisions(decisions...).
 			AddEvents(events...).
 			AddMetas(metas...)
 
+		if len(alertItem.Labels) > 0 {
+			tags, err := json.Marshal(alertItem.Labels)
+			if err != nil {
+				return []string{}, errors.Wrapf(MarshalFail, "alert labels '%v' : %s", alertItem.Labels, err)
+			}
+			alertB.SetTags(string(tags))
+		}
+		if keepIDs {
+			alertB.SetID(int(alertItem.ID))
+		}
+
 		if owner != nil {
 			alertB.SetOwner(owner)
 		}
 		bulk = append(bulk, alertB)
+	}
 
-		if len(bulk) == bulkSize {
-			alerts, err := c.Ent.Alert.CreateBulk(bulk...).Save(c.CTX)
-			if err != nil {
-				return []string{}, errors.Wrapf(BulkError, "bulk creating alert : %s", err)
-			}
-			for _, alert := range alerts {
-				ret = append(ret, strconv.Itoa(alert.ID))
-			}
+	alerts, err := tx.Alert.CreateBulk(bulk...).Save(ctx)
+	if err != nil {
+		return []string{}, wrapDBError(err, BulkError, "bulk creating alert : %s", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return []string{}, wrapDBError(err, BulkError, "committing alerts : %s", err)
+	}
+	tx = nil
 
-			if len(alertList)-i <= bulkSize {
-				bulk = make([]*ent.AlertCreate, 0, (len(alertList) - i))
-			} else {
-				bulk = make([]*ent.AlertCreate, 0, bulkSize)
-			}
+	ret := make([]string, 0, len(alerts))
+	for _, alert := range alerts {
+		ret = append(ret, strconv.Itoa(alert.ID))
+	}
+	return ret, nil
+}
+
+// activeDecisionWithKey returns the active decision expiring last with the same value, scope and origin as decisionItem, of the (normalized) decisionType
+// and matching the extra predicates, nil if there is none
+func activeDecisionWithKey(ctx context.Context, tx *ent.Tx, decisionItem *models.Decision, decisionType string, extra ...predicate.Decision) (*ent.Decision, error) {
+	existing, err := tx.Decision.Query().
+		Where(decision.ValueEQ(*decisionItem.Value)).
+		Where(decision.ScopeEQ(*decisionItem.Scope)).
+		Where(decision.TypeEQ(decisionType)).
+		Where(decision.OriginEQ(*decisionItem.Origin)).
+		Where(decision.UntilGTE(time.Now())).
+		Where(extra...).
+		Order(ent.Desc(decision.FieldUntil)).
+		First(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, wrapDBError(err, QueryFail, "active decision for '%s' : %s", *decisionItem.Value, err)
+	}
+	return existing, nil
+}
+
+// extendActiveDecision looks for an active decision with the same value, scope and origin as decisionItem, and of the (normalized) decisionType,
+// and pushes its expiration to until if it is later. It returns false if there is no such decision, so that a new one is created
+func extendActiveDecision(ctx context.Context, tx *ent.Tx, decisionItem *models.Decision, decisionType string, until time.Time) (bool, error) {
+	existing, err := activeDecisionWithKey(ctx, tx, decisionItem, decisionType)
+	if err != nil || existing == nil {
+		return false, err
+	}
+	if until.After(existing.Until) {
+		if err := tx.Decision.UpdateOne(existing).SetUntil(until).Exec(ctx); err != nil {
+			return false, wrapDBError(err, UpdateFail, "extending decision %d : %s", existing.ID, err)
+		}
+	}
+	return true, nil
+}
+
+// upsertActiveDecision looks for an active decision with the same value, scope, origin, (normalized) decisionType and simulated flag as decisionItem,
+// brought by an alert of the same owner (or by an ownerless alert if owner is nil), and sets its expiration to until.
+// It returns false if there is no such decision, so that a new one is created
+func upsertActiveDecision(ctx context.Context, tx *ent.Tx, owner *ent.Machine, decisionItem *models.Decision, decisionType string, simulated bool, until time.Time) (bool, error) {
+	sameOwner := alert.Not(alert.HasOwner())
+	if owner != nil {
+		sameOwner = alert.HasOwnerWith(machine.IDEQ(owner.ID))
+	}
+	existing, err := activeDecisionWithKey(ctx, tx, decisionItem, decisionType, decision.SimulatedEQ(simulated), decision.HasOwnerWith(sameOwner))
+	if err != nil || existing == nil {
+		return false, err
+	}
+	if !until.Equal(existing.Until) {
+		if err := tx.Decision.UpdateOne(existing).SetUntil(until).Exec(ctx); err != nil {
+			return false, wrapDBError(err, UpdateFail, "updating decision %d : %s", existing.ID, err)
+		}
+	}
+	return true, nil
+}
+
+// validateAlert checks that the mandatory fields of the alert are set, instead of dereferencing them blindly :
+// scenario, scenario_hash, scenario_version, message, events_count, start_at, stop_at, capacity, leakspeed, simulated
+// and source (with its scope and value), the timestamp of the events, and the duration, origin, scenario, scope, type and value of the decisions.
+func validateAlert(alertItem *models.Alert) error {
+	if alertItem == nil {
+		return errors.Wrap(InvalidAlert, "empty alert")
+	}
+	required := map[string]bool{
+		"scenario":         alertItem.Scenario != nil,
+		"scenario_hash":    alertItem.ScenarioHash != nil,
+		"scenario_version": alertItem.ScenarioVersion != nil,
+		"message":          alertItem.Message != nil,
+		"events_count":     alertItem.EventsCount != nil,
+		"start_at":         alertItem.StartAt != nil,
+		"stop_at":          alertItem.StopAt != nil,
+		"capacity":         alertItem.Capacity != nil,
+		"leakspeed":        alertItem.Leakspeed != nil,
+		"simulated":        alertItem.Simulated != nil,
+		"source":           alertItem.Source != nil,
+	}
+	if alertItem.Source != nil {
+		required["source.scope"] = alertItem.Source.Scope != nil
+		required["source.value"] = alertItem.Source.Value != nil
+	}
+	for i, eventItem := range alertItem.Events {
+		required[fmt.Sprintf("events[%d].timestamp", i)] = eventItem != nil && eventItem.Timestamp != nil
+	}
+	for i, metaItem := range alertItem.Meta {
+		required[fmt.Sprintf("meta[%d]", i)] = metaItem != nil
+	}
+	for i, decisionItem := range alertItem.Decisions {
+		required[fmt.Sprintf("decisions[%d]", i)] = decisionItem != nil
+		if decisionItem == nil {
+			continue
+		}
+		required[fmt.Sprintf("decisions[%d].duration", i)] = decisionItem.Duration != nil
+		required[fmt.Sprintf("decisions[%d].origin", i)] = decisionItem.Origin != nil
+		required[fmt.Sprintf("decisions[%d].scenario", i)] = decisionItem.Scenario != nil
+		required[fmt.Sprintf("decisions[%d].scope", i)] = decisionItem.Scope != nil
+		required[fmt.Sprintf("decisions[%d].type", i)] = decisionItem.Type != nil
+		required[fmt.Sprintf("decisions[%d].value", i)] = decisionItem.Value != nil
+	}
+
+	missing := []string{}
+	for name, isSet := range required {
+		if !isSet {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return errors.Wrapf(InvalidAlert, "missing mandatory fields: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// ImportAlerts creates the alerts like CreateAlertBulk, except that the alerts carrying an ID (ie. from a backup or another instance) keep it.
+// If one of these IDs is already used, the alert is skipped if skipConflicts is set, otherwise nothing is imported and an IDConflict error is returned.
+// The ids of the alerts that kept their ID come first in the returned list, followed by the ones of the alerts without ID.
+func (c *Client) ImportAlerts(machineId string, alertList []*models.Alert, skipConflicts bool) ([]string, error) {
+	var withID, withoutID []*models.Alert
+
+	for _, alertItem := range alertList {
+		if alertItem.ID > 0 {
+			withID = append(withID, alertItem)
+		} else {
+			withoutID = append(withoutID, alertItem)
 		}
 	}
 
-	alerts, err := c.Ent.Alert.CreateBulk(bulk...).Save(c.CTX)
+	withID, err := c.dropConflictingAlertIDs(withID, skipConflicts)
+	if err != nil {
+		return []string{}, err
+	}
+	ret, err := c.createAlertBulk(c.CTX, machineId, withID, true)
 	if err != nil {
-		return []string{}, errors.Wrapf(BulkError, "leftovers creating alert : %s", err)
+		return []string{}, err
 	}
+	if len(ret) > 0 {
+		if err := c.syncAlertIDSequence(); err != nil {
+			return []string{}, err
+		}
+	}
+	ids, err := c.createAlertBulk(c.CTX, machineId, withoutID, false)
+	if err != nil {
+		return []string{}, err
+	}
+	return append(ret, ids...), nil
+}
 
-	for _, alert := range alerts {
-		ret = append(ret, strconv.Itoa(alert.ID))
+// dropConflictingAlertIDs removes the alerts whose ID is already used (in the database or earlier in the list), or returns an IDConflict error
+func (c *Client) dropConflictingAlertIDs(alertList []*models.Alert, skipConflicts bool) ([]*models.Alert, error) {
+	used := make(map[int]bool, len(alertList))
+	for start := 0; start < len(alertList); start += paginationSize {
+		end := start + paginationSize
+		if end > len(alertList) {
+			end = len(alertList)
+		}
+		ids := make([]int, 0, end-start)
+		for _, alertItem := range alertList[start:end] {
+			ids = append(ids, int(alertItem.ID))
+		}
+		existing, err := c.Ent.Alert.Query().Where(alert.IDIn(ids...)).IDs(c.CTX)
+		if err != nil {
+			c.logger().Warningf("ImportAlerts : %s", err)
+			return nil, errors.Wrap(QueryFail, "looking for existing alert ids")
+		}
+		for _, id := range existing {
+			used[id] = true
+		}
 	}
 
+	ret := make([]*models.Alert, 0, len(alertList))
+	for _, alertItem := range alertList {
+		id := int(alertItem.ID)
+		if used[id] {
+			if !skipConflicts {
+				return nil, errors.Wrapf(IDConflict, "alert %d", id)
+			}
+			c.logger().Warningf("ImportAlerts : alert %d already exists, skipping it", id)
+			continue
+		}
+		used[id] = true
+		ret = append(ret, alertItem)
+	}
 	return ret, nil
 }
 
+// syncAlertIDSequence makes postgres' sequence follow the ids inserted explicitly, other backends don't need it
+func (c *Client) syncAlertIDSequence() error {
+	if c.drv == nil || c.drv.Dialect() != dialect.Postgres {
+		return nil
+	}
+	query := fmt.Sprintf("SELECT setval(pg_get_serial_sequence('%s', '%s'), (SELECT MAX(%s) FROM %s))", alert.Table, alert.FieldID, alert.FieldID, alert.Table)
+	if _, err := c.drv.ExecContext(c.CTX, query); err != nil {
+		c.logger().Warningf("ImportAlerts : %s", err)
+		return errors.Wrapf(UpdateFail, "alert id sequence: %s", err)
+	}
+	return nil
+}
+
 func BuildAlertRequestFromFilter(alerts *ent.AlertQuery, filter map[string][]string) (*ent.AlertQuery, error) {
 	var err error
-	var startIP, endIP int64
+	var ipSize int
+	var startIP, startSuffix, endIP, endSuffix int64
 	var hasActiveDecision bool
 
 	/*the simulated filter is a bit different : if it's not present *or* set to false, specifically exclude records with simulated to true */
@@ -283,165 +709,859 @@ func BuildAlertRequestFromFilter(alerts *ent.AlertQuery, filter map[string][]str
 		if v[0] == "false" {
 			alerts = alerts.Where(alert.SimulatedEQ(false))
 		}
-		delete(filter, "simulated")
+	}
+	/*the archived alerts are hidden unless include_archived is true*/
+	includeArchived := false
+	if v, ok := filter["include_archived"]; ok {
+		if includeArchived, err = strconv.ParseBool(v[0]); err != nil {
+			return nil, errors.Wrapf(ParseType, "'%s' is not a boolean: %s", v[0], err)
+		}
+	}
+	if !includeArchived {
+		alerts = alerts.Where(notArchived())
+	}
+	/*decisions carry their own simulated flag : decision_simulated restricts has_active_decision to the decisions with the given flag*/
+	activeDecision := []predicate.Decision{}
+	decisionSimulated, err := decisionSimulatedFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	if decisionSimulated != nil {
+		activeDecision = append(activeDecision, decision.SimulatedEQ(*decisionSimulated))
 	}
 
 	for param, value := range filter {
 		switch param {
+		case "simulated":
+			/*already handled, and kept in the filter as it can be used to build several queries*/
+			continue
+		case "include_archived":
+			continue
+		case "decision_simulated":
+			alerts = alerts.Where(alert.HasDecisionsWith(activeDecision...))
+		/*scope, value and scenario match any of their values when repeated (ie. scenario=a&scenario=b)*/
 		case "scope":
-			var scope string = value[0]
-			if strings.ToLower(scope) == "ip" {
-				scope = types.Ip
-			} else if strings.ToLower(scope) == "range" {
-				scope = types.Range
+			scopes := []string{}
+			for _, scopeList := range value {
+				for _, scope := range strings.Split(scopeList, ",") {
+					scopes = append(scopes, normalizeScope(scope))
+				}
 			}
-			alerts = alerts.Where(alert.SourceScopeEQ(scope))
+			alerts = alerts.Where(alert.SourceScopeIn(scopes...))
 		case "value":
-			alerts = alerts.Where(alert.SourceValueEQ(value[0]))
+			alerts = alerts.Where(alert.SourceValueIn(value...))
 		case "scenario":
-			alerts = alerts.Where(alert.ScenarioEQ(value[0]))
-		case "ip":
-			isValidIP := IsIpv4(value[0])
-			if !isValidIP {
-				return nil, errors.Wrapf(InvalidIPOrRange, "unable to parse '%s': %s", value[0], err)
+			alerts = alerts.Where(alert.ScenarioIn(value...))
+		case "scenario_version":
+			if value[0] == "" {
+				return nil, errors.Wrapf(InvalidFilter, "scenario_version can't be empty")
+			}
+			alerts = alerts.Where(alert.ScenarioVersionEQ(value[0]))
+		case "scenario_like":
+			/*ie. "http-" for all the http scenarios, whatever their author*/
+			if value[0] == "" {
+				return nil, errors.Wrapf(InvalidFilter, "scenario_like can't be empty")
 			}
-			startIP, endIP, err = GetIpsFromIpRange(value[0] + "/32")
+			alerts = alerts.Where(alert.ScenarioContains(value[0]))
+		case "ip":
+			ipSize, startIP, startSuffix, endIP, endSuffix, err = getIpBoundsFromIp(value[0])
 			if err != nil {
 				return nil, errors.Wrapf(InvalidIPOrRange, "unable to convert '%s' to int interval: %s", value[0], err)
 			}
 		case "range":
-			startIP, endIP, err = GetIpsFromIpRange(value[0])
+			ipSize, startIP, startSuffix, endIP, endSuffix, err = GetIpBoundsFromIpRange(value[0])
 			if err != nil {
 				return nil, errors.Wrapf(InvalidIPOrRange, "unable to convert '%s' to int interval: %s", value[0], err)
 			}
 		case "since":
-			duration, err := types.ParseDuration(value[0])
+			duration, err := ParseDurationExtended(value[0])
 			if err != nil {
 				return nil, errors.Wrap(err, "while parsing duration")
 			}
+			if duration <= 0 {
+				return nil, errors.Wrapf(InvalidFilter, "since duration must be positive (got '%s')", value[0])
+			}
 			since := time.Now().Add(-duration)
 			if since.IsZero() {
 				return nil, fmt.Errorf("Empty time now() - %s", since.String())
 			}
 			alerts = alerts.Where(alert.StartedAtGTE(since))
+		/*created_before and created_after take an absolute RFC3339 date, or a duration before now*/
 		case "created_before":
-			duration, err := types.ParseDuration(value[0])
+			before, err := parseCreationBound(param, value[0])
 			if err != nil {
-				return nil, errors.Wrap(err, "while parsing duration")
+				return nil, err
 			}
-			since := time.Now().Add(-duration)
-			if since.IsZero() {
-				return nil, fmt.Errorf("Empty time now() - %s", since.String())
+			alerts = alerts.Where(alert.CreatedAtLTE(before))
+		case "created_after":
+			after, err := parseCreationBound(param, value[0])
+			if err != nil {
+				return nil, err
 			}
-			alerts = alerts.Where(alert.CreatedAtLTE(since))
+			alerts = alerts.Where(alert.CreatedAtGTE(after))
 		case "until":
-			duration, err := types.ParseDuration(value[0])
+			duration, err := ParseDurationExtended(value[0])
 			if err != nil {
 				return nil, errors.Wrap(err, "while parsing duration")
 			}
+			if duration <= 0 {
+				return nil, errors.Wrapf(InvalidFilter, "until duration must be positive (got '%s')", value[0])
+			}
 			until := time.Now().Add(-duration)
 			if until.IsZero() {
 				return nil, fmt.Errorf("Empty time now() - %s", until.String())
 			}
 			alerts = alerts.Where(alert.StartedAtLTE(until))
+		case "events_count_gte", "events_count_lte":
+			eventsCount, err := strconv.ParseInt(value[0], 10, 32)
+			if err != nil {
+				return nil, errors.Wrapf(ParseType, "'%s' is not an integer: %s", value[0], err)
+			}
+			if param == "events_count_gte" {
+				alerts = alerts.Where(alert.EventsCountGTE(int32(eventsCount)))
+			} else {
+				alerts = alerts.Where(alert.EventsCountLTE(int32(eventsCount)))
+			}
+		case "as_number", "as_in":
+			/*as_in is an alias of as_number*/
+			asNumbers, err := nonEmptyFilterList(param, value)
+			if err != nil {
+				return nil, err
+			}
+			alerts = alerts.Where(alert.SourceAsNumberIn(asNumbers...))
+		case "country":
+			countries, err := nonEmptyFilterList(param, value)
+			if err != nil {
+				return nil, err
+			}
+			for i, country := range countries {
+				countries[i] = strings.ToUpper(country)
+			}
+			alerts = alerts.Where(alert.SourceCountryIn(countries...))
+		case "continent":
+			countries, ok := countriesOfContinent(value[0])
+			if !ok {
+				return nil, errors.Wrapf(InvalidFilter, "unknown continent '%s' (expected AF, AN, AS, EU, NA, OC or SA)", value[0])
+			}
+			alerts = alerts.Where(alert.SourceCountryIn(countries...))
+		case "decision_value_contains":
+			/*substring matching only makes sense for string scopes (username, country...), ips and ranges have the ip/range filters*/
+			if v, ok := filter["scope"]; ok {
+				for _, scope := range strings.Split(v[0], ",") {
+					if scope = normalizeScope(scope); scope == types.Ip || scope == types.Range {
+						return nil, errors.Wrapf(InvalidFilter, "decision_value_contains can't be used with scope '%s', use ip or range instead", scope)
+					}
+				}
+			}
+			alerts = alerts.Where(alert.HasDecisionsWith(
+				decision.ScopeNotIn(types.Ip, types.Range),
+				decision.ValueContainsFold(value[0]),
+			))
+		case "range_prefix_max":
+			/*flags the wide bans : 16 matches the decisions on a /16 or broader*/
+			prefix, err := strconv.Atoi(value[0])
+			if err != nil || prefix < 0 || prefix > 32 {
+				return nil, errors.Wrapf(InvalidFilter, "range_prefix_max '%s' must be a prefix length between 0 and 32", value[0])
+			}
+			alerts = alerts.Where(alert.HasDecisionsWith(ipv4Decision(), rangeBroaderThanDecision(prefix)))
+		case "batch_id":
+			alerts = alerts.Where(alert.BatchIdEQ(value[0]))
+		case "tag":
+			/*tags are stored as a JSON list, look for the JSON encoded tag*/
+			tag, err := json.Marshal(value[0])
+			if err != nil {
+				return nil, errors.Wrapf(InvalidFilter, "invalid tag '%s': %s", value[0], err)
+			}
+			alerts = alerts.Where(alert.TagsContains(string(tag)))
+		case "ip_class":
+			switch value[0] {
+			/*the class is the one of the source itself, whatever the decisions of the alert*/
+			case "private":
+				alerts = alerts.Where(alert.SourceScopeEQ(types.Ip), privateIPSource())
+			case "public":
+				alerts = alerts.Where(alert.SourceScopeEQ(types.Ip), alert.Not(privateIPSource()))
+			default:
+				return nil, errors.Wrapf(InvalidFilter, "invalid ip_class '%s' (expected private or public)", value[0])
+			}
 		case "decision_type":
 			alerts = alerts.Where(alert.HasDecisionsWith(decision.TypeEQ(value[0])))
+		case "without_decision_type":
+			/*the alerts with no decision of the given types, active or not. With decision_type, both apply : ie. decision_type=captcha
+			and without_decision_type=ban are the alerts with a captcha decision but no ban, giving the same type to both matches nothing*/
+			if strings.TrimSpace(value[0]) == "" {
+				return nil, errors.Wrapf(InvalidFilter, "without_decision_type can't be empty")
+			}
+			alerts = alerts.Where(alert.Not(alert.HasDecisionsWith(decision.TypeIn(splitFilterList(value[0])...))))
+		case "meta":
+			/*"key:value" for the alerts with a meta key whose value contains value, all of them must match when repeated*/
+			for _, keyValue := range value {
+				parts := strings.SplitN(keyValue, ":", 2)
+				if len(parts) != 2 || parts[0] == "" {
+					return nil, errors.Wrapf(InvalidFilter, "meta filter '%s' isn't in the key:value form", keyValue)
+				}
+				alerts = alerts.Where(alert.HasMetasWith(meta.KeyEQ(parts[0]), meta.ValueContains(parts[1])))
+			}
+		case "machine_id":
+			/*"none" for the alerts without owner (ie. pushed while the machine didn't exist)*/
+			if value[0] == "none" {
+				alerts = alerts.Where(alert.Not(alert.HasOwner()))
+			} else {
+				alerts = alerts.Where(alert.HasOwnerWith(machine.MachineIdEQ(value[0])))
+			}
+		case "decision_scope":
+			if strings.TrimSpace(value[0]) == "" {
+				return nil, errors.Wrapf(InvalidFilter, "decision_scope can't be empty")
+			}
+			scopes := splitFilterList(value[0])
+			for i, scope := range scopes {
+				scopes[i] = normalizeScope(scope)
+			}
+			alerts = alerts.Where(alert.HasDecisionsWith(decision.ScopeIn(scopes...)))
+		case "origin_prefix":
+			/*ie. "lists:" for the decisions of all the subscribed blocklists*/
+			alerts = alerts.Where(alert.HasDecisionsWith(decision.OriginHasPrefix(value[0])))
+		case "origin":
+			alerts = alerts.Where(alert.HasDecisionsWith(decision.OriginIn(splitFilterList(value[0])...)))
+		case "origin_not":
+			alerts = alerts.Where(decisionsFromOtherOrigins(splitFilterList(value[0])))
 		case "include_capi": //allows to exclude one or more specific origins
 			if value[0] == "false" {
-				alerts = alerts.Where(alert.HasDecisionsWith(decision.OriginNEQ("CAPI")))
+				alerts = alerts.Where(decisionsFromOtherOrigins([]string{"CAPI"}))
 			} else if value[0] != "true" {
-				log.Errorf("Invalid bool '%s' for include_capi", value[0])
+				return nil, errors.Wrapf(InvalidFilter, "invalid bool '%s' for include_capi", value[0])
 			}
 		case "has_active_decision":
 			if hasActiveDecision, err = strconv.ParseBool(value[0]); err != nil {
 				return nil, errors.Wrapf(ParseType, "'%s' is not a boolean: %s", value[0], err)
 			}
 			if hasActiveDecision {
-				alerts = alerts.Where(alert.HasDecisionsWith(decision.UntilGTE(time.Now())))
+				alerts = alerts.Where(alert.HasDecisionsWith(append(activeDecision, decision.UntilGTE(time.Now()))...))
 			} else {
 				alerts = alerts.Where(alert.Not(alert.HasDecisions()))
 			}
+		case "no_events":
+			noEvents, err := strconv.ParseBool(value[0])
+			if err != nil {
+				return nil, errors.Wrapf(ParseType, "'%s' is not a boolean: %s", value[0], err)
+			}
+			if noEvents {
+				alerts = alerts.Where(alert.Not(alert.HasEvents()))
+			} else {
+				alerts = alerts.Where(alert.HasEvents())
+			}
+		case "acknowledged":
+			acknowledged, err := strconv.ParseBool(value[0])
+			if err != nil {
+				return nil, errors.Wrapf(ParseType, "'%s' is not a boolean: %s", value[0], err)
+			}
+			if acknowledged {
+				alerts = alerts.Where(alert.AcknowledgedEQ(true))
+			} else {
+				alerts = alerts.Where(alert.Or(alert.AcknowledgedEQ(false), alert.AcknowledgedIsNil()))
+			}
 		case "limit":
 			continue
+		case "offset":
+			continue
+		case "last":
+			continue
+		case "active_decisions_only":
+			continue
 		case "sort":
 			continue
+		case "order":
+			continue
 		default:
 			return nil, errors.Wrapf(InvalidFilter, "Filter parameter '%s' is unknown (=%s)", param, value[0])
 		}
 	}
-	if startIP != 0 && endIP != 0 {
-		/*the user is checking for a single IP*/
-		if startIP == endIP {
-			//DECISION_START <= IP_Q >= DECISON_END
-			alerts = alerts.Where(alert.And(
-				alert.HasDecisionsWith(decision.StartIPLTE(startIP)),
-				alert.HasDecisionsWith(decision.EndIPGTE(endIP)),
-			))
-		} else { /*the user is checking for a RANGE */
-			//START_Q >= DECISION_START AND END_Q <= DECISION_END
-			alerts = alerts.Where(alert.And(
-				alert.HasDecisionsWith(decision.StartIPGTE(startIP)),
-				alert.HasDecisionsWith(decision.EndIPLTE(endIP)),
-			))
-		}
+	/*both bounds must hold for the same decision : a decision ending before the ip and another one starting after it don't contain it*/
+	if ipFilter := decisionIPFilter(ipSize, startIP, startSuffix, endIP, endSuffix); ipFilter != nil {
+		alerts = alerts.Where(alert.HasDecisionsWith(ipFilter))
 	}
 	return alerts, nil
 }
 
-func (c *Client) TotalAlerts() (int, error) {
-	return c.Ent.Alert.Query().Count(c.CTX)
+// splitFilterList returns the items of a comma-separated filter value
+func splitFilterList(value string) []string {
+	items := strings.Split(value, ",")
+	for i, item := range items {
+		items[i] = strings.TrimSpace(item)
+	}
+	return items
 }
 
-func (c *Client) QueryAlertWithFilter(filter map[string][]string) ([]*ent.Alert, error) {
-	sort := "DESC" // we sort by desc by default
-	if val, ok := filter["sort"]; ok {
-		if val[0] != "ASC" && val[0] != "DESC" {
-			log.Errorf("invalid 'sort' parameter: %s", val)
-		} else {
-			sort = val[0]
-		}
+// parseCreationBound parses the value of the created_before and created_after filters : either an RFC3339 date, or a duration before now (as FlushAlerts uses)
+func parseCreationBound(param string, value string) (time.Time, error) {
+	if bound, err := time.Parse(time.RFC3339, value); err == nil {
+		return bound, nil
 	}
-	limit := defaultLimit
-	if val, ok := filter["limit"]; ok {
-		limitConv, err := strconv.Atoi(val[0])
-		if err != nil {
-			return []*ent.Alert{}, errors.Wrapf(QueryFail, "bad limit in parameters: %s", val)
-		}
-		limit = limitConv
+	duration, err := ParseDurationExtended(value)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(ParseTimeFail, "%s '%s' is neither an RFC3339 date nor a duration", param, value)
+	}
+	return time.Now().Add(-duration), nil
+}
 
+// decisionSimulatedFilter returns the value of the decision_simulated filter, nil if it isn't set
+func decisionSimulatedFilter(filter map[string][]string) (*bool, error) {
+	v, ok := filter["decision_simulated"]
+	if !ok {
+		return nil, nil
 	}
-	offset := 0
-	ret := make([]*ent.Alert, 0)
-	for {
-		alerts := c.Ent.Alert.Query()
-		alerts, err := BuildAlertRequestFromFilter(alerts, filter)
-		if err != nil {
-			return []*ent.Alert{}, err
-		}
-		alerts = alerts.
-			WithDecisions().
-			WithEvents().
-			WithMetas().
+	simulated, err := strconv.ParseBool(v[0])
+	if err != nil {
+		return nil, errors.Wrapf(ParseType, "'%s' is not a boolean: %s", v[0], err)
+	}
+	return &simulated, nil
+}
+
+// nonEmptyFilterList returns all the comma separated values of a repeated filter parameter, or an InvalidFilter error if one of them is empty
+func nonEmptyFilterList(param string, values []string) ([]string, error) {
+	ret := []string{}
+	for _, value := range values {
+		for _, item := range splitFilterList(value) {
+			if item == "" {
+				return nil, errors.Wrapf(InvalidFilter, "%s can't be empty (got '%s')", param, value)
+			}
+			ret = append(ret, item)
+		}
+	}
+	return ret, nil
+}
+
+// decisionsFromOtherOrigins matches the alerts having decisions from other origins than the given ones
+func decisionsFromOtherOrigins(origins []string) predicate.Alert {
+	return alert.HasDecisionsWith(decision.OriginNotIn(origins...))
+}
+
+// setDecisionIPBounds stores the addresses covered by the decision : the client provides them for IPv4, but
+// IPv6 ones don't fit in the model and are computed here from the value of Ip and Range decisions.
+// Reversed bounds, that no address would ever match, are refused unless Client.SwapReversedIPBounds is set
+func (c *Client) setDecisionIPBounds(create *ent.DecisionCreate, decisionItem *models.Decision) (*ent.DecisionCreate, error) {
+	cidr := *decisionItem.Value
+	switch normalizeScope(*decisionItem.Scope) {
+	case types.Ip:
+		if !IsIpv6(cidr) {
+			cidr = ""
+			break
+		}
+		cidr += "/128"
+	case types.Range:
+	default:
+		cidr = ""
+	}
+	if cidr != "" {
+		ipSize, startIP, startSuffix, endIP, endSuffix, err := GetIpBoundsFromIpRange(cidr)
+		if err == nil && ipSize == 16 {
+			if startIP, startSuffix, endIP, endSuffix, err = c.checkIPBoundsOrder(decisionItem, startIP, startSuffix, endIP, endSuffix); err != nil {
+				return nil, err
+			}
+			return create.SetIPSize(int64(ipSize)).
+				SetStartIP(startIP).
+				SetStartSuffix(startSuffix).
+				SetEndIP(endIP).
+				SetEndSuffix(endSuffix), nil
+		}
+	}
+	if decisionItem.StartIP != 0 || decisionItem.EndIP != 0 {
+		startIP, _, endIP, _, err := c.checkIPBoundsOrder(decisionItem, decisionItem.StartIP, 0, decisionItem.EndIP, 0)
+		if err != nil {
+			return nil, err
+		}
+		return create.SetIPSize(4).SetStartIP(startIP).SetEndIP(endIP), nil
+	}
+	return create, nil
+}
+
+// checkIPBoundsOrder makes sure the start of the decision comes before its end, comparing the suffixes of IPv6 addresses
+// when their first halves are equal. Reversed bounds are an InvalidIPOrRange error, or swapped if Client.SwapReversedIPBounds is set
+func (c *Client) checkIPBoundsOrder(decisionItem *models.Decision, startIP, startSuffix, endIP, endSuffix int64) (int64, int64, int64, int64, error) {
+	if startIP < endIP || (startIP == endIP && startSuffix <= endSuffix) {
+		return startIP, startSuffix, endIP, endSuffix, nil
+	}
+	if !c.SwapReversedIPBounds {
+		return 0, 0, 0, 0, errors.Wrapf(InvalidIPOrRange, "decision on '%s' : start ip %d is after end ip %d", *decisionItem.Value, startIP, endIP)
+	}
+	c.logger().Warningf("decision on '%s' : swapping reversed start ip %d and end ip %d", *decisionItem.Value, startIP, endIP)
+	return endIP, endSuffix, startIP, startSuffix, nil
+}
+
+// ipv4Decision matches the decisions on IPv4 addresses, including the ones stored before ip_size existed
+func ipv4Decision() predicate.Decision {
+	return decision.Or(decision.IPSizeIsNil(), decision.IPSizeEQ(4))
+}
+
+// decisionIPFilter matches the decisions of the family of the ip or range filter (see GetIpBoundsFromIpRange) containing its single address,
+// or within its range. It returns nil if ipSize is 0, ie. there is no such filter
+func decisionIPFilter(ipSize int, startIP int64, startSuffix int64, endIP int64, endSuffix int64) predicate.Decision {
+	switch ipSize {
+	case 4:
+		if startIP == endIP {
+			//DECISION_START <= IP_Q <= DECISION_END
+			return decision.And(ipv4Decision(), decision.StartIPLTE(startIP), decision.EndIPGTE(endIP))
+		}
+		//START_Q <= DECISION_START AND DECISION_END <= END_Q
+		return decision.And(ipv4Decision(), decision.StartIPGTE(startIP), decision.EndIPLTE(endIP))
+	case 16:
+		if startIP == endIP && startSuffix == endSuffix {
+			return ipv6DecisionContains(startIP, startSuffix)
+		}
+		return ipv6DecisionWithin(startIP, startSuffix, endIP, endSuffix)
+	}
+	return nil
+}
+
+// ipv6DecisionContains matches the IPv6 decisions covering the address (ip, suffix)
+func ipv6DecisionContains(ip int64, suffix int64) predicate.Decision {
+	return decision.And(
+		decision.IPSizeEQ(16),
+		//DECISION_START <= IP_Q
+		decision.Or(
+			decision.StartIPLT(ip),
+			decision.And(decision.StartIPEQ(ip), decision.StartSuffixLTE(suffix)),
+		),
+		//DECISION_END >= IP_Q
+		decision.Or(
+			decision.EndIPGT(ip),
+			decision.And(decision.EndIPEQ(ip), decision.EndSuffixGTE(suffix)),
+		),
+	)
+}
+
+// ipv6DecisionWithin matches the IPv6 decisions entirely within the range going from (startIP, startSuffix) to (endIP, endSuffix)
+func ipv6DecisionWithin(startIP int64, startSuffix int64, endIP int64, endSuffix int64) predicate.Decision {
+	return decision.And(
+		decision.IPSizeEQ(16),
+		//DECISION_START >= START_Q
+		decision.Or(
+			decision.StartIPGT(startIP),
+			decision.And(decision.StartIPEQ(startIP), decision.StartSuffixGTE(startSuffix)),
+		),
+		//DECISION_END <= END_Q
+		decision.Or(
+			decision.EndIPLT(endIP),
+			decision.And(decision.EndIPEQ(endIP), decision.EndSuffixLTE(endSuffix)),
+		),
+	)
+}
+
+// ipv6DecisionOverlaps matches the IPv6 decisions covering at least one address of the range going from (startIP, startSuffix) to (endIP, endSuffix)
+func ipv6DecisionOverlaps(startIP int64, startSuffix int64, endIP int64, endSuffix int64) predicate.Decision {
+	return decision.And(
+		decision.IPSizeEQ(16),
+		//DECISION_START <= END_Q
+		decision.Or(
+			decision.StartIPLT(endIP),
+			decision.And(decision.StartIPEQ(endIP), decision.StartSuffixLTE(endSuffix)),
+		),
+		//DECISION_END >= START_Q
+		decision.Or(
+			decision.EndIPGT(startIP),
+			decision.And(decision.EndIPEQ(startIP), decision.EndSuffixGTE(startSuffix)),
+		),
+	)
+}
+
+// privateIPSource matches the alerts whose source value is an address of one of the RFC1918 ranges
+func privateIPSource() predicate.Alert {
+	prefixes := make([]predicate.Alert, 0, len(privateIPPrefixes))
+	for _, prefix := range privateIPPrefixes {
+		prefixes = append(prefixes, alert.SourceValueHasPrefix(prefix))
+	}
+	return alert.Or(prefixes...)
+}
+
+// notArchived matches the alerts that aren't archived, including the ones stored before the archived column existed (NULL)
+func notArchived() predicate.Alert {
+	return alert.Or(alert.ArchivedEQ(false), alert.ArchivedIsNil())
+}
+
+// rangeBroaderThanDecision matches decisions covering at least as many addresses as a /prefix range (ie. end_ip - start_ip + 1 >= 2^(32-prefix))
+func rangeBroaderThanDecision(prefix int) predicate.Decision {
+	minSpan := int64(1)<<uint(32-prefix) - 1
+	return predicate.Decision(func(s *sql.Selector) {
+		s.Where(sql.P(func(b *sql.Builder) {
+			b.Ident(s.C(decision.FieldEndIP)).WriteString(" - ").Ident(s.C(decision.FieldStartIP))
+			b.WriteOp(sql.OpGTE).Arg(minSpan)
+		}))
+	})
+}
+
+// RepeatOffender is a source value along with its number of alerts and the time of the most recent one
+type RepeatOffender struct {
+	Value    string
+	Count    int
+	LastSeen time.Time
+}
+
+// RepeatOffenders returns the source values of the alerts matching the filter, the most active ones first
+func (c *Client) RepeatOffenders(filter map[string][]string, limit int) ([]RepeatOffender, error) {
+	var groups []struct {
+		SourceValue string         `json:"source_value"`
+		Count       int            `json:"count"`
+		LastSeen    aggregatedTime `json:"last_seen"`
+	}
+
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	alerts, err := BuildAlertRequestFromFilter(c.Ent.Alert.Query(), filter)
+	if err != nil {
+		return []RepeatOffender{}, err
+	}
+	/*the groups are sorted and limited by the database, the most recently seen first when they have as many alerts*/
+	err = alerts.
+		Order(func(s *sql.Selector, _ func(string) bool) {
+			s.OrderBy(sql.Desc("count"), sql.Desc("last_seen"), sql.Asc(alert.FieldSourceValue))
+		}).
+		Limit(limit).
+		GroupBy(alert.FieldSourceValue).
+		Aggregate(ent.As(ent.Count(), "count"), ent.As(ent.Max(alert.FieldCreatedAt), "last_seen")).
+		Scan(c.CTX, &groups)
+	if err != nil {
+		c.logger().Warningf("RepeatOffenders : %s", err)
+		return []RepeatOffender{}, errors.Wrap(QueryFail, "group alerts by source value")
+	}
+
+	ret := make([]RepeatOffender, 0, len(groups))
+	for _, group := range groups {
+		ret = append(ret, RepeatOffender{
+			Value:    group.SourceValue,
+			Count:    group.Count,
+			LastSeen: group.LastSeen.Time,
+		})
+	}
+	return ret, nil
+}
+
+// AlertsCountByScenario returns the number of alerts of each scenario created since the given time
+func (c *Client) AlertsCountByScenario(since time.Time) (map[string]int, error) {
+	var groups []struct {
+		Scenario string `json:"scenario"`
+		Count    int    `json:"count"`
+	}
+	err := c.Ent.Alert.Query().
+		Where(alert.CreatedAtGTE(since)).
+		GroupBy(alert.FieldScenario).
+		Aggregate(ent.Count()).
+		Scan(c.CTX, &groups)
+	if err != nil {
+		c.logger().Warningf("AlertsCountByScenario : %s", err)
+		return map[string]int{}, errors.Wrapf(QueryFail, "group alerts created since %s by scenario", since)
+	}
+	ret := make(map[string]int, len(groups))
+	for _, group := range groups {
+		ret[group.Scenario] = group.Count
+	}
+	return ret, nil
+}
+
+// AlertsCountByDecisionOrigin returns the number of alerts created since the given date, by origin of their decisions (crowdsec, CAPI, lists, cscli...).
+// An alert with decisions of several origins is counted once for each of them, but only once for an origin whatever its number of decisions from it
+func (c *Client) AlertsCountByDecisionOrigin(since time.Time) (map[string]int, error) {
+	var groups []struct {
+		Origin string `json:"origin"`
+		Count  int    `json:"count"`
+	}
+	countAlerts := func(s *sql.Selector, _ func(string) bool) string {
+		return sql.Count(sql.Distinct(s.C(decision.OwnerColumn)))
+	}
+	err := c.Ent.Decision.Query().
+		Where(decision.HasOwnerWith(alert.CreatedAtGTE(since))).
+		GroupBy(decision.FieldOrigin).
+		Aggregate(ent.As(countAlerts, "count")).
+		Scan(c.CTX, &groups)
+	if err != nil {
+		c.logger().Warningf("AlertsCountByDecisionOrigin : %s", err)
+		return map[string]int{}, errors.Wrapf(QueryFail, "group alerts created since %s by decision origin", since)
+	}
+	ret := make(map[string]int, len(groups))
+	for _, group := range groups {
+		ret[group.Origin] = group.Count
+	}
+	return ret, nil
+}
+
+// SourceValueCount is a source value (ie. an ip) along with the number of alerts it triggered
+type SourceValueCount struct {
+	Value string
+	Count int
+}
+
+// TopSourceValues returns the limit source values (all of them if not positive) with the most alerts created since the given date, the noisiest first.
+// Only the sources of the given scope are counted, unless scope is empty
+func (c *Client) TopSourceValues(since time.Time, limit int, scope string) ([]SourceValueCount, error) {
+	var groups []struct {
+		SourceValue string `json:"source_value"`
+		Count       int    `json:"count"`
+	}
+	query := c.Ent.Alert.Query().Where(alert.CreatedAtGTE(since))
+	if scope != "" {
+		query = query.Where(alert.SourceScopeEQ(normalizeScope(scope)))
+	}
+	/*the groups are sorted and limited by the database*/
+	query = query.Order(func(s *sql.Selector, _ func(string) bool) {
+		s.OrderBy(sql.Desc("count"), sql.Asc(alert.FieldSourceValue))
+	})
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	err := query.
+		GroupBy(alert.FieldSourceValue).
+		Aggregate(ent.As(ent.Count(), "count")).
+		Scan(c.CTX, &groups)
+	if err != nil {
+		c.logger().Warningf("TopSourceValues : %s", err)
+		return []SourceValueCount{}, errors.Wrapf(QueryFail, "group alerts created since %s by source value", since)
+	}
+	ret := make([]SourceValueCount, 0, len(groups))
+	for _, group := range groups {
+		ret = append(ret, SourceValueCount{Value: group.SourceValue, Count: group.Count})
+	}
+	return ret, nil
+}
+
+// SumEventsCount returns the total number of events of the alerts created since the given date, 0 if there is none
+func (c *Client) SumEventsCount(since time.Time) (int, error) {
+	var sum sql.NullInt64
+
+	if c.drv == nil {
+		return 0, errors.Wrap(QueryFail, "no database driver available")
+	}
+	selector := sql.Dialect(c.drv.Dialect()).Select().From(sql.Table(alert.Table))
+	query, args := selector.
+		Select(sql.Sum(selector.C(alert.FieldEventsCount))).
+		Where(sql.GTE(selector.C(alert.FieldCreatedAt), since)).
+		Query()
+	if err := c.drv.DB().QueryRowContext(c.CTX, query, args...).Scan(&sum); err != nil {
+		c.logger().Warningf("SumEventsCount : %s", err)
+		return 0, wrapDBError(err, QueryFail, "sum events of alerts created since %s: %s", since, err)
+	}
+	return int(sum.Int64), nil
+}
+
+func (c *Client) TotalAlerts() (int, error) {
+	return c.Ent.Alert.Query().Count(c.CTX)
+}
+
+// RecentCounts returns the number of alerts and decisions created during the last window, both counted in the same transaction
+func (c *Client) RecentCounts(window time.Duration) (int, int, error) {
+	since := time.Now().Add(-window)
+	tx, err := c.Ent.Tx(c.CTX)
+	if err != nil {
+		return 0, 0, errors.Wrapf(QueryFail, "starting transaction: %s", err)
+	}
+	nbAlerts, nbDecisions, err := c.countCreatedSince(tx.Client(), since)
+	if err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			c.logger().Warningf("RecentCounts : rollback failed: %s", rbErr)
+		}
+		return 0, 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, 0, errors.Wrapf(QueryFail, "ending transaction: %s", err)
+	}
+	return nbAlerts, nbDecisions, nil
+}
+
+func (c *Client) countCreatedSince(client *ent.Client, since time.Time) (int, int, error) {
+	nbAlerts, err := client.Alert.Query().Where(alert.CreatedAtGTE(since)).Count(c.CTX)
+	if err != nil {
+		c.logger().Warningf("RecentCounts : %s", err)
+		return 0, 0, errors.Wrapf(QueryFail, "counting alerts since %s", since)
+	}
+	nbDecisions, err := client.Decision.Query().Where(decision.CreatedAtGTE(since)).Count(c.CTX)
+	if err != nil {
+		c.logger().Warningf("RecentCounts : %s", err)
+		return 0, 0, errors.Wrapf(QueryFail, "counting decisions since %s", since)
+	}
+	return nbAlerts, nbDecisions, nil
+}
+
+func (c *Client) QueryAlertWithFilter(filter map[string][]string) ([]*ent.Alert, error) {
+	return c.QueryAlertWithFilterCtx(c.CTX, filter)
+}
+
+// QueryAlertWithFilterCtx is QueryAlertWithFilter bounded by ctx : if it's cancelled or its deadline is exceeded, ctx.Err() is returned.
+func (c *Client) QueryAlertWithFilterCtx(ctx context.Context, filter map[string][]string) ([]*ent.Alert, error) {
+	ret, err := c.queryAlertWithFilter(ctx, c.Ent, filter)
+	if err != nil && ctx.Err() != nil {
+		return []*ent.Alert{}, errors.Wrapf(ctx.Err(), "querying alerts: %s", err)
+	}
+	return ret, err
+}
+
+// CountAlertsWithFilter returns the number of alerts matching the filter, without loading them. The limit and offset keys don't apply.
+func (c *Client) CountAlertsWithFilter(filter map[string][]string) (int, error) {
+	alerts, err := BuildAlertRequestFromFilter(c.Ent.Alert.Query(), filter)
+	if err != nil {
+		return 0, err
+	}
+	count, err := alerts.Count(c.CTX)
+	if err != nil {
+		c.logger().Warningf("CountAlertsWithFilter : %s", err)
+		return 0, wrapDBError(err, QueryFail, "count alerts: %s", err)
+	}
+	return count, nil
+}
+
+/*the fields the alerts can be sorted on, so that no arbitrary column name ends up in the query*/
+var alertSortFields = map[string]string{
+	"created_at":   alert.FieldCreatedAt,
+	"started_at":   alert.FieldStartedAt,
+	"events_count": alert.FieldEventsCount,
+}
+
+// QueryAlertsPage returns the alerts matching the filter (with limit/offset/sort like QueryAlertWithFilter) and the total number of alerts matching it.
+// Both are read in the same transaction, so the total is consistent with the page even with concurrent inserts or flushes.
+func (c *Client) QueryAlertsPage(filter map[string][]string) ([]*ent.Alert, int, error) {
+	tx, err := c.Ent.Tx(c.CTX)
+	if err != nil {
+		return []*ent.Alert{}, 0, errors.Wrapf(QueryFail, "starting transaction: %s", err)
+	}
+	alerts, total, err := c.queryAlertsPage(tx.Client(), filter)
+	if err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			c.logger().Warningf("QueryAlertsPage : rollback failed: %s", rbErr)
+		}
+		return []*ent.Alert{}, 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return []*ent.Alert{}, 0, errors.Wrapf(QueryFail, "ending transaction: %s", err)
+	}
+	return alerts, total, nil
+}
+
+func (c *Client) queryAlertsPage(client *ent.Client, filter map[string][]string) ([]*ent.Alert, int, error) {
+	alerts, err := BuildAlertRequestFromFilter(client.Alert.Query(), filter)
+	if err != nil {
+		return []*ent.Alert{}, 0, err
+	}
+	total, err := alerts.Count(c.CTX)
+	if err != nil {
+		c.logger().Warningf("QueryAlertsPage : %s", err)
+		return []*ent.Alert{}, 0, errors.Wrap(QueryFail, "count alerts")
+	}
+	if c.afterAlertsPageCount != nil {
+		c.afterAlertsPageCount()
+	}
+	page, err := c.queryAlertWithFilter(c.CTX, client, filter)
+	if err != nil {
+		return []*ent.Alert{}, 0, err
+	}
+	return page, total, nil
+}
+
+func (c *Client) queryAlertWithFilter(ctx context.Context, client *ent.Client, filter map[string][]string) ([]*ent.Alert, error) {
+	sortField := alert.FieldCreatedAt
+	sort := "DESC" // we sort by desc by default
+	if val, ok := filter["sort"]; ok {
+		switch {
+		case val[0] == "ASC" || val[0] == "DESC":
+			/*the sort order of the creation date, as before the sort field could be chosen*/
+			sort = val[0]
+		case alertSortFields[val[0]] != "":
+			sortField = alertSortFields[val[0]]
+		default:
+			return []*ent.Alert{}, errors.Wrapf(InvalidFilter, "invalid 'sort' parameter: %s", val)
+		}
+	}
+	if val, ok := filter["order"]; ok {
+		order := strings.ToUpper(val[0])
+		if order != "ASC" && order != "DESC" {
+			return []*ent.Alert{}, errors.Wrapf(InvalidFilter, "invalid 'order' parameter: %s", val)
+		}
+		sort = order
+	}
+	limit := defaultLimit
+	if val, ok := filter["limit"]; ok {
+		limitConv, err := strconv.Atoi(val[0])
+		if err != nil || limitConv < 0 {
+			return []*ent.Alert{}, errors.Wrapf(InvalidFilter, "bad limit in parameters: %s", val)
+		}
+		limit = limitConv
+
+	}
+	/*number of matching alerts to skip, to page through the results*/
+	offset := 0
+	if val, ok := filter["offset"]; ok {
+		offsetConv, err := strconv.Atoi(val[0])
+		if err != nil || offsetConv < 0 {
+			return []*ent.Alert{}, errors.Wrapf(InvalidFilter, "bad offset in parameters: %s", val)
+		}
+		offset = offsetConv
+	}
+	/*the N most recent alerts : fetched newest first, then returned oldest first. It overrides sort, order, limit and offset*/
+	last := 0
+	if val, ok := filter["last"]; ok {
+		lastConv, err := strconv.Atoi(val[0])
+		if err != nil || lastConv <= 0 {
+			return []*ent.Alert{}, errors.Wrapf(InvalidFilter, "bad last in parameters: %s", val)
+		}
+		last = lastConv
+		sortField = alert.FieldCreatedAt
+		sort = "DESC"
+		limit = last
+		offset = 0
+	}
+	/*only load the decisions that are still active, to not display stale ones*/
+	activeDecisionsOnly := false
+	if val, ok := filter["active_decisions_only"]; ok {
+		var err error
+		if activeDecisionsOnly, err = strconv.ParseBool(val[0]); err != nil {
+			return []*ent.Alert{}, errors.Wrapf(ParseType, "'%s' is not a boolean: %s", val[0], err)
+		}
+	}
+	decisionSimulated, err := decisionSimulatedFilter(filter)
+	if err != nil {
+		return []*ent.Alert{}, err
+	}
+	ret := make([]*ent.Alert, 0)
+	for {
+		alerts := client.Alert.Query()
+		alerts, err := BuildAlertRequestFromFilter(alerts, filter)
+		if err != nil {
+			return []*ent.Alert{}, err
+		}
+		/*with decision_simulated, only the decisions with the given flag are loaded*/
+		loadedDecisions := []predicate.Decision{}
+		if activeDecisionsOnly {
+			loadedDecisions = append(loadedDecisions, decision.UntilGTE(time.Now()))
+		}
+		if decisionSimulated != nil {
+			loadedDecisions = append(loadedDecisions, decision.SimulatedEQ(*decisionSimulated))
+		}
+		alerts = alerts.WithDecisions(func(q *ent.DecisionQuery) {
+			q.Where(loadedDecisions...)
+		})
+		alerts = alerts.
+			WithEvents().
+			WithMetas().
 			WithOwner()
+		/*alerts can share the value of the sort field (ie. the creation date of a same bulk) : the id keeps the pages stable*/
 		if sort == "ASC" {
-			alerts = alerts.Order(ent.Asc(alert.FieldCreatedAt))
+			alerts = alerts.Order(ent.Asc(sortField), ent.Asc(alert.FieldID))
 		} else {
-			alerts = alerts.Order(ent.Desc(alert.FieldCreatedAt))
+			alerts = alerts.Order(ent.Desc(sortField), ent.Desc(alert.FieldID))
 		}
 		if limit == 0 {
-			limit, err = alerts.Count(c.CTX)
+			limit, err = alerts.Count(ctx)
 			if err != nil {
-				return []*ent.Alert{}, fmt.Errorf("unable to count nb alerts: %s", err)
+				return []*ent.Alert{}, wrapDBError(err, QueryFail, "unable to count nb alerts: %s", err)
+			}
+			if limit -= offset; limit < 0 {
+				limit = 0
 			}
 		}
-		result, err := alerts.Limit(paginationSize).Offset(offset).All(c.CTX)
+		result, err := alerts.Limit(paginationSize).Offset(offset).All(ctx)
 		if err != nil {
-			return []*ent.Alert{}, errors.Wrapf(QueryFail, "pagination size: %d, offset: %d: %s", paginationSize, offset, err)
+			return []*ent.Alert{}, wrapDBError(err, QueryFail, "pagination size: %d, offset: %d: %s", paginationSize, offset, err)
 		}
 		if diff := limit - len(ret); diff < paginationSize {
 			if len(result) < diff {
 				ret = append(ret, result...)
-				log.Debugf("Pagination done, %d < %d", len(result), diff)
+				c.logger().Debugf("Pagination done, %d < %d", len(result), diff)
 				break
 			}
 			ret = append(ret, result[0:diff]...)
@@ -449,129 +1569,713 @@ func (c *Client) QueryAlertWithFilter(filter map[string][]string) ([]*ent.Alert,
 			ret = append(ret, result...)
 		}
 		if len(ret) == limit || len(ret) == 0 {
-			log.Debugf("Pagination done len(ret) = %d", len(ret))
+			c.logger().Debugf("Pagination done len(ret) = %d", len(ret))
 			break
 		}
 		offset += paginationSize
 	}
+	if last > 0 {
+		for i, j := 0, len(ret)-1; i < j; i, j = i+1, j-1 {
+			ret[i], ret[j] = ret[j], ret[i]
+		}
+	}
 
 	return ret, nil
 }
 
+// IterateAlertsWithFilter calls fn on each alert matching filter, loaded with all its edges, in id order and one page at a time to bound the memory used.
+// The iteration stops at the first error returned by fn, which is returned as is. limit, offset and sort are ignored.
+// The pages are fetched after the last id seen rather than with an offset, so that fn can delete the alerts it is given
+func (c *Client) IterateAlertsWithFilter(filter map[string][]string, fn func(*ent.Alert) error) error {
+	lastID := 0
+	for {
+		alerts, err := BuildAlertRequestFromFilter(c.Ent.Alert.Query(), filter)
+		if err != nil {
+			return err
+		}
+		result, err := alerts.
+			Where(alert.IDGT(lastID)).
+			WithDecisions().
+			WithEvents().
+			WithMetas().
+			WithOwner().
+			Order(ent.Asc(alert.FieldID)).
+			Limit(paginationSize).
+			All(c.CTX)
+		if err != nil {
+			c.logger().Warningf("IterateAlertsWithFilter : %s", err)
+			return wrapDBError(err, QueryFail, "alerts after id %d: %s", lastID, err)
+		}
+		for _, alertItem := range result {
+			if err := fn(alertItem); err != nil {
+				return err
+			}
+			lastID = alertItem.ID
+		}
+		if len(result) < paginationSize {
+			return nil
+		}
+	}
+}
+
+// FilterAlertsByEventMeta returns the alerts matching baseFilter that have at least one event with the meta key set to value.
+// Event metas aren't indexed : the matching alerts are fetched paginationSize at a time, most recent first, and their events are decoded to be checked.
+// "limit" bounds the number of matching alerts returned (defaultLimit if unset, 0 for all of them).
+func (c *Client) FilterAlertsByEventMeta(baseFilter map[string][]string, key, value string) ([]*ent.Alert, error) {
+	limit := defaultLimit
+	if val, ok := baseFilter["limit"]; ok {
+		limitConv, err := strconv.Atoi(val[0])
+		if err != nil || limitConv < 0 {
+			return []*ent.Alert{}, errors.Wrapf(InvalidFilter, "bad limit in parameters: %s", val)
+		}
+		limit = limitConv
+	}
+
+	ret := make([]*ent.Alert, 0)
+	lastID := 0
+	for {
+		alerts, err := BuildAlertRequestFromFilter(c.Ent.Alert.Query(), baseFilter)
+		if err != nil {
+			return []*ent.Alert{}, err
+		}
+		if lastID != 0 {
+			alerts = alerts.Where(alert.IDLT(lastID))
+		}
+		result, err := alerts.
+			WithDecisions().
+			WithEvents().
+			WithMetas().
+			WithOwner().
+			Order(ent.Desc(alert.FieldID)).
+			Limit(paginationSize).
+			All(c.CTX)
+		if err != nil {
+			c.logger().Warningf("FilterAlertsByEventMeta : %s", err)
+			return []*ent.Alert{}, errors.Wrapf(QueryFail, "alerts before id %d", lastID)
+		}
+		for _, alertItem := range result {
+			lastID = alertItem.ID
+			match, err := eventsHaveMeta(alertItem.Edges.Events, key, value)
+			if err != nil {
+				return []*ent.Alert{}, errors.Wrapf(err, "alert %d", alertItem.ID)
+			}
+			if !match {
+				continue
+			}
+			ret = append(ret, alertItem)
+			if len(ret) == limit {
+				return ret, nil
+			}
+		}
+		if len(result) < paginationSize {
+			break
+		}
+	}
+	return ret, nil
+}
+
+// eventsHaveMeta tells if one of the events has the meta key set to value
+func eventsHaveMeta(events []*ent.Event, key, value string) (bool, error) {
+	for _, eventItem := range events {
+		var metas models.Meta
+		if err := json.Unmarshal([]byte(eventItem.Serialized), &metas); err != nil {
+			return false, errors.Wrapf(UnmarshalFail, "event %d meta: %s", eventItem.ID, err)
+		}
+		for _, metaItem := range metas {
+			if metaItem != nil && metaItem.Key == key && metaItem.Value == value {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// AuditEventsCount returns the number of alerts whose EventsCount differs from the number of events actually stored for them,
+// and sets it to the right value if fix is set
+func (c *Client) AuditEventsCount(fix bool) (int, error) {
+	var mismatched int
+	lastID := 0
+
+	for {
+		var alerts []struct {
+			ID          int `json:"id"`
+			EventsCount int `json:"events_count"`
+		}
+		var groups []struct {
+			AlertEvents int `json:"alert_events"`
+			Count       int `json:"count"`
+		}
+
+		err := c.Ent.Alert.Query().
+			Where(alert.IDGT(lastID)).
+			Order(ent.Asc(alert.FieldID)).
+			Limit(paginationSize).
+			Select(alert.FieldID, alert.FieldEventsCount).
+			Scan(c.CTX, &alerts)
+		if err != nil {
+			c.logger().Warningf("AuditEventsCount : %s", err)
+			return mismatched, errors.Wrapf(QueryFail, "alerts after id %d", lastID)
+		}
+		if len(alerts) == 0 {
+			break
+		}
+		ids := make([]int, 0, len(alerts))
+		for _, alertItem := range alerts {
+			ids = append(ids, alertItem.ID)
+		}
+		err = c.Ent.Event.Query().
+			Where(event.HasOwnerWith(alert.IDIn(ids...))).
+			GroupBy(event.OwnerColumn).
+			Aggregate(ent.Count()).
+			Scan(c.CTX, &groups)
+		if err != nil {
+			c.logger().Warningf("AuditEventsCount : %s", err)
+			return mismatched, errors.Wrapf(QueryFail, "count events of alerts after id %d", lastID)
+		}
+		nbEvents := make(map[int]int, len(groups))
+		for _, group := range groups {
+			nbEvents[group.AlertEvents] = group.Count
+		}
+
+		for _, alertItem := range alerts {
+			lastID = alertItem.ID
+			if alertItem.EventsCount == nbEvents[alertItem.ID] {
+				continue
+			}
+			mismatched++
+			c.logger().Debugf("alert %d has %d events, but an events count of %d", alertItem.ID, nbEvents[alertItem.ID], alertItem.EventsCount)
+			if !fix {
+				continue
+			}
+			_, err := c.Ent.Alert.UpdateOneID(alertItem.ID).SetEventsCount(int32(nbEvents[alertItem.ID])).Save(c.CTX)
+			if err != nil {
+				c.logger().Warningf("AuditEventsCount : %s", err)
+				return mismatched, errors.Wrapf(UpdateFail, "events count of alert %d", alertItem.ID)
+			}
+		}
+		if len(alerts) < paginationSize {
+			break
+		}
+	}
+	return mismatched, nil
+}
+
 func (c *Client) DeleteAlertGraph(alertItem *ent.Alert) error {
 	// delete the associated events
-	_, err := c.Ent.Event.Delete().
-		Where(event.HasOwnerWith(alert.IDEQ(alertItem.ID))).Exec(c.CTX)
+	err := c.retryLocked(c.CTX, func() error {
+		_, err := c.Ent.Event.Delete().
+			Where(event.HasOwnerWith(alert.IDEQ(alertItem.ID))).Exec(c.CTX)
+		return err
+	})
 	if err != nil {
-		log.Warningf("DeleteAlertGraph : %s", err)
+		c.logger().Warningf("DeleteAlertGraph : %s", err)
 		return errors.Wrapf(DeleteFail, "event with alert ID '%d'", alertItem.ID)
 	}
 
 	// delete the associated meta
-	_, err = c.Ent.Meta.Delete().
-		Where(meta.HasOwnerWith(alert.IDEQ(alertItem.ID))).Exec(c.CTX)
+	err = c.retryLocked(c.CTX, func() error {
+		_, err := c.Ent.Meta.Delete().
+			Where(meta.HasOwnerWith(alert.IDEQ(alertItem.ID))).Exec(c.CTX)
+		return err
+	})
 	if err != nil {
-		log.Warningf("DeleteAlertGraph : %s", err)
+		c.logger().Warningf("DeleteAlertGraph : %s", err)
 		return errors.Wrapf(DeleteFail, "meta with alert ID '%d'", alertItem.ID)
 	}
 
 	// delete the associated decisions
-	_, err = c.Ent.Decision.Delete().
-		Where(decision.HasOwnerWith(alert.IDEQ(alertItem.ID))).Exec(c.CTX)
+	err = c.retryLocked(c.CTX, func() error {
+		_, err := c.Ent.Decision.Delete().
+			Where(decision.HasOwnerWith(alert.IDEQ(alertItem.ID))).Exec(c.CTX)
+		return err
+	})
 	if err != nil {
-		log.Warningf("DeleteAlertGraph : %s", err)
+		c.logger().Warningf("DeleteAlertGraph : %s", err)
 		return errors.Wrapf(DeleteFail, "decision with alert ID '%d'", alertItem.ID)
 	}
 
 	// delete the alert
-	err = c.Ent.Alert.DeleteOne(alertItem).Exec(c.CTX)
+	err = c.retryLocked(c.CTX, func() error {
+		return c.Ent.Alert.DeleteOne(alertItem).Exec(c.CTX)
+	})
 	if err != nil {
-		log.Warningf("DeleteAlertGraph : %s", err)
+		c.logger().Warningf("DeleteAlertGraph : %s", err)
 		return errors.Wrapf(DeleteFail, "alert with ID '%d'", alertItem.ID)
 	}
 
 	return nil
 }
 
+// DeleteAlertGraphBatch deletes the alerts along with their events, metas and decisions like DeleteAlertGraph,
+// but with a single statement per table for every deleteBatchSize alerts. Each batch is deleted in its own transaction,
+// and the number of alerts deleted is returned even if a later batch fails.
+func (c *Client) DeleteAlertGraphBatch(alertItems []*ent.Alert) (int, error) {
+	ids := make([]int, 0, len(alertItems))
+	for _, alertItem := range alertItems {
+		ids = append(ids, alertItem.ID)
+	}
+	return c.deleteAlertGraphIDsBatch(ids)
+}
+
+// deleteAlertGraphIDsBatch is DeleteAlertGraphBatch for callers that only know the ids of the alerts
+func (c *Client) deleteAlertGraphIDsBatch(ids []int) (int, error) {
+	deleted := 0
+	for start := 0; start < len(ids); start += deleteBatchSize {
+		end := start + deleteBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		if err := c.deleteAlertGraphIDs(ids[start:end]); err != nil {
+			return deleted, err
+		}
+		deleted += end - start
+	}
+	return deleted, nil
+}
+
+func (c *Client) deleteAlertGraphIDs(ids []int) error {
+	tx, err := c.Ent.Tx(c.CTX)
+	if err != nil {
+		c.logger().Warningf("DeleteAlertGraphBatch : %s", err)
+		return errors.Wrapf(DeleteFail, "starting transaction: %s", err)
+	}
+	fail := func(err error, items string) error {
+		c.logger().Warningf("DeleteAlertGraphBatch : %s", err)
+		if rbErr := tx.Rollback(); rbErr != nil {
+			c.logger().Warningf("DeleteAlertGraphBatch : rollback failed: %s", rbErr)
+		}
+		return errors.Wrapf(DeleteFail, "%s of %d alerts", items, len(ids))
+	}
+
+	if _, err := tx.Event.Delete().Where(event.HasOwnerWith(alert.IDIn(ids...))).Exec(c.CTX); err != nil {
+		return fail(err, "events")
+	}
+	if _, err := tx.Meta.Delete().Where(meta.HasOwnerWith(alert.IDIn(ids...))).Exec(c.CTX); err != nil {
+		return fail(err, "metas")
+	}
+	if _, err := tx.Decision.Delete().Where(decision.HasOwnerWith(alert.IDIn(ids...))).Exec(c.CTX); err != nil {
+		return fail(err, "decisions")
+	}
+	if _, err := tx.Alert.Delete().Where(alert.IDIn(ids...)).Exec(c.CTX); err != nil {
+		return fail(err, "alerts")
+	}
+	if err := tx.Commit(); err != nil {
+		c.logger().Warningf("DeleteAlertGraphBatch : %s", err)
+		return errors.Wrapf(DeleteFail, "ending transaction: %s", err)
+	}
+	return nil
+}
+
+// DeleteAlertWithFilter deletes the alerts matching the filter along with their events, metas and decisions.
+// If a deletion fails, the number of alerts deleted until then is returned with the error.
 func (c *Client) DeleteAlertWithFilter(filter map[string][]string) (int, error) {
 	var err error
 
 	// Get all the alerts that match the filter
 	alertsToDelete, err := c.QueryAlertWithFilter(filter)
+	if err != nil {
+		c.logger().Warningf("DeleteAlertWithFilter : %s", err)
+		return 0, errors.Wrapf(QueryFail, "alerts to delete: %s", err)
+	}
 
-	for _, alertItem := range alertsToDelete {
+	for i, alertItem := range alertsToDelete {
 		err = c.DeleteAlertGraph(alertItem)
 		if err != nil {
-			log.Warningf("DeleteAlertWithFilter : %s", err)
-			return 0, errors.Wrapf(DeleteFail, "event with alert ID '%d'", alertItem.ID)
+			c.logger().Warningf("DeleteAlertWithFilter : %s", err)
+			return i, errors.Wrapf(DeleteFail, "event with alert ID '%d'", alertItem.ID)
 		}
 	}
 	return len(alertsToDelete), nil
 }
 
+// CountAlertsToFlush returns the number of alerts FlushAlerts would delete with the same max age (0 for none) and max number of items,
+// without deleting anything. The alerts too old being the oldest ones, they also count towards the alerts exceeding MaxItems.
+func (c *Client) CountAlertsToFlush(MaxAge time.Duration, MaxItems int) (int, error) {
+	totalAlerts, err := c.TotalAlerts()
+	if err != nil {
+		c.logger().Warningf("CountAlertsToFlush : %s", err)
+		return 0, errors.Wrap(QueryFail, "count alerts")
+	}
+	nbByAge := 0
+	if MaxAge > 0 {
+		nbByAge, err = c.Ent.Alert.Query().Where(alert.CreatedAtLTE(time.Now().Add(-MaxAge))).Count(c.CTX)
+		if err != nil {
+			c.logger().Warningf("CountAlertsToFlush : %s", err)
+			return 0, errors.Wrapf(QueryFail, "count alerts created %s ago or more", MaxAge)
+		}
+	}
+	if MaxItems > 0 && totalAlerts-nbByAge > MaxItems {
+		return totalAlerts - MaxItems, nil
+	}
+	return nbByAge, nil
+}
+
+// FlushAlerts deletes the alerts created MaxAge ago or more (none if empty), then the oldest ones if more than MaxItems (if positive) are left,
+// archived or not. The alerts archived Client.ArchiveGracePeriod ago or more are deleted too, if it is set.
+// CountAlertsToFlush tells how many alerts it would delete, leaving out the archived ones.
 func (c *Client) FlushAlerts(MaxAge string, MaxItems int) error {
 	var deletedByAge int
 	var deletedByNbItem int
+	var deletedArchived int
 	var totalAlerts int
 	var err error
 	totalAlerts, err = c.TotalAlerts()
 	if err != nil {
-		log.Warningf("FlushAlerts (max items count) : %s", err)
+		c.logger().Warningf("FlushAlerts (max items count) : %s", err)
 		return errors.Wrap(err, "unable to get alerts count")
 	}
 	if MaxAge != "" {
+		/*all the alerts too old are flushed, only their ids are read*/
 		filter := map[string][]string{
-			"created_before": {MaxAge},
+			"created_before":   {MaxAge},
+			"include_archived": {"true"},
 		}
-		nbDeleted, err := c.DeleteAlertWithFilter(filter)
+		alerts, err := BuildAlertRequestFromFilter(c.Ent.Alert.Query(), filter)
 		if err != nil {
-			log.Warningf("FlushAlerts (max age) : %s", err)
+			c.logger().Warningf("FlushAlerts (max age query) : %s", err)
+			return errors.Wrapf(err, "unable to get alerts with filter until: %s", MaxAge)
+		}
+		ids, err := alerts.IDs(c.CTX)
+		if err != nil {
+			c.logger().Warningf("FlushAlerts (max age query) : %s", err)
+			return wrapDBError(err, QueryFail, "alerts created before %s: %s", MaxAge, err)
+		}
+		nbDeleted, err := c.deleteAlertGraphIDsBatch(ids)
+		if err != nil {
+			c.logger().Warningf("FlushAlerts (max age) : %s", err)
 			return errors.Wrapf(err, "unable to flush alerts with filter until: %s", MaxAge)
 		}
 		deletedByAge = nbDeleted
 	}
 	if MaxItems > 0 {
-		if totalAlerts > MaxItems {
-			nbToDelete := totalAlerts - MaxItems
-			alerts, err := c.QueryAlertWithFilter(map[string][]string{
-				"sort":  {"ASC"},
-				"limit": {strconv.Itoa(nbToDelete)},
-			}) // we want to delete older alerts if we reach the max number of items
+		/*the alerts already flushed because of their age count towards the max number of items*/
+		if totalAlerts-deletedByAge > MaxItems {
+			nbToDelete := totalAlerts - deletedByAge - MaxItems
+			/*only the ids of the oldest alerts are read, the alerts and their edges are never loaded*/
+			ids, err := c.Ent.Alert.Query().
+				Order(ent.Asc(alert.FieldCreatedAt), ent.Asc(alert.FieldID)).
+				Limit(nbToDelete).
+				IDs(c.CTX)
 			if err != nil {
-				log.Warningf("FlushAlerts (max items query) : %s", err)
-				return errors.Wrap(err, "unable to get all alerts")
+				c.logger().Warningf("FlushAlerts (max items query) : %s", err)
+				return wrapDBError(err, QueryFail, "oldest %d alerts: %s", nbToDelete, err)
 			}
-			for itemNb, alert := range alerts {
-				if itemNb < nbToDelete {
-					err := c.DeleteAlertGraph(alert)
-					if err != nil {
-						log.Warningf("FlushAlerts : %s", err)
-						return errors.Wrap(err, "unable to flush alert")
-					}
-					deletedByNbItem++
-				}
+			deletedByNbItem, err = c.deleteAlertGraphIDsBatch(ids)
+			if err != nil {
+				c.logger().Warningf("FlushAlerts : %s", err)
+				return errors.Wrap(err, "unable to flush alert")
 			}
 		}
 	}
+	if c.ArchiveGracePeriod > 0 {
+		ids, err := c.Ent.Alert.Query().
+			Where(alert.ArchivedEQ(true), alert.ArchivedAtLTE(time.Now().Add(-c.ArchiveGracePeriod))).
+			IDs(c.CTX)
+		if err != nil {
+			c.logger().Warningf("FlushAlerts (archived query) : %s", err)
+			return wrapDBError(err, QueryFail, "alerts archived %s ago or more: %s", c.ArchiveGracePeriod, err)
+		}
+		deletedArchived, err = c.deleteAlertGraphIDsBatch(ids)
+		if err != nil {
+			c.logger().Warningf("FlushAlerts (archived) : %s", err)
+			return errors.Wrapf(err, "unable to flush alerts archived %s ago or more", c.ArchiveGracePeriod)
+		}
+	}
 	if deletedByNbItem > 0 {
-		log.Infof("flushed %d/%d alerts because max number of alerts has been reached (%d max)", deletedByNbItem, totalAlerts, MaxItems)
+		c.logger().Infof("flushed %d/%d alerts because max number of alerts has been reached (%d max)", deletedByNbItem, totalAlerts, MaxItems)
+	}
+	if deletedArchived > 0 {
+		c.logger().Infof("flushed %d/%d alerts because they were archived %s ago or more", deletedArchived, totalAlerts, c.ArchiveGracePeriod)
 	}
 	if deletedByAge > 0 {
-		log.Infof("flushed %d/%d alerts because they were created %s ago or more", deletedByAge, totalAlerts, MaxAge)
+		c.logger().Infof("flushed %d/%d alerts because they were created %s ago or more", deletedByAge, totalAlerts, MaxAge)
+	}
+	/*the flush itself went fine, a failed maintenance will be retried after the next large one*/
+	if nbFlushed := deletedByAge + deletedByNbItem + deletedArchived; c.MaintenanceThreshold > 0 && nbFlushed > c.MaintenanceThreshold {
+		if err := c.PostFlushMaintenance(); err != nil {
+			c.logger().Warningf("FlushAlerts : maintenance after flushing %d alerts failed: %s", nbFlushed, err)
+		}
 	}
 	return nil
 }
 
+// GetAlertByID returns the alert with its decisions, events, metas and owner, or an ItemNotFound error if there is no such alert
 func (c *Client) GetAlertByID(alertID int) (*ent.Alert, error) {
-	alert, err := c.Ent.Alert.Query().Where(alert.IDEQ(alertID)).WithDecisions().WithEvents().WithMetas().WithOwner().First(c.CTX)
+	alert, err := c.Ent.Alert.Query().Where(alert.IDEQ(alertID)).WithDecisions().WithEvents().WithMetas().WithOwner().Only(c.CTX)
 	if err != nil {
 		/*record not found, 404*/
 		if ent.IsNotFound(err) {
-			log.Warningf("GetAlertByID (not found): %s", err)
-			return &ent.Alert{}, ItemNotFound
+			c.logger().Warningf("GetAlertByID (not found): %s", err)
+			return &ent.Alert{}, errors.Wrapf(ItemNotFound, "alert with id '%d'", alertID)
 		}
-		log.Warningf("GetAlertByID : %s", err)
-		return &ent.Alert{}, QueryFail
+		c.logger().Warningf("GetAlertByID : %s", err)
+		return &ent.Alert{}, wrapDBError(err, QueryFail, "alert with id '%d': %s", alertID, err)
 	}
 	return alert, nil
 }
+
+// ExportAlertGraph returns the alert with its events, metas and decisions, as models.Alert JSON.
+// Timestamps are kept as RFC3339 and decisions carry their absolute expiration, so that the export can be fed back to CreateAlertBulk.
+func (c *Client) ExportAlertGraph(id int) ([]byte, error) {
+	alertItem, err := c.GetAlertByID(id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "alert %d", id)
+	}
+	exported, err := alertGraphToModel(alertItem)
+	if err != nil {
+		return nil, errors.Wrapf(err, "alert %d", id)
+	}
+	ret, err := json.Marshal(exported)
+	if err != nil {
+		return nil, errors.Wrapf(MarshalFail, "alert %d: %s", id, err)
+	}
+	return ret, nil
+}
+
+// ExportAlerts writes all the alerts matching filter to w as a JSON array, one page at a time. limit, offset and sort are ignored.
+// Events are only loaded and exported when includeEvents is true
+func (c *Client) ExportAlerts(filter map[string][]string, w io.Writer, includeEvents bool) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return errors.Wrapf(MarshalFail, "writing alerts: %s", err)
+	}
+	nbExported := 0
+	lastID := 0
+	for {
+		alerts, err := BuildAlertRequestFromFilter(c.Ent.Alert.Query(), filter)
+		if err != nil {
+			return err
+		}
+		alerts = alerts.
+			Where(alert.IDGT(lastID)).
+			WithDecisions().
+			WithMetas().
+			WithOwner()
+		if includeEvents {
+			alerts = alerts.WithEvents()
+		}
+		result, err := alerts.
+			Order(ent.Asc(alert.FieldID)).
+			Limit(paginationSize).
+			All(c.CTX)
+		if err != nil {
+			c.logger().Warningf("ExportAlerts : %s", err)
+			return errors.Wrapf(QueryFail, "alerts after id %d", lastID)
+		}
+		for _, alertItem := range result {
+			exported, err := alertGraphToModel(alertItem)
+			if err != nil {
+				return errors.Wrapf(err, "alert %d", alertItem.ID)
+			}
+			serialized, err := json.Marshal(exported)
+			if err != nil {
+				return errors.Wrapf(MarshalFail, "alert %d: %s", alertItem.ID, err)
+			}
+			if nbExported > 0 {
+				serialized = append([]byte(","), serialized...)
+			}
+			if _, err := w.Write(serialized); err != nil {
+				return errors.Wrapf(MarshalFail, "writing alert %d: %s", alertItem.ID, err)
+			}
+			nbExported++
+			lastID = alertItem.ID
+		}
+		if len(result) < paginationSize {
+			break
+		}
+	}
+	if _, err := io.WriteString(w, "]"); err != nil {
+		return errors.Wrapf(MarshalFail, "writing alerts: %s", err)
+	}
+	return nil
+}
+
+// alertGraphToModel converts an alert loaded with all its edges back to the models.Alert it was created from
+func alertGraphToModel(alertItem *ent.Alert) (*models.Alert, error) {
+	machineID := ""
+	if alertItem.Edges.Owner != nil {
+		machineID = alertItem.Edges.Owner.MachineId
+	}
+	startAt := alertItem.StartedAt.Format(time.RFC3339)
+	stopAt := alertItem.StoppedAt.Format(time.RFC3339)
+	ret := &models.Alert{
+		ID:              int64(alertItem.ID),
+		MachineID:       machineID,
+		CreatedAt:       alertItem.CreatedAt.Format(time.RFC3339),
+		Scenario:        &alertItem.Scenario,
+		ScenarioVersion: &alertItem.ScenarioVersion,
+		ScenarioHash:    &alertItem.ScenarioHash,
+		Message:         &alertItem.Message,
+		EventsCount:     &alertItem.EventsCount,
+		StartAt:         &startAt,
+		StopAt:          &stopAt,
+		Capacity:        &alertItem.Capacity,
+		Leakspeed:       &alertItem.LeakSpeed,
+		Simulated:       &alertItem.Simulated,
+		Source: &models.Source{
+			Scope:     &alertItem.SourceScope,
+			Value:     &alertItem.SourceValue,
+			IP:        alertItem.SourceIp,
+			Range:     alertItem.SourceRange,
+			AsNumber:  alertItem.SourceAsNumber,
+			AsName:    alertItem.SourceAsName,
+			Cn:        alertItem.SourceCountry,
+			Latitude:  alertItem.SourceLatitude,
+			Longitude: alertItem.SourceLongitude,
+		},
+	}
+	if alertItem.Tags != "" {
+		if err := json.Unmarshal([]byte(alertItem.Tags), &ret.Labels); err != nil {
+			return nil, errors.Wrapf(UnmarshalFail, "tags '%s': %s", alertItem.Tags, err)
+		}
+	}
+	for _, eventItem := range alertItem.Edges.Events {
+		var metas models.Meta
+		if err := json.Unmarshal([]byte(eventItem.Serialized), &metas); err != nil {
+			return nil, errors.Wrapf(UnmarshalFail, "event %d meta: %s", eventItem.ID, err)
+		}
+		timestamp := eventItem.Time.Format(time.RFC3339)
+		ret.Events = append(ret.Events, &models.Event{
+			Timestamp: &timestamp,
+			Meta:      metas,
+		})
+	}
+	for _, metaItem := range alertItem.Edges.Metas {
+		ret.Meta = append(ret.Meta, &models.MetaItems0{
+			Key:   metaItem.Key,
+			Value: metaItem.Value,
+		})
+	}
+	for _, decisionItem := range alertItem.Edges.Decisions {
+		decisionItem := decisionItem
+		duration := decisionItem.Until.Sub(alertItem.StoppedAt).String()
+		ret.Decisions = append(ret.Decisions, &models.Decision{
+			ID:        int64(decisionItem.ID),
+			Duration:  &duration,
+			Until:     decisionItem.Until.Format(time.RFC3339),
+			Scenario:  &decisionItem.Scenario,
+			Type:      &decisionItem.Type,
+			StartIP:   decisionItem.StartIP,
+			EndIP:     decisionItem.EndIP,
+			Scope:     &decisionItem.Scope,
+			Value:     &decisionItem.Value,
+			Origin:    &decisionItem.Origin,
+			Simulated: &decisionItem.Simulated,
+		})
+	}
+	return ret, nil
+}
+
+// AcknowledgeAlerts marks the given alerts as reviewed by 'by', and returns the number of alerts updated
+func (c *Client) AcknowledgeAlerts(ids []int, by string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	nbUpdated, err := c.Ent.Alert.Update().
+		Where(alert.IDIn(ids...)).
+		SetAcknowledged(true).
+		SetAcknowledgedAt(time.Now()).
+		SetAcknowledgedBy(by).
+		Save(c.CTX)
+	if err != nil {
+		c.logger().Warningf("AcknowledgeAlerts : %s", err)
+		return 0, errors.Wrapf(UpdateFail, "acknowledge %d alerts", len(ids))
+	}
+	return nbUpdated, nil
+}
+
+// ArchiveAlertsWithFilter marks the alerts matching filter as archived, hiding them from the queries without deleting them, and returns the number of alerts archived.
+// limit, offset and sort are ignored, all the matching alerts are archived
+func (c *Client) ArchiveAlertsWithFilter(filter map[string][]string) (int, error) {
+	alerts, err := BuildAlertRequestFromFilter(c.Ent.Alert.Query(), filter)
+	if err != nil {
+		return 0, err
+	}
+	ids, err := alerts.Where(notArchived()).IDs(c.CTX)
+	if err != nil {
+		c.logger().Warningf("ArchiveAlertsWithFilter : %s", err)
+		return 0, wrapDBError(err, QueryFail, "alerts to archive: %s", err)
+	}
+	nbArchived := 0
+	now := time.Now()
+	for start := 0; start < len(ids); start += deleteBatchSize {
+		end := start + deleteBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		nbUpdated, err := c.Ent.Alert.Update().
+			Where(alert.IDIn(ids[start:end]...)).
+			SetArchived(true).
+			SetArchivedAt(now).
+			Save(c.CTX)
+		if err != nil {
+			c.logger().Warningf("ArchiveAlertsWithFilter : %s", err)
+			return nbArchived, errors.Wrapf(UpdateFail, "archive %d alerts", end-start)
+		}
+		nbArchived += nbUpdated
+	}
+	return nbArchived, nil
+}
+
+// LatestAlertForValue returns the most recent alert that has an active decision on value
+func (c *Client) LatestAlertForValue(value string) (*ent.Alert, error) {
+	alert, err := c.Ent.Alert.Query().
+		Where(alert.HasDecisionsWith(decision.ValueEQ(value), decision.UntilGTE(time.Now()))).
+		Order(ent.Desc(alert.FieldCreatedAt), ent.Desc(alert.FieldID)).
+		WithDecisions().WithEvents().WithMetas().WithOwner().
+		First(c.CTX)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return &ent.Alert{}, errors.Wrapf(ItemNotFound, "no active decision on '%s'", value)
+		}
+		c.logger().Warningf("LatestAlertForValue : %s", err)
+		return &ent.Alert{}, errors.Wrapf(QueryFail, "latest alert for '%s'", value)
+	}
+	return alert, nil
+}
+
+// MachineRecentAlerts returns the alerts the machine reported during the last window, the most recent first.
+// Only their decisions are loaded, use GetAlertByID for the full alert.
+func (c *Client) MachineRecentAlerts(machineID string, window time.Duration) ([]*ent.Alert, error) {
+	since := time.Now().Add(-window)
+	ret := make([]*ent.Alert, 0)
+	/*loading the decisions of too many alerts at once would hit 'too many SQL variables'*/
+	for offset := 0; ; offset += paginationSize {
+		alerts, err := c.Ent.Alert.Query().
+			Where(
+				alert.HasOwnerWith(machine.MachineIdEQ(machineID)),
+				alert.CreatedAtGTE(since),
+			).
+			Order(ent.Desc(alert.FieldCreatedAt), ent.Desc(alert.FieldID)).
+			WithDecisions().
+			Limit(paginationSize).
+			Offset(offset).
+			All(c.CTX)
+		if err != nil {
+			c.logger().Warningf("MachineRecentAlerts : %s", err)
+			return []*ent.Alert{}, errors.Wrapf(QueryFail, "alerts of machine '%s' in the last %s", machineID, window)
+		}
+		ret = append(ret, alerts...)
+		if len(alerts) < paginationSize {
+			break
+		}
+	}
+	return ret, nil
+}
+
+// DistinctSourceValues returns up to limit (defaultLimit if <= 0) distinct source values starting with prefix, sorted
+func (c *Client) DistinctSourceValues(prefix string, limit int) ([]string, error) {
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	values, err := c.Ent.Alert.Query().
+		Where(alert.SourceValueHasPrefix(prefix)).
+		Order(ent.Asc(alert.FieldSourceValue)).
+		Limit(limit).
+		GroupBy(alert.FieldSourceValue).
+		Strings(c.CTX)
+	if err != nil {
+		c.logger().Warningf("DistinctSourceValues : %s", err)
+		return []string{}, errors.Wrapf(QueryFail, "source values starting with '%s'", prefix)
+	}
+	return values, nil
+}
@@ -0,0 +1,2582 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/alert"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/decision"
+	"github.com/crowdsecurity/crowdsec/pkg/models"
+	"github.com/crowdsecurity/crowdsec/pkg/types"
+	"github.com/facebook/ent/dialect"
+	"github.com/go-openapi/strfmt"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateAlertBulkClockSkew(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	newFutureAlert := func() *models.Alert {
+		alertItem := newTestAlert("crowdsecurity/test", "1.2.3.4")
+		future := time.Now().UTC().Add(2 * time.Hour).Format(time.RFC3339)
+		alertItem.StartAt = &future
+		alertItem.StopAt = &future
+		return alertItem
+	}
+
+	/*no check unless MaxClockSkew is set*/
+	_, err := dbClient.CreateAlertBulk("test", []*models.Alert{newFutureAlert()})
+	assert.NoError(t, err)
+
+	dbClient.MaxClockSkew = time.Hour
+	ids, err := dbClient.CreateAlertBulk("test", []*models.Alert{newFutureAlert()})
+	assert.Empty(t, ids)
+	assert.Equal(t, TimeInFuture, errors.Cause(err))
+	nbAlerts, err := dbClient.TotalAlerts()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, nbAlerts)
+
+	/*a timestamp within the skew is kept as is*/
+	nearFuture := newFutureAlert()
+	inSkew := time.Now().UTC().Add(30 * time.Minute).Truncate(time.Second)
+	inSkewStr := inSkew.Format(time.RFC3339)
+	nearFuture.StartAt = &inSkewStr
+	nearFuture.StopAt = &inSkewStr
+	ids, err = dbClient.CreateAlertBulk("test", []*models.Alert{nearFuture})
+	assert.NoError(t, err)
+	assert.Len(t, ids, 1)
+	alertID, err := strconv.Atoi(ids[0])
+	assert.NoError(t, err)
+	kept, err := dbClient.Ent.Alert.Get(dbClient.CTX, alertID)
+	assert.NoError(t, err)
+	assert.True(t, inSkew.Equal(kept.StartedAt), "%s != %s", inSkew, kept.StartedAt)
+}
+
+func TestCreateAlertBulkClampClockSkew(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	dbClient.MaxClockSkew = time.Hour
+	dbClient.ClampClockSkew = true
+	alertItem := newTestAlert("crowdsecurity/test", "1.2.3.4")
+	future := time.Now().UTC().Add(2 * time.Hour).Format(time.RFC3339)
+	alertItem.StartAt = &future
+	alertItem.StopAt = &future
+	before := time.Now().UTC().Truncate(time.Second)
+	ids, err := dbClient.CreateAlertBulk("test", []*models.Alert{alertItem})
+	assert.NoError(t, err)
+	assert.Len(t, ids, 1)
+
+	/*the timestamps are replaced with now()*/
+	alertID, err := strconv.Atoi(ids[0])
+	assert.NoError(t, err)
+	clamped, err := dbClient.Ent.Alert.Get(dbClient.CTX, alertID)
+	assert.NoError(t, err)
+	for _, ts := range []time.Time{clamped.StartedAt, clamped.StoppedAt} {
+		assert.False(t, ts.Before(before), "%s before %s", ts, before)
+		assert.True(t, ts.Before(time.Now().Add(time.Minute)), "%s still in the future", ts)
+	}
+}
+
+func TestAlertNoEventsFilter(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := newTestAlerts(3)
+	/*the alert still claims one event*/
+	alerts[1].Events = nil
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+
+	sourceValues := func(filter map[string][]string) []string {
+		alerts, err := dbClient.QueryAlertWithFilter(filter)
+		assert.NoError(t, err)
+		values := make([]string, 0, len(alerts))
+		for _, alertItem := range alerts {
+			values = append(values, alertItem.SourceValue)
+		}
+		return values
+	}
+
+	assert.Equal(t, []string{"1.2.3.1"}, sourceValues(map[string][]string{"no_events": {"true"}}))
+	assert.ElementsMatch(t, []string{"1.2.3.0", "1.2.3.2"}, sourceValues(map[string][]string{"no_events": {"false"}}))
+
+	_, err = dbClient.QueryAlertWithFilter(map[string][]string{"no_events": {"maybe"}})
+	assert.Equal(t, ParseType, errors.Cause(err))
+}
+
+func TestLatestAlertForValue(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	machineID := "test"
+	password := strfmt.Password("password")
+	_, err := dbClient.CreateMachine(&machineID, &password, "127.0.0.1", true, false)
+	assert.NoError(t, err)
+
+	older := newTestAlert("crowdsecurity/old", "1.2.3.4")
+	newer := newTestAlert("crowdsecurity/new", "1.2.3.4")
+	/*the most recent alert, but its decision is over*/
+	expired := newTestAlert("crowdsecurity/expired", "1.2.3.4")
+	expired.Decisions[0].Until = time.Now().UTC().Add(-time.Hour).Format(time.RFC3339)
+	ids, err := dbClient.CreateAlertBulk(machineID, []*models.Alert{newer, older, expired})
+	assert.NoError(t, err)
+	olderID, err := strconv.Atoi(ids[1])
+	assert.NoError(t, err)
+	/*the highest id isn't the most recent one*/
+	_, err = dbClient.Ent.Alert.UpdateOneID(olderID).SetCreatedAt(time.Now().Add(-time.Hour)).Save(dbClient.CTX)
+	assert.NoError(t, err)
+
+	latest, err := dbClient.LatestAlertForValue("1.2.3.4")
+	assert.NoError(t, err)
+	assert.Equal(t, "crowdsecurity/new", latest.Scenario)
+	assert.Len(t, latest.Edges.Decisions, 1)
+	assert.Len(t, latest.Edges.Events, 1)
+	if assert.NotNil(t, latest.Edges.Owner) {
+		assert.Equal(t, machineID, latest.Edges.Owner.MachineId)
+	}
+
+	_, err = dbClient.LatestAlertForValue("1.2.3.5")
+	assert.Equal(t, ItemNotFound, errors.Cause(err))
+}
+
+func TestCreateAlertBulkSkipOwnerLookup(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	machineID := "test"
+	password := strfmt.Password("password")
+	_, err := dbClient.CreateMachine(&machineID, &password, "127.0.0.1", true, false)
+	assert.NoError(t, err)
+
+	nbMachineQueries := 0
+	dbClient.Ent = ent.NewClient(ent.Driver(dialect.Debug(dbClient.drv, func(args ...interface{}) {
+		if strings.Contains(fmt.Sprint(args...), "FROM `machines`") {
+			nbMachineQueries++
+		}
+	})))
+	dbClient.SkipOwnerLookup = true
+
+	ids, err := dbClient.CreateAlertBulk(machineID, newTestAlerts(5))
+	assert.NoError(t, err)
+	assert.Len(t, ids, 5)
+	assert.Equal(t, 0, nbMachineQueries)
+
+	/*the machine exists, but the alerts are stored without owner*/
+	nbOwned, err := dbClient.Ent.Alert.Query().Where(alert.HasOwner()).Count(dbClient.CTX)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, nbOwned)
+	nbAlerts, err := dbClient.Ent.Alert.Query().Count(dbClient.CTX)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, nbAlerts)
+}
+
+func TestQueryAlertActiveDecisionsOnly(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alertItem := newTestAlert("crowdsecurity/test", "1.2.3.4")
+	expired := newTestDecision("1.2.3.4", types.Ip, "captcha", "4h")
+	expired.Until = time.Now().UTC().Add(-time.Hour).Format(time.RFC3339)
+	alertItem.Decisions = append(alertItem.Decisions, expired)
+	_, err := dbClient.CreateAlertBulk("test", []*models.Alert{alertItem})
+	assert.NoError(t, err)
+
+	alerts, err := dbClient.QueryAlertWithFilter(map[string][]string{})
+	assert.NoError(t, err)
+	if assert.Len(t, alerts, 1) {
+		assert.Len(t, alerts[0].Edges.Decisions, 2)
+	}
+	alerts, err = dbClient.QueryAlertWithFilter(map[string][]string{"active_decisions_only": {"true"}})
+	assert.NoError(t, err)
+	if assert.Len(t, alerts, 1) && assert.Len(t, alerts[0].Edges.Decisions, 1) {
+		assert.Equal(t, "ban", alerts[0].Edges.Decisions[0].Type)
+	}
+
+	_, err = dbClient.QueryAlertWithFilter(map[string][]string{"active_decisions_only": {"yes please"}})
+	assert.Equal(t, ParseType, errors.Cause(err))
+}
+
+func TestDistinctSourceValues(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := make([]*models.Alert, 0)
+	for _, value := range []string{"1.2.30.5", "1.2.3.1", "1.2.3.0", "5.6.7.8", "1.2.3.0"} {
+		alerts = append(alerts, newTestAlert("crowdsecurity/test", value))
+	}
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+
+	values, err := dbClient.DistinctSourceValues("1.2.3", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1.2.3.0", "1.2.3.1", "1.2.30.5"}, values)
+	/*each value once, even if there are several alerts on it*/
+	values, err = dbClient.DistinctSourceValues("1.2.3", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1.2.3.0", "1.2.3.1"}, values)
+	values, err = dbClient.DistinctSourceValues("9.", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, values)
+}
+
+func TestAlertASInFilter(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := newTestAlerts(4)
+	for i, asNumber := range []string{"12345", "67890", "12345", "4242"} {
+		alerts[i].Source.AsNumber = asNumber
+	}
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+
+	sourceValues := func(filter map[string][]string) []string {
+		alerts, err := dbClient.QueryAlertWithFilter(filter)
+		assert.NoError(t, err)
+		values := make([]string, 0, len(alerts))
+		for _, alertItem := range alerts {
+			values = append(values, alertItem.SourceValue)
+		}
+		return values
+	}
+
+	assert.ElementsMatch(t, []string{"1.2.3.0", "1.2.3.1", "1.2.3.2"}, sourceValues(map[string][]string{"as_in": {"12345, 67890"}}))
+	assert.ElementsMatch(t, []string{"1.2.3.3"}, sourceValues(map[string][]string{"as_in": {"4242"}}))
+	assert.Empty(t, sourceValues(map[string][]string{"as_in": {"1111,2222"}}))
+	/*same as as_number*/
+	assert.ElementsMatch(t, sourceValues(map[string][]string{"as_number": {"12345", "67890"}}), sourceValues(map[string][]string{"as_in": {"12345", "67890"}}))
+
+	for _, filter := range []map[string][]string{
+		{"as_in": {""}},
+		{"as_in": {"12345,"}},
+	} {
+		_, err = dbClient.QueryAlertWithFilter(filter)
+		assert.Equal(t, InvalidFilter, errors.Cause(err), "%v", filter)
+	}
+}
+
+func TestCreateAlertBulkCancel(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	/*cancel the context as soon as the second batch (20 alerts per batch) starts to be written*/
+	nbAlertMutations := 0
+	dbClient.Ent.Alert.Use(func(next ent.Mutator) ent.Mutator {
+		return ent.MutateFunc(func(ctx context.Context, m ent.Mutation) (ent.Value, error) {
+			nbAlertMutations++
+			if nbAlertMutations == 21 {
+				cancel()
+			}
+			return next.Mutate(ctx, m)
+		})
+	})
+
+	ids, err := dbClient.CreateAlertBulkCtx(ctx, "test", newTestAlerts(30))
+	assert.Error(t, err)
+	assert.Equal(t, context.Canceled, errors.Cause(err))
+	/*the ids of the committed batch are still returned*/
+	assert.Len(t, ids, 20)
+
+	nbAlerts, err := dbClient.Ent.Alert.Query().Count(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 20, nbAlerts)
+	nbDecisions, err := dbClient.Ent.Decision.Query().Count(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 20, nbDecisions)
+	nbEvents, err := dbClient.Ent.Event.Query().Count(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 20, nbEvents)
+	nbMetas, err := dbClient.Ent.Meta.Query().Count(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 20, nbMetas)
+}
+
+func TestAlertTagFilter(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := newTestAlerts(3)
+	alerts[0].Labels = []string{"severity:high", "team:red"}
+	alerts[1].Labels = []string{"severity:low"}
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+
+	result, err := dbClient.QueryAlertWithFilter(map[string][]string{"tag": {"severity:high"}})
+	assert.NoError(t, err)
+	if assert.Len(t, result, 1) {
+		assert.Equal(t, `["severity:high","team:red"]`, result[0].Tags)
+	}
+
+	/*a tag must match entirely*/
+	result, err = dbClient.QueryAlertWithFilter(map[string][]string{"tag": {"severity"}})
+	assert.NoError(t, err)
+	assert.Len(t, result, 0)
+}
+
+func TestAlertRangeFilterInvalid(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	for _, invalidRange := range []string{"10.0.0.0/33", "notanip/24", "1.2.3.4"} {
+		_, err := dbClient.QueryAlertWithFilter(map[string][]string{"range": {invalidRange}})
+		assert.Equal(t, InvalidIPOrRange, errors.Cause(err), invalidRange)
+	}
+}
+
+func TestAlertBatchIDFilter(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	firstIDs, err := dbClient.CreateAlertBulk("test", newTestAlerts(3))
+	assert.NoError(t, err)
+	_, err = dbClient.CreateAlertBulk("test", newTestAlerts(2))
+	assert.NoError(t, err)
+
+	firstAlert, err := dbClient.GetAlertByID(1)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, firstAlert.BatchId)
+
+	result, err := dbClient.QueryAlertWithFilter(map[string][]string{"batch_id": {firstAlert.BatchId}})
+	assert.NoError(t, err)
+	assert.Len(t, result, len(firstIDs))
+}
+
+func TestCreateAlertBulkBatchBoundaries(t *testing.T) {
+	/*batches are of 20 alerts*/
+	for _, nbAlerts := range []int{0, 1, 19, 20, 21, 40, 41} {
+		t.Run(strconv.Itoa(nbAlerts), func(t *testing.T) {
+			dbClient, cleanup := getDBClient(t)
+			defer cleanup()
+
+			ids, err := dbClient.CreateAlertBulk("test", newTestAlerts(nbAlerts))
+			assert.NoError(t, err)
+			assert.Len(t, ids, nbAlerts)
+
+			total, err := dbClient.TotalAlerts()
+			assert.NoError(t, err)
+			assert.Equal(t, nbAlerts, total)
+			nbDecisions, err := dbClient.Ent.Decision.Query().Count(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, nbAlerts, nbDecisions)
+		})
+	}
+}
+
+func TestQueryAlertsPageConsistency(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	_, err := dbClient.CreateAlertBulk("test", newTestAlerts(5))
+	assert.NoError(t, err)
+	filter := map[string][]string{"limit": {"0"}}
+
+	/*without transaction, an alert inserted between the count and the page query makes them disagree*/
+	dbClient.afterAlertsPageCount = func() {
+		_, err := dbClient.CreateAlertBulk("test", newTestAlerts(1))
+		assert.NoError(t, err)
+	}
+	page, total, err := dbClient.queryAlertsPage(dbClient.Ent, filter)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, total)
+	assert.Len(t, page, 6)
+
+	/*within the transaction, the concurrent insert only lands once both queries are done*/
+	inserted := make(chan error)
+	dbClient.afterAlertsPageCount = func() {
+		go func() {
+			_, err := dbClient.CreateAlertBulk("test", newTestAlerts(1))
+			inserted <- err
+		}()
+		time.Sleep(100 * time.Millisecond)
+	}
+	page, total, err = dbClient.QueryAlertsPage(filter)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, total)
+	assert.Len(t, page, 6)
+
+	assert.NoError(t, <-inserted)
+	total, err = dbClient.TotalAlerts()
+	assert.NoError(t, err)
+	assert.Equal(t, 7, total)
+}
+
+func TestAcknowledgeAlerts(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	_, err := dbClient.CreateAlertBulk("test", newTestAlerts(3))
+	assert.NoError(t, err)
+	alerts, err := dbClient.QueryAlertWithFilter(map[string][]string{"sort": {"ASC"}})
+	assert.NoError(t, err)
+
+	nbUpdated, err := dbClient.AcknowledgeAlerts([]int{alerts[0].ID, alerts[1].ID}, "analyst")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, nbUpdated)
+
+	/*without the filter, acknowledged alerts are still listed*/
+	all, err := dbClient.QueryAlertWithFilter(map[string][]string{})
+	assert.NoError(t, err)
+	assert.Len(t, all, 3)
+
+	pending, err := dbClient.QueryAlertWithFilter(map[string][]string{"acknowledged": {"false"}})
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+	assert.Equal(t, alerts[2].ID, pending[0].ID)
+
+	acknowledged, err := dbClient.QueryAlertWithFilter(map[string][]string{"acknowledged": {"true"}})
+	assert.NoError(t, err)
+	assert.Len(t, acknowledged, 2)
+	for _, alertItem := range acknowledged {
+		assert.Equal(t, "analyst", alertItem.AcknowledgedBy)
+		assert.False(t, alertItem.AcknowledgedAt.IsZero())
+	}
+
+	_, err = dbClient.QueryAlertWithFilter(map[string][]string{"acknowledged": {"maybe"}})
+	assert.Equal(t, ParseType, errors.Cause(err))
+}
+
+func TestArchiveAlertsWithFilter(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := []*models.Alert{
+		newTestAlert("crowdsecurity/ssh-bf", "1.2.3.0"),
+		newTestAlert("crowdsecurity/ssh-bf", "1.2.3.1"),
+		newTestAlert("crowdsecurity/http-probing", "1.2.3.2"),
+	}
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+
+	nbArchived, err := dbClient.ArchiveAlertsWithFilter(map[string][]string{"scenario": {"crowdsecurity/ssh-bf"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, nbArchived)
+	/*archiving again doesn't touch the already archived alerts*/
+	nbArchived, err = dbClient.ArchiveAlertsWithFilter(map[string][]string{"scenario": {"crowdsecurity/ssh-bf"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, nbArchived)
+
+	/*archived alerts are hidden by default, but still stored*/
+	result, err := dbClient.QueryAlertWithFilter(map[string][]string{})
+	assert.NoError(t, err)
+	if assert.Len(t, result, 1) {
+		assert.Equal(t, "crowdsecurity/http-probing", result[0].Scenario)
+	}
+	total, err := dbClient.TotalAlerts()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, total)
+
+	result, err = dbClient.QueryAlertWithFilter(map[string][]string{"include_archived": {"true"}})
+	assert.NoError(t, err)
+	assert.Len(t, result, 3)
+	result, err = dbClient.QueryAlertWithFilter(map[string][]string{"include_archived": {"true"}, "scenario": {"crowdsecurity/ssh-bf"}})
+	assert.NoError(t, err)
+	for _, alertItem := range result {
+		assert.True(t, alertItem.Archived)
+		assert.False(t, alertItem.ArchivedAt.IsZero())
+	}
+
+	_, err = dbClient.QueryAlertWithFilter(map[string][]string{"include_archived": {"maybe"}})
+	assert.Equal(t, ParseType, errors.Cause(err))
+
+	/*the archived alerts are only deleted once the grace period is over*/
+	dbClient.ArchiveGracePeriod = time.Hour
+	assert.NoError(t, dbClient.FlushAlerts("", 0))
+	total, err = dbClient.TotalAlerts()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, total)
+
+	_, err = dbClient.Ent.Alert.Update().Where(alert.ArchivedEQ(true)).SetArchivedAt(time.Now().Add(-2 * time.Hour)).Save(dbClient.CTX)
+	assert.NoError(t, err)
+	assert.NoError(t, dbClient.FlushAlerts("", 0))
+	total, err = dbClient.TotalAlerts()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, total)
+}
+
+func TestArchivedNullIsNotArchived(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	_, err := dbClient.CreateAlertBulk("test", newTestAlerts(2))
+	assert.NoError(t, err)
+	/*as stored before the archived column was added*/
+	_, err = dbClient.Ent.Alert.Update().Where(alert.SourceValueEQ("1.2.3.0")).ClearArchived().Save(dbClient.CTX)
+	assert.NoError(t, err)
+
+	result, err := dbClient.QueryAlertWithFilter(map[string][]string{})
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	nbArchived, err := dbClient.ArchiveAlertsWithFilter(map[string][]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, nbArchived)
+	result, err = dbClient.QueryAlertWithFilter(map[string][]string{})
+	assert.NoError(t, err)
+	assert.Empty(t, result)
+}
+
+func TestFilterAlertsByEventMeta(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	/*more alerts than paginationSize, so that the matches span several pages*/
+	alerts := newTestAlerts(paginationSize + 20)
+	for i, alertItem := range alerts {
+		if i%3 == 0 {
+			alertItem.Events[0].Meta = append(alertItem.Events[0].Meta, &models.MetaItems0{Key: "target_user", Value: "root"})
+		}
+	}
+	other := newTestAlert("crowdsecurity/other", "4.3.2.1")
+	other.Events[0].Meta = append(other.Events[0].Meta, &models.MetaItems0{Key: "target_user", Value: "root"})
+	_, err := dbClient.CreateAlertBulk("test", append(alerts, other))
+	assert.NoError(t, err)
+
+	matches, err := dbClient.FilterAlertsByEventMeta(map[string][]string{"limit": {"0"}}, "target_user", "root")
+	assert.NoError(t, err)
+	assert.Len(t, matches, 41)
+	for i := 1; i < len(matches); i++ {
+		assert.True(t, matches[i-1].ID > matches[i].ID)
+	}
+
+	matches, err = dbClient.FilterAlertsByEventMeta(map[string][]string{"scenario": {"crowdsecurity/test"}, "limit": {"0"}}, "target_user", "root")
+	assert.NoError(t, err)
+	assert.Len(t, matches, 40)
+
+	matches, err = dbClient.FilterAlertsByEventMeta(map[string][]string{"limit": {"5"}}, "target_user", "root")
+	assert.NoError(t, err)
+	assert.Len(t, matches, 5)
+	assert.Len(t, matches[0].Edges.Events, 1)
+
+	matches, err = dbClient.FilterAlertsByEventMeta(map[string][]string{}, "target_user", "admin")
+	assert.NoError(t, err)
+	assert.Len(t, matches, 0)
+
+	for _, limit := range []string{"many", "-1"} {
+		_, err = dbClient.FilterAlertsByEventMeta(map[string][]string{"limit": {limit}}, "target_user", "root")
+		assert.Equal(t, InvalidFilter, errors.Cause(err), limit)
+	}
+}
+
+func TestCreateAlertBulkDecisionDuration(t *testing.T) {
+	tests := []struct {
+		name          string
+		duration      string
+		minDuration   time.Duration
+		clamp         bool
+		expectedErr   error
+		expectedUntil time.Duration
+	}{
+		{name: "positive", duration: "4h", expectedUntil: 4 * time.Hour},
+		{name: "zero", duration: "0s", expectedErr: InvalidDuration},
+		{name: "negative", duration: "-1h", expectedErr: InvalidDuration},
+		{name: "under minimum", duration: "30s", minDuration: time.Minute, expectedErr: InvalidDuration},
+		{name: "clamped", duration: "0s", minDuration: time.Minute, clamp: true, expectedUntil: time.Minute},
+		{name: "clamp without minimum", duration: "-1h", clamp: true, expectedErr: InvalidDuration},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dbClient, cleanup := getDBClient(t)
+			defer cleanup()
+			dbClient.MinDecisionDuration = test.minDuration
+			dbClient.ClampDecisionDuration = test.clamp
+
+			alertItem := newTestAlert("crowdsecurity/test", "1.2.3.4")
+			alertItem.Decisions[0].Duration = &test.duration
+			ids, err := dbClient.CreateAlertBulk("test", []*models.Alert{alertItem})
+			if test.expectedErr != nil {
+				assert.Equal(t, test.expectedErr, errors.Cause(err))
+				assert.Len(t, ids, 0)
+				return
+			}
+			assert.NoError(t, err)
+			decisions, err := dbClient.QueryAllDecisions()
+			assert.NoError(t, err)
+			assert.Len(t, decisions, 1)
+			stopAt, _ := time.Parse(time.RFC3339, *alertItem.StopAt)
+			assert.True(t, decisions[0].Until.Equal(stopAt.Add(test.expectedUntil)))
+		})
+	}
+}
+
+func TestExportAlertGraph(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alertItem := newTestAlert("crowdsecurity/test", "1.2.3.4")
+	alertItem.Labels = []string{"ssh", "bruteforce"}
+	ids, err := dbClient.CreateAlertBulk("test", []*models.Alert{alertItem})
+	assert.NoError(t, err)
+	id, err := strconv.Atoi(ids[0])
+	assert.NoError(t, err)
+
+	output, err := dbClient.ExportAlertGraph(id)
+	assert.NoError(t, err)
+	var exported models.Alert
+	assert.NoError(t, json.Unmarshal(output, &exported))
+
+	assert.Equal(t, int64(id), exported.ID)
+	assert.Equal(t, *alertItem.Scenario, *exported.Scenario)
+	assert.Equal(t, *alertItem.StartAt, *exported.StartAt)
+	assert.Equal(t, *alertItem.StopAt, *exported.StopAt)
+	assert.Equal(t, alertItem.Labels, exported.Labels)
+	assert.Equal(t, alertItem.Meta, exported.Meta)
+	assert.Len(t, exported.Events, 1)
+	assert.Equal(t, alertItem.Events[0].Meta, exported.Events[0].Meta)
+	assert.Equal(t, *alertItem.Events[0].Timestamp, *exported.Events[0].Timestamp)
+	assert.Len(t, exported.Decisions, 1)
+	assert.Equal(t, "4h0m0s", *exported.Decisions[0].Duration)
+	assert.Equal(t, *alertItem.Source.Value, *exported.Decisions[0].Value)
+
+	/*the export can be imported again*/
+	_, err = dbClient.CreateAlertBulk("test", []*models.Alert{&exported})
+	assert.NoError(t, err)
+
+	_, err = dbClient.ExportAlertGraph(id + 42)
+	assert.Equal(t, ItemNotFound, errors.Cause(err))
+}
+
+func TestIterateAlertsWithFilter(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	_, err := dbClient.CreateAlertBulk("test", newTestAlerts(1000))
+	assert.NoError(t, err)
+
+	seen := make(map[int]int)
+	err = dbClient.IterateAlertsWithFilter(map[string][]string{}, func(alertItem *ent.Alert) error {
+		seen[alertItem.ID]++
+		assert.Len(t, alertItem.Edges.Events, 1)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, seen, 1000)
+	for id, nbCalls := range seen {
+		assert.Equal(t, 1, nbCalls, "alert %d", id)
+	}
+
+	/*the iteration stops with the error of the callback*/
+	stop := errors.New("stop")
+	nbCalls := 0
+	err = dbClient.IterateAlertsWithFilter(map[string][]string{}, func(alertItem *ent.Alert) error {
+		nbCalls++
+		if nbCalls == paginationSize+10 {
+			return stop
+		}
+		return nil
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, paginationSize+10, nbCalls)
+
+	/*the callback can delete the alerts it is given without any being skipped*/
+	nbCalls = 0
+	err = dbClient.IterateAlertsWithFilter(map[string][]string{}, func(alertItem *ent.Alert) error {
+		nbCalls++
+		return dbClient.DeleteAlertGraph(alertItem)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1000, nbCalls)
+	total, err := dbClient.TotalAlerts()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, total)
+
+	err = dbClient.IterateAlertsWithFilter(map[string][]string{"unknown": {"x"}}, func(*ent.Alert) error { return nil })
+	assert.Equal(t, InvalidFilter, errors.Cause(err))
+}
+
+func TestExportAlerts(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := []*models.Alert{
+		newTestAlert("crowdsecurity/ssh-bf", "1.2.3.4"),
+		newTestAlert("crowdsecurity/http-probing", "1.2.3.5"),
+	}
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+
+	var output bytes.Buffer
+	assert.NoError(t, dbClient.ExportAlerts(map[string][]string{}, &output, true))
+	var exported []models.Alert
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &exported))
+	assert.Len(t, exported, 2)
+	for i, alertItem := range alerts {
+		assert.Equal(t, *alertItem.Scenario, *exported[i].Scenario)
+		assert.Equal(t, *alertItem.Source.Value, *exported[i].Source.Value)
+		assert.Equal(t, alertItem.Meta, exported[i].Meta)
+		assert.Len(t, exported[i].Events, 1)
+		assert.Len(t, exported[i].Decisions, 1)
+	}
+
+	/*without events, and filtered*/
+	output.Reset()
+	assert.NoError(t, dbClient.ExportAlerts(map[string][]string{"scenario": {"crowdsecurity/ssh-bf"}}, &output, false))
+	exported = nil
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &exported))
+	assert.Len(t, exported, 1)
+	assert.Equal(t, "crowdsecurity/ssh-bf", *exported[0].Scenario)
+	assert.Empty(t, exported[0].Events)
+	assert.Len(t, exported[0].Decisions, 1)
+
+	/*nothing matches*/
+	output.Reset()
+	assert.NoError(t, dbClient.ExportAlerts(map[string][]string{"scenario": {"crowdsecurity/nope"}}, &output, false))
+	assert.Equal(t, "[]", output.String())
+
+	err = dbClient.ExportAlerts(map[string][]string{"unknown": {"x"}}, &output, false)
+	assert.Equal(t, InvalidFilter, errors.Cause(err))
+}
+
+func TestCreateAlertBulkSkipsBadAlert(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	/*the serialized meta of this event is too long to be stored, failing the first batch of 20*/
+	alerts := newTestAlerts(25)
+	alerts[3].Events[0].Meta = append(alerts[3].Events[0].Meta, &models.MetaItems0{Key: "payload", Value: strings.Repeat("A", 5000)})
+
+	ids, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.Equal(t, BulkError, errors.Cause(err))
+	skipped, ok := err.(BulkInsertErrors)
+	assert.True(t, ok)
+	assert.Len(t, skipped, 1)
+	assert.Equal(t, 3, skipped[0].Index)
+	assert.Len(t, ids, 24)
+
+	nbAlerts, err := dbClient.TotalAlerts()
+	assert.NoError(t, err)
+	assert.Equal(t, 24, nbAlerts)
+	bad, err := dbClient.QueryAlertWithFilter(map[string][]string{"value": {*alerts[3].Source.Value}})
+	assert.NoError(t, err)
+	assert.Len(t, bad, 0)
+	nbEvents, err := dbClient.Ent.Event.Query().Count(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 24, nbEvents)
+}
+
+func TestCreateAlertBulkReversedIPBounds(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	newReversedAlert := func() *models.Alert {
+		alertItem := newTestAlert("crowdsecurity/test", "1.2.3.4")
+		decisionItem := newTestDecision("1.2.3.0/24", types.Range, "ban", "4h")
+		startIP, endIP, err := GetIpsFromIpRange("1.2.3.0/24")
+		assert.NoError(t, err)
+		decisionItem.StartIP, decisionItem.EndIP = endIP, startIP
+		alertItem.Decisions = []*models.Decision{decisionItem}
+		return alertItem
+	}
+
+	ids, err := dbClient.CreateAlertBulk("test", []*models.Alert{newReversedAlert()})
+	assert.Empty(t, ids)
+	assert.Equal(t, InvalidIPOrRange, errors.Cause(err))
+	nbDecisions, err := dbClient.Ent.Decision.Query().Count(dbClient.CTX)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, nbDecisions)
+
+	dbClient.SwapReversedIPBounds = true
+	_, err = dbClient.CreateAlertBulk("test", []*models.Alert{newReversedAlert()})
+	assert.NoError(t, err)
+	alerts, err := dbClient.QueryAlertWithFilter(map[string][]string{"ip": {"1.2.3.42"}})
+	assert.NoError(t, err)
+	assert.Len(t, alerts, 1)
+}
+
+func TestCreateAlertBulkDecisionType(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	newTypedAlert := func(decisionType string) *models.Alert {
+		alertItem := newTestAlert("crowdsecurity/test", "1.2.3.4")
+		alertItem.Decisions = []*models.Decision{newTestDecision("1.2.3.4", types.Ip, decisionType, "4h")}
+		return alertItem
+	}
+
+	alerts := []*models.Alert{newTypedAlert(" Ban"), newTypedAlert("CAPTCHA"), newTypedAlert("tarpit")}
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+	/*the alerts given are left as they are*/
+	assert.Equal(t, " Ban", *alerts[0].Decisions[0].Type)
+	assert.Equal(t, "CAPTCHA", *alerts[1].Decisions[0].Type)
+	decisions, err := dbClient.Ent.Decision.Query().Order(ent.Asc(decision.FieldID)).All(dbClient.CTX)
+	assert.NoError(t, err)
+	if assert.Len(t, decisions, 3) {
+		assert.Equal(t, "ban", decisions[0].Type)
+		assert.Equal(t, "captcha", decisions[1].Type)
+		assert.Equal(t, "tarpit", decisions[2].Type)
+	}
+
+	dbClient.RejectUnknownDecisionTypes = true
+	ids, err := dbClient.CreateAlertBulk("test", []*models.Alert{newTypedAlert("tarpit")})
+	assert.Empty(t, ids)
+	assert.Equal(t, InvalidDecisionType, errors.Cause(err))
+	_, err = dbClient.CreateAlertBulk("test", []*models.Alert{newTypedAlert("Throttle")})
+	assert.NoError(t, err)
+	nbDecisions, err := dbClient.Ent.Decision.Query().Count(dbClient.CTX)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, nbDecisions)
+
+	/*the dedup compares the normalized type*/
+	dbClient.DedupDecisions = true
+	_, err = dbClient.CreateAlertBulk("test", []*models.Alert{newTypedAlert("BAN ")})
+	assert.NoError(t, err)
+	nbDecisions, err = dbClient.Ent.Decision.Query().Count(dbClient.CTX)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, nbDecisions)
+}
+
+func TestAlertWithoutDecisionTypeFilter(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	/*a ban, a captcha, both, and no decision at all*/
+	alerts := newTestAlerts(4)
+	captcha := "captcha"
+	alerts[1].Decisions[0].Type = &captcha
+	alerts[2].Decisions = append(alerts[2].Decisions, newTestDecision("1.2.3.2", types.Ip, "captcha", "4h"))
+	alerts[3].Decisions = nil
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+
+	alertSourceValues := func(alerts []*ent.Alert) []string {
+		values := make([]string, 0, len(alerts))
+		for _, alertItem := range alerts {
+			values = append(values, alertItem.SourceValue)
+		}
+		return values
+	}
+
+	withoutBan, err := dbClient.QueryAlertWithFilter(map[string][]string{"without_decision_type": {"ban"}})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"1.2.3.1", "1.2.3.3"}, alertSourceValues(withoutBan))
+
+	captchaWithoutBan, err := dbClient.QueryAlertWithFilter(map[string][]string{"decision_type": {"captcha"}, "without_decision_type": {"ban"}})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"1.2.3.1"}, alertSourceValues(captchaWithoutBan))
+
+	none, err := dbClient.QueryAlertWithFilter(map[string][]string{"decision_type": {"ban"}, "without_decision_type": {"ban"}})
+	assert.NoError(t, err)
+	assert.Empty(t, none)
+
+	_, err = dbClient.QueryAlertWithFilter(map[string][]string{"without_decision_type": {""}})
+	assert.Equal(t, InvalidFilter, errors.Cause(err))
+}
+
+func TestAlertIPFilterContainment(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	newDecisionsAlert := func(sourceIP string, ranges ...string) *models.Alert {
+		alertItem := newTestAlert("crowdsecurity/test", sourceIP)
+		alertItem.Decisions = nil
+		for _, ipRange := range ranges {
+			decisionItem := newTestDecision(ipRange, types.Range, "ban", "4h")
+			startIP, endIP, err := GetIpsFromIpRange(ipRange)
+			assert.NoError(t, err)
+			decisionItem.StartIP, decisionItem.EndIP = startIP, endIP
+			alertItem.Decisions = append(alertItem.Decisions, decisionItem)
+		}
+		return alertItem
+	}
+	/*a /24 containing 1.2.3.42, and two decisions around it that don't : one ends before it, the other starts after it*/
+	_, err := dbClient.CreateAlertBulk("test", []*models.Alert{
+		newDecisionsAlert("1.2.3.1", "1.2.3.0/24"),
+		newDecisionsAlert("1.2.3.2", "1.2.3.0/27", "1.2.3.128/25"),
+	})
+	assert.NoError(t, err)
+
+	alerts, err := dbClient.QueryAlertWithFilter(map[string][]string{"ip": {"1.2.3.42"}})
+	assert.NoError(t, err)
+	if assert.Len(t, alerts, 1) {
+		assert.Equal(t, "1.2.3.1", alerts[0].SourceValue)
+	}
+	/*the same goes for the ranges : a single decision must be within the range*/
+	alerts, err = dbClient.QueryAlertWithFilter(map[string][]string{"range": {"1.2.3.64/26"}})
+	assert.NoError(t, err)
+	assert.Empty(t, alerts)
+	alerts, err = dbClient.QueryAlertWithFilter(map[string][]string{"range": {"1.2.3.0/26"}})
+	assert.NoError(t, err)
+	if assert.Len(t, alerts, 1) {
+		assert.Equal(t, "1.2.3.2", alerts[0].SourceValue)
+	}
+}
+
+func TestAlertIPClassFilter(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := []*models.Alert{
+		newTestAlert("crowdsecurity/test", "10.0.0.1"),
+		newTestAlert("crowdsecurity/test", "172.31.255.1"),
+		newTestAlert("crowdsecurity/test", "192.168.1.1"),
+		newTestAlert("crowdsecurity/test", "172.32.0.1"),
+		newTestAlert("crowdsecurity/test", "100.0.0.1"),
+		newTestAlert("crowdsecurity/test", "2001:db8::1"),
+	}
+	/*without decisions, or with decisions on other addresses, the alerts keep the class of their source*/
+	alerts[1].Decisions = nil
+	alerts[4].Decisions = nil
+	alerts[2].Decisions[0] = newTestDecision("8.8.8.8", types.Ip, "ban", "4h")
+	userScope := "Username"
+	userAlert := newTestAlert("crowdsecurity/test", "10.0.0.2")
+	userAlert.Source.Scope = &userScope
+	_, err := dbClient.CreateAlertBulk("test", append(alerts, userAlert))
+	assert.NoError(t, err)
+	_, err = dbClient.Ent.Decision.Delete().Where(decision.ValueEQ("10.0.0.1")).Exec(dbClient.CTX)
+	assert.NoError(t, err)
+
+	sourceValues := func(ipClass string) []string {
+		alerts, err := dbClient.QueryAlertWithFilter(map[string][]string{"ip_class": {ipClass}})
+		assert.NoError(t, err)
+		values := make([]string, 0, len(alerts))
+		for _, alertItem := range alerts {
+			values = append(values, alertItem.SourceValue)
+		}
+		return values
+	}
+	assert.ElementsMatch(t, []string{"10.0.0.1", "172.31.255.1", "192.168.1.1"}, sourceValues("private"))
+	assert.ElementsMatch(t, []string{"172.32.0.1", "100.0.0.1", "2001:db8::1"}, sourceValues("public"))
+
+	_, err = dbClient.QueryAlertWithFilter(map[string][]string{"ip_class": {"internal"}})
+	assert.Equal(t, InvalidFilter, errors.Cause(err))
+}
+
+func TestRepeatOffenders(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := []*models.Alert{}
+	for source, nb := range map[string]int{"1.2.3.4": 3, "5.6.7.8": 2, "9.9.9.9": 2, "10.0.0.1": 1} {
+		for i := 0; i < nb; i++ {
+			alerts = append(alerts, newTestAlert("crowdsecurity/test", source))
+		}
+	}
+	ids, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+	/*the creation times go backwards with the ids : the last alert of each source isn't its most recent one*/
+	lastSeen := map[string]time.Time{}
+	for i, id := range ids {
+		alertID, err := strconv.Atoi(id)
+		assert.NoError(t, err)
+		createdAt := time.Now().UTC().Add(-time.Duration(i) * time.Hour).Truncate(time.Second)
+		_, err = dbClient.Ent.Alert.UpdateOneID(alertID).SetCreatedAt(createdAt).Save(dbClient.CTX)
+		assert.NoError(t, err)
+		if _, ok := lastSeen[*alerts[i].Source.Value]; !ok {
+			lastSeen[*alerts[i].Source.Value] = createdAt
+		}
+	}
+
+	/*the groups are limited by the database, not after loading all of them*/
+	queries := []string{}
+	dbClient.Ent = ent.NewClient(ent.Driver(dialect.Debug(dbClient.drv, func(args ...interface{}) {
+		queries = append(queries, fmt.Sprint(args...))
+	})))
+	offenders, err := dbClient.RepeatOffenders(map[string][]string{}, 3)
+	assert.NoError(t, err)
+	if assert.Len(t, queries, 1) {
+		assert.Contains(t, queries[0], "LIMIT 3")
+	}
+	if assert.Len(t, offenders, 3) {
+		assert.Equal(t, "1.2.3.4", offenders[0].Value)
+		assert.Equal(t, 3, offenders[0].Count)
+		/*with as many alerts, the most recently seen comes first*/
+		first, second := "5.6.7.8", "9.9.9.9"
+		if lastSeen[second].After(lastSeen[first]) {
+			first, second = second, first
+		}
+		assert.Equal(t, []string{first, second}, []string{offenders[1].Value, offenders[2].Value})
+		for _, offender := range offenders {
+			assert.True(t, lastSeen[offender.Value].Equal(offender.LastSeen), "%s : %s != %s", offender.Value, lastSeen[offender.Value], offender.LastSeen)
+		}
+	}
+
+	offenders, err = dbClient.RepeatOffenders(map[string][]string{"ip": {"10.0.0.1"}}, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, offenders, 1) {
+		assert.Equal(t, 1, offenders[0].Count)
+	}
+}
+
+func TestAlertContinentFilter(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := newTestAlerts(4)
+	for i, country := range []string{"FR", "DE", "JP", "US"} {
+		alerts[i].Source.Cn = country
+	}
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+
+	europe, err := dbClient.QueryAlertWithFilter(map[string][]string{"continent": {"EU"}})
+	assert.NoError(t, err)
+	assert.Len(t, europe, 2)
+
+	asia, err := dbClient.QueryAlertWithFilter(map[string][]string{"continent": {"as"}})
+	assert.NoError(t, err)
+	assert.Len(t, asia, 1)
+	assert.Equal(t, "JP", asia[0].SourceCountry)
+
+	_, err = dbClient.QueryAlertWithFilter(map[string][]string{"continent": {"atlantis"}})
+	assert.Equal(t, InvalidFilter, errors.Cause(err))
+}
+
+func TestAlertDecisionSimulatedFilter(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := newTestAlerts(2)
+	captcha := newTestDecision("1.2.3.0", types.Ip, "captcha", "4h")
+	captcha.StartIP, captcha.EndIP = alerts[0].Decisions[0].StartIP, alerts[0].Decisions[0].EndIP
+	alerts[0].Decisions = append(alerts[0].Decisions, captcha)
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+	/*the alerts aren't simulated, but the captcha on 1.2.3.0 and the decision on 1.2.3.1 are*/
+	_, err = dbClient.Ent.Decision.Update().
+		Where(decision.Or(decision.TypeEQ("captcha"), decision.ValueEQ("1.2.3.1"))).
+		SetSimulated(true).
+		Save(dbClient.CTX)
+	assert.NoError(t, err)
+
+	result, err := dbClient.QueryAlertWithFilter(map[string][]string{"decision_simulated": {"false"}, "has_active_decision": {"true"}})
+	assert.NoError(t, err)
+	if assert.Len(t, result, 1) {
+		assert.Equal(t, "1.2.3.0", result[0].SourceValue)
+		/*only the real decision is loaded*/
+		if assert.Len(t, result[0].Edges.Decisions, 1) {
+			assert.Equal(t, "ban", result[0].Edges.Decisions[0].Type)
+		}
+	}
+	result, err = dbClient.QueryAlertWithFilter(map[string][]string{"decision_simulated": {"true"}})
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	/*without the filter, all the decisions are there*/
+	result, err = dbClient.QueryAlertWithFilter(map[string][]string{"value": {"1.2.3.0"}})
+	assert.NoError(t, err)
+	if assert.Len(t, result, 1) {
+		assert.Len(t, result[0].Edges.Decisions, 2)
+	}
+
+	decisions, err := dbClient.GetActiveDecisionsByIP("1.2.3.0", false)
+	assert.NoError(t, err)
+	if assert.Len(t, decisions, 1) {
+		assert.Equal(t, "ban", decisions[0].Type)
+	}
+	decisions, err = dbClient.GetActiveDecisionsByIP("1.2.3.0", true)
+	assert.NoError(t, err)
+	assert.Len(t, decisions, 2)
+
+	_, err = dbClient.QueryAlertWithFilter(map[string][]string{"decision_simulated": {"maybe"}})
+	assert.Equal(t, ParseType, errors.Cause(err))
+}
+
+func TestAlertEventsCountFilters(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := newTestAlerts(5)
+	for i, count := range []int32{1, 100, 500, 1000, 5000} {
+		count := count
+		alerts[i].EventsCount = &count
+	}
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+
+	eventsCounts := func(filter map[string][]string) []int32 {
+		alerts, err := dbClient.QueryAlertWithFilter(filter)
+		assert.NoError(t, err)
+		counts := make([]int32, 0, len(alerts))
+		for _, alertItem := range alerts {
+			counts = append(counts, alertItem.EventsCount)
+		}
+		return counts
+	}
+
+	assert.ElementsMatch(t, []int32{1000, 5000}, eventsCounts(map[string][]string{"events_count_gte": {"1000"}}))
+	assert.ElementsMatch(t, []int32{1, 100}, eventsCounts(map[string][]string{"events_count_lte": {"100"}}))
+	assert.ElementsMatch(t, []int32{100, 500, 1000}, eventsCounts(map[string][]string{
+		"events_count_gte": {"100"},
+		"events_count_lte": {"1000"},
+	}))
+
+	for _, filter := range []map[string][]string{
+		{"events_count_gte": {"many"}},
+		{"events_count_lte": {"1.5"}},
+	} {
+		_, err = dbClient.QueryAlertWithFilter(filter)
+		assert.Equal(t, ParseType, errors.Cause(err), "%v", filter)
+	}
+}
+
+func TestAlertCountryAndASNumberFilters(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := newTestAlerts(4)
+	for i, source := range []struct{ country, asNumber string }{
+		{"RU", "1234"},
+		{"FR", "1234"},
+		{"RU", "5678"},
+		{"US", "9999"},
+	} {
+		alerts[i].Source.Cn = source.country
+		alerts[i].Source.AsNumber = source.asNumber
+	}
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+
+	russia, err := dbClient.QueryAlertWithFilter(map[string][]string{"country": {"ru"}})
+	assert.NoError(t, err)
+	assert.Len(t, russia, 2)
+	for _, alertItem := range russia {
+		assert.Equal(t, "RU", alertItem.SourceCountry)
+	}
+
+	asNumbers, err := dbClient.QueryAlertWithFilter(map[string][]string{"as_number": {"1234, 9999"}})
+	assert.NoError(t, err)
+	assert.Len(t, asNumbers, 3)
+
+	both, err := dbClient.QueryAlertWithFilter(map[string][]string{"country": {"RU"}, "as_number": {"1234", "9999"}})
+	assert.NoError(t, err)
+	if assert.Len(t, both, 1) {
+		assert.Equal(t, "1234", both[0].SourceAsNumber)
+	}
+
+	for _, filter := range []map[string][]string{
+		{"country": {""}},
+		{"country": {"RU,"}},
+		{"as_number": {""}},
+	} {
+		_, err = dbClient.QueryAlertWithFilter(filter)
+		assert.Equal(t, InvalidFilter, errors.Cause(err), "%v", filter)
+	}
+}
+
+func TestRecentCounts(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := newTestAlerts(3)
+	alerts[0].Decisions = append(alerts[0].Decisions, newTestDecision("1.2.3.0", "Ip", "captcha", "1h"))
+	ids, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+
+	/*make one alert and its decision look older than the window*/
+	oldID, err := strconv.Atoi(ids[2])
+	assert.NoError(t, err)
+	old := time.Now().Add(-time.Hour)
+	_, err = dbClient.Ent.Alert.UpdateOneID(oldID).SetCreatedAt(old).Save(context.Background())
+	assert.NoError(t, err)
+	_, err = dbClient.Ent.Decision.Update().Where(decision.HasOwnerWith(alert.IDEQ(oldID))).SetCreatedAt(old).Save(context.Background())
+	assert.NoError(t, err)
+
+	nbAlerts, nbDecisions, err := dbClient.RecentCounts(5 * time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, nbAlerts)
+	assert.Equal(t, 3, nbDecisions)
+
+	nbAlerts, nbDecisions, err = dbClient.RecentCounts(2 * time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, nbAlerts)
+	assert.Equal(t, 4, nbDecisions)
+}
+
+func TestAlertDecisionValueContainsFilter(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := newTestAlerts(3)
+	alerts[0].Decisions = append(alerts[0].Decisions, newTestDecision("AdminUser", "username", "ban", "4h"))
+	alerts[1].Decisions = append(alerts[1].Decisions, newTestDecision("guest", "username", "ban", "4h"))
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+
+	matches, err := dbClient.QueryAlertWithFilter(map[string][]string{"decision_value_contains": {"admin"}})
+	assert.NoError(t, err)
+	assert.Len(t, matches, 1)
+	assert.Equal(t, "1.2.3.0", matches[0].SourceValue)
+
+	/*ip decisions are never matched on their value*/
+	matches, err = dbClient.QueryAlertWithFilter(map[string][]string{"decision_value_contains": {"1.2.3"}})
+	assert.NoError(t, err)
+	assert.Len(t, matches, 0)
+
+	_, err = dbClient.QueryAlertWithFilter(map[string][]string{"decision_value_contains": {"1.2.3"}, "scope": {"ip"}})
+	assert.Equal(t, InvalidFilter, errors.Cause(err))
+}
+
+func TestAlertRangePrefixMaxFilter(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := newTestAlerts(3)
+	for i, cidr := range []string{"10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24"} {
+		startIP, endIP, err := GetIpsFromIpRange(cidr)
+		assert.NoError(t, err)
+		rangeDecision := newTestDecision(cidr, "Range", "ban", "4h")
+		rangeDecision.StartIP = startIP
+		rangeDecision.EndIP = endIP
+		alerts[i].Decisions = []*models.Decision{rangeDecision}
+	}
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+
+	for prefix, expected := range map[string]int{"8": 1, "12": 1, "16": 2, "24": 3, "32": 3, "0": 0} {
+		matches, err := dbClient.QueryAlertWithFilter(map[string][]string{"range_prefix_max": {prefix}})
+		assert.NoError(t, err)
+		assert.Len(t, matches, expected, "prefix /%s", prefix)
+	}
+
+	for _, prefix := range []string{"33", "-1", "wide"} {
+		_, err = dbClient.QueryAlertWithFilter(map[string][]string{"range_prefix_max": {prefix}})
+		assert.Equal(t, InvalidFilter, errors.Cause(err))
+	}
+}
+
+func TestImportAlertsKeepsIDs(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := newTestAlerts(3)
+	alerts[0].ID = 100
+	alerts[1].ID = 200
+	ids, err := dbClient.ImportAlerts("test", alerts, false)
+	assert.NoError(t, err)
+	assert.Len(t, ids, 3)
+	assert.Equal(t, []string{"100", "200"}, ids[:2])
+
+	imported, err := dbClient.GetAlertByID(200)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.3.1", imported.SourceValue)
+	assert.Len(t, imported.Edges.Decisions, 1)
+
+	/*normal ingestion goes on after the imported ids*/
+	created, err := dbClient.CreateAlertBulk("test", newTestAlerts(1))
+	assert.NoError(t, err)
+	createdID, err := strconv.Atoi(created[0])
+	assert.NoError(t, err)
+	assert.True(t, createdID > 200)
+
+	/*conflicting ids abort the import, unless they are skipped*/
+	conflicting := newTestAlerts(3)
+	conflicting[0].ID = 100
+	conflicting[1].ID = 300
+	conflicting[2].ID = 300
+	_, err = dbClient.ImportAlerts("test", conflicting, false)
+	assert.Equal(t, IDConflict, errors.Cause(err))
+	_, err = dbClient.GetAlertByID(300)
+	assert.Equal(t, ItemNotFound, errors.Cause(err))
+
+	ids, err = dbClient.ImportAlerts("test", conflicting, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"300"}, ids)
+	nbAlerts, err := dbClient.TotalAlerts()
+	assert.NoError(t, err)
+	assert.Equal(t, 5, nbAlerts)
+}
+
+func TestAlertOriginPrefixFilter(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := newTestAlerts(3)
+	for i, origin := range []string{"lists:firehol", "lists:spamhaus", "crowdsec"} {
+		origin := origin
+		alerts[i].Decisions[0].Origin = &origin
+	}
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+
+	lists, err := dbClient.QueryAlertWithFilter(map[string][]string{"origin_prefix": {"lists:"}})
+	assert.NoError(t, err)
+	assert.Len(t, lists, 2)
+
+	lists, err = dbClient.QueryAlertWithFilter(map[string][]string{"origin_prefix": {"lists:fire"}})
+	assert.NoError(t, err)
+	assert.Len(t, lists, 1)
+	assert.Equal(t, "1.2.3.0", lists[0].SourceValue)
+}
+
+func TestAuditEventsCount(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := newTestAlerts(paginationSize + 5)
+	var wrongCount int32 = 3
+	alerts[2].EventsCount = &wrongCount
+	alerts[paginationSize+1].EventsCount = &wrongCount
+	/*no event stored, but the alert says there was one*/
+	alerts[4].Events = nil
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+
+	mismatched, err := dbClient.AuditEventsCount(false)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, mismatched)
+	/*auditing alone doesn't change anything*/
+	mismatched, err = dbClient.AuditEventsCount(true)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, mismatched)
+
+	mismatched, err = dbClient.AuditEventsCount(false)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, mismatched)
+	fixed, err := dbClient.QueryAlertWithFilter(map[string][]string{"value": {*alerts[4].Source.Value}})
+	assert.NoError(t, err)
+	assert.Len(t, fixed, 1)
+	assert.Equal(t, int32(0), fixed[0].EventsCount)
+}
+
+func TestCreateAlertBulkConnectionError(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	dbClient.Ent.Alert.Use(func(next ent.Mutator) ent.Mutator {
+		return ent.MutateFunc(func(ctx context.Context, m ent.Mutation) (ent.Value, error) {
+			return nil, driver.ErrBadConn
+		})
+	})
+	/*the batch isn't retried alert by alert, the caller has to retry once the connection is back*/
+	ids, err := dbClient.CreateAlertBulk("test", newTestAlerts(3))
+	assert.True(t, errors.Is(err, ConnectionError))
+	assert.Empty(t, ids)
+}
+
+func TestCreateAlertBulkSingleMachineLookup(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	machineID := "test"
+	password := strfmt.Password("password")
+	_, err := dbClient.CreateMachine(&machineID, &password, "127.0.0.1", true, false)
+	assert.NoError(t, err)
+
+	/*count the queries on the machines table*/
+	nbMachineQueries := 0
+	dbClient.Ent = ent.NewClient(ent.Driver(dialect.Debug(dbClient.drv, func(args ...interface{}) {
+		if strings.Contains(fmt.Sprint(args...), "FROM `machines`") {
+			nbMachineQueries++
+		}
+	})))
+
+	ids, err := dbClient.CreateAlertBulk(machineID, newTestAlerts(5000))
+	assert.NoError(t, err)
+	assert.Len(t, ids, 5000)
+	assert.Equal(t, 1, nbMachineQueries)
+
+	alerts, err := dbClient.MachineRecentAlerts(machineID, time.Hour)
+	assert.NoError(t, err)
+	assert.Len(t, alerts, 5000)
+}
+
+func TestAlertIPv6Filters(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := []*models.Alert{
+		newTestAlert("crowdsecurity/ssh-bf", "2001:db8:0:1::5"),
+		newTestAlert("crowdsecurity/ssh-bf", "2001:db8:0:1:ffff::1"),
+		newTestAlert("crowdsecurity/ssh-bf", "2001:db8:0:2::5"),
+		newTestAlert("crowdsecurity/ssh-bf", "1.2.3.4"),
+	}
+	rangeScope := types.Range
+	rangeValue := "2001:db8:0:1::/64"
+	alerts[1].Decisions[0].Scope = &rangeScope
+	alerts[1].Decisions[0].Value = &rangeValue
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+
+	inRange, err := dbClient.QueryAlertWithFilter(map[string][]string{"range": {"2001:db8:0:1::/64"}})
+	assert.NoError(t, err)
+	values := make([]string, 0, len(inRange))
+	for _, alertItem := range inRange {
+		values = append(values, alertItem.SourceValue)
+	}
+	assert.ElementsMatch(t, []string{"2001:db8:0:1::5", "2001:db8:0:1:ffff::1"}, values)
+
+	/*the /64 decision covers the address as well*/
+	single, err := dbClient.QueryAlertWithFilter(map[string][]string{"ip": {"2001:db8:0:1::5"}})
+	assert.NoError(t, err)
+	assert.Len(t, single, 2)
+
+	single, err = dbClient.QueryAlertWithFilter(map[string][]string{"ip": {"2001:db8:0:2::5"}})
+	assert.NoError(t, err)
+	if assert.Len(t, single, 1) {
+		assert.Equal(t, "2001:db8:0:2::5", single[0].SourceValue)
+	}
+
+	single, err = dbClient.QueryAlertWithFilter(map[string][]string{"ip": {"2001:db8:0:2::6"}})
+	assert.NoError(t, err)
+	assert.Len(t, single, 0)
+
+	/*IPv4 lookups are unchanged*/
+	single, err = dbClient.QueryAlertWithFilter(map[string][]string{"ip": {"1.2.3.4"}})
+	assert.NoError(t, err)
+	if assert.Len(t, single, 1) {
+		assert.Equal(t, "1.2.3.4", single[0].SourceValue)
+	}
+}
+
+func TestQueryAlertWithFilterOffset(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	_, err := dbClient.CreateAlertBulk("test", newTestAlerts(50))
+	assert.NoError(t, err)
+
+	page, err := dbClient.QueryAlertWithFilter(map[string][]string{"sort": {"ASC"}, "limit": {"10"}, "offset": {"20"}})
+	assert.NoError(t, err)
+	if assert.Len(t, page, 10) {
+		for i, alertItem := range page {
+			assert.Equal(t, Int2ip(uint32(0x01020300+20+i)).String(), alertItem.SourceValue)
+		}
+	}
+
+	/*without limit, all the alerts after the offset are returned*/
+	page, err = dbClient.QueryAlertWithFilter(map[string][]string{"limit": {"0"}, "offset": {"45"}})
+	assert.NoError(t, err)
+	assert.Len(t, page, 5)
+
+	for _, filter := range []map[string][]string{
+		{"offset": {"-1"}},
+		{"offset": {"ten"}},
+		{"limit": {"-1"}},
+	} {
+		_, err = dbClient.QueryAlertWithFilter(filter)
+		assert.True(t, errors.Is(err, InvalidFilter), "%v: %v", filter, err)
+	}
+}
+
+func TestQueryAlertWithFilterSort(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := newTestAlerts(3)
+	for i, count := range []int32{5, 1, 3} {
+		count := count
+		alerts[i].EventsCount = &count
+	}
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+
+	sourceValues := func(alerts []*ent.Alert) []string {
+		values := make([]string, 0, len(alerts))
+		for _, alertItem := range alerts {
+			values = append(values, alertItem.SourceValue)
+		}
+		return values
+	}
+
+	sorted, err := dbClient.QueryAlertWithFilter(map[string][]string{"sort": {"created_at"}, "order": {"desc"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1.2.3.2", "1.2.3.1", "1.2.3.0"}, sourceValues(sorted))
+
+	sorted, err = dbClient.QueryAlertWithFilter(map[string][]string{"sort": {"events_count"}, "order": {"asc"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1.2.3.1", "1.2.3.2", "1.2.3.0"}, sourceValues(sorted))
+
+	/*the former ASC/DESC values of sort still apply to the creation date*/
+	sorted, err = dbClient.QueryAlertWithFilter(map[string][]string{"sort": {"ASC"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1.2.3.0", "1.2.3.1", "1.2.3.2"}, sourceValues(sorted))
+
+	for _, filter := range []map[string][]string{
+		{"sort": {"id; DROP TABLE alerts"}},
+		{"sort": {"message"}},
+		{"order": {"sideways"}},
+	} {
+		_, err = dbClient.QueryAlertWithFilter(filter)
+		assert.True(t, errors.Is(err, InvalidFilter), "%v: %v", filter, err)
+	}
+}
+
+func TestQueryAlertWithFilterLast(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	_, err := dbClient.CreateAlertBulk("test", newTestAlerts(30))
+	assert.NoError(t, err)
+	/*the creation dates go backward with the ids, so that the most recent alerts are the first inserted*/
+	now := time.Now()
+	for id := 1; id <= 30; id++ {
+		err := dbClient.Ent.Alert.UpdateOneID(id).SetCreatedAt(now.Add(-time.Duration(id) * time.Minute)).Exec(dbClient.CTX)
+		assert.NoError(t, err)
+	}
+
+	last, err := dbClient.QueryAlertWithFilter(map[string][]string{"last": {"5"}})
+	assert.NoError(t, err)
+	ids := make([]int, 0, len(last))
+	for _, alertItem := range last {
+		ids = append(ids, alertItem.ID)
+	}
+	/*the oldest of the five comes first*/
+	assert.Equal(t, []int{5, 4, 3, 2, 1}, ids)
+
+	last, err = dbClient.QueryAlertWithFilter(map[string][]string{"last": {"50"}})
+	assert.NoError(t, err)
+	assert.Len(t, last, 30)
+
+	for _, value := range []string{"0", "-1", "five"} {
+		_, err = dbClient.QueryAlertWithFilter(map[string][]string{"last": {value}})
+		assert.True(t, errors.Is(err, InvalidFilter), "%s: %v", value, err)
+	}
+}
+
+func TestCreateAlertBulkRetriedBatch(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	countDecisions := func() int {
+		nbDecisions, err := dbClient.Ent.Decision.Query().Count(dbClient.CTX)
+		assert.NoError(t, err)
+		return nbDecisions
+	}
+	machineID := "test"
+	password := strfmt.Password("password")
+	_, err := dbClient.CreateMachine(&machineID, &password, "127.0.0.1", true, false)
+	assert.NoError(t, err)
+
+	dbClient.UpsertDecisions = true
+	batch := newTestAlerts(3)
+	for i := 0; i < 2; i++ {
+		_, err := dbClient.CreateAlertBulk("test", batch)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, countDecisions())
+	}
+
+	/*the same decisions from another machine (here, ownerless alerts) are kept apart*/
+	for i := 0; i < 2; i++ {
+		_, err := dbClient.CreateAlertBulk("unknown", batch)
+		assert.NoError(t, err)
+		assert.Equal(t, 6, countDecisions())
+	}
+
+	/*the retried decision takes the expiration of the retry*/
+	longer := "8h"
+	batch[0].Decisions[0].Duration = &longer
+	_, err = dbClient.CreateAlertBulk("test", batch)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, countDecisions())
+	decisions, err := dbClient.Ent.Decision.Query().
+		Where(decision.ValueEQ("1.2.3.0"), decision.HasOwnerWith(alert.HasOwner())).
+		All(dbClient.CTX)
+	assert.NoError(t, err)
+	if assert.Len(t, decisions, 1) {
+		stopAt, err := time.Parse(time.RFC3339, *batch[0].StopAt)
+		assert.NoError(t, err)
+		assert.WithinDuration(t, stopAt.Add(8*time.Hour), decisions[0].Until, time.Second)
+	}
+
+	/*a simulated decision doesn't update a real one*/
+	simulated := true
+	batch[1].Simulated = &simulated
+	_, err = dbClient.CreateAlertBulk("test", batch[1:2])
+	assert.NoError(t, err)
+	assert.Equal(t, 7, countDecisions())
+}
+
+func TestCreateAlertBulkDedupDecisions(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	first := newTestAlert("crowdsecurity/ssh-bf", "1.2.3.4")
+	second := newTestAlert("crowdsecurity/ssh-bf", "1.2.3.4")
+	longer := "8h"
+	second.Decisions[0].Duration = &longer
+
+	/*by default, each alert brings its own decision*/
+	_, err := dbClient.CreateAlertBulk("test", []*models.Alert{first})
+	assert.NoError(t, err)
+	_, err = dbClient.CreateAlertBulk("test", []*models.Alert{first})
+	assert.NoError(t, err)
+	nbDecisions, err := dbClient.Ent.Decision.Query().Count(dbClient.CTX)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, nbDecisions)
+	_, err = dbClient.Ent.Decision.Delete().Exec(dbClient.CTX)
+	assert.NoError(t, err)
+
+	dbClient.DedupDecisions = true
+	_, err = dbClient.CreateAlertBulk("test", []*models.Alert{first})
+	assert.NoError(t, err)
+	_, err = dbClient.CreateAlertBulk("test", []*models.Alert{second})
+	assert.NoError(t, err)
+	decisions, err := dbClient.Ent.Decision.Query().All(dbClient.CTX)
+	assert.NoError(t, err)
+	if assert.Len(t, decisions, 1) {
+		stopAt, err := time.Parse(time.RFC3339, *second.StopAt)
+		assert.NoError(t, err)
+		assert.WithinDuration(t, stopAt.Add(8*time.Hour), decisions[0].Until, time.Second)
+	}
+
+	/*a shorter decision doesn't shorten the existing one*/
+	_, err = dbClient.CreateAlertBulk("test", []*models.Alert{first})
+	assert.NoError(t, err)
+	until := decisions[0].Until
+	decisions, err = dbClient.Ent.Decision.Query().All(dbClient.CTX)
+	assert.NoError(t, err)
+	if assert.Len(t, decisions, 1) {
+		assert.True(t, until.Equal(decisions[0].Until))
+	}
+
+	/*another type of decision isn't a duplicate*/
+	captcha := newTestAlert("crowdsecurity/ssh-bf", "1.2.3.4")
+	captchaType := "captcha"
+	captcha.Decisions[0].Type = &captchaType
+	_, err = dbClient.CreateAlertBulk("test", []*models.Alert{captcha})
+	assert.NoError(t, err)
+	nbDecisions, err = dbClient.Ent.Decision.Query().Count(dbClient.CTX)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, nbDecisions)
+}
+
+func TestCreateAlertBulkRollback(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	/*the alerts fail to be created once their events, metas and decisions are*/
+	dbClient.Ent.Alert.Use(func(next ent.Mutator) ent.Mutator {
+		return ent.MutateFunc(func(ctx context.Context, m ent.Mutation) (ent.Value, error) {
+			return nil, errors.New("alert creation failed")
+		})
+	})
+	ids, err := dbClient.CreateAlertBulk("test", newTestAlerts(3))
+	assert.Error(t, err)
+	assert.Empty(t, ids)
+
+	nbEvents, err := dbClient.Ent.Event.Query().Count(dbClient.CTX)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, nbEvents)
+	nbMetas, err := dbClient.Ent.Meta.Query().Count(dbClient.CTX)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, nbMetas)
+	nbDecisions, err := dbClient.Ent.Decision.Query().Count(dbClient.CTX)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, nbDecisions)
+}
+
+func TestDeleteAlertWithFilterErrors(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	_, err := dbClient.CreateAlertBulk("test", newTestAlerts(3))
+	assert.NoError(t, err)
+
+	/*the alert deletions fail after the first one*/
+	nbDeleted := 0
+	dbClient.Ent.Alert.Use(func(next ent.Mutator) ent.Mutator {
+		return ent.MutateFunc(func(ctx context.Context, m ent.Mutation) (ent.Value, error) {
+			if m.Op().Is(ent.OpDeleteOne) {
+				if nbDeleted == 1 {
+					return nil, errors.New("alert deletion failed")
+				}
+				nbDeleted++
+			}
+			return next.Mutate(ctx, m)
+		})
+	})
+	deleted, err := dbClient.DeleteAlertWithFilter(map[string][]string{})
+	assert.Equal(t, DeleteFail, errors.Cause(err))
+	assert.Equal(t, 1, deleted)
+	total, err := dbClient.TotalAlerts()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, total)
+
+	/*a failing query isn't mistaken for nothing to delete*/
+	assert.NoError(t, dbClient.Ent.Close())
+	deleted, err = dbClient.DeleteAlertWithFilter(map[string][]string{})
+	assert.Equal(t, QueryFail, errors.Cause(err))
+	assert.Equal(t, 0, deleted)
+}
+
+func TestDeleteAlertGraphBatch(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	_, err := dbClient.CreateAlertBulk("test", newTestAlerts(deleteBatchSize+10))
+	assert.NoError(t, err)
+	alerts, err := dbClient.Ent.Alert.Query().Order(ent.Asc(alert.FieldID)).All(dbClient.CTX)
+	assert.NoError(t, err)
+
+	/*the graph of the kept alerts is left untouched*/
+	deleted, err := dbClient.DeleteAlertGraphBatch(alerts[:deleteBatchSize+5])
+	assert.NoError(t, err)
+	assert.Equal(t, deleteBatchSize+5, deleted)
+	for _, count := range []func(context.Context) (int, error){
+		dbClient.Ent.Alert.Query().Count,
+		dbClient.Ent.Event.Query().Count,
+		dbClient.Ent.Meta.Query().Count,
+		dbClient.Ent.Decision.Query().Count,
+	} {
+		nb, err := count(dbClient.CTX)
+		assert.NoError(t, err)
+		assert.Equal(t, 5, nb)
+	}
+}
+
+func BenchmarkDeleteAlertGraph(b *testing.B) {
+	deletions := map[string]func(*Client, []*ent.Alert) error{
+		"single": func(dbClient *Client, alerts []*ent.Alert) error {
+			for _, alertItem := range alerts {
+				if err := dbClient.DeleteAlertGraph(alertItem); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		"batch": func(dbClient *Client, alerts []*ent.Alert) error {
+			_, err := dbClient.DeleteAlertGraphBatch(alerts)
+			return err
+		},
+	}
+	for name, deleteAlerts := range deletions {
+		deleteAlerts := deleteAlerts
+		b.Run(name, func(b *testing.B) {
+			dbClient, cleanup := getDBClient(b)
+			defer cleanup()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				if _, err := dbClient.CreateAlertBulk("test", newTestAlerts(1000)); err != nil {
+					b.Fatal(err)
+				}
+				alerts, err := dbClient.Ent.Alert.Query().All(dbClient.CTX)
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+				if err := deleteAlerts(dbClient, alerts); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestFlushAlertsMaxAge(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	ids, err := dbClient.CreateAlertBulk("test", newTestAlerts(10))
+	assert.NoError(t, err)
+	for _, id := range ids[:4] {
+		alertID, err := strconv.Atoi(id)
+		assert.NoError(t, err)
+		_, err = dbClient.Ent.Alert.UpdateOneID(alertID).SetCreatedAt(time.Now().Add(-2 * time.Hour)).Save(dbClient.CTX)
+		assert.NoError(t, err)
+	}
+
+	/*the edges of the alerts are never loaded, only deleted*/
+	edgeQueries := 0
+	dbClient.Ent = ent.NewClient(ent.Driver(dialect.Debug(dbClient.drv, func(args ...interface{}) {
+		query := fmt.Sprint(args...)
+		if !strings.Contains(query, "DELETE") && (strings.Contains(query, "FROM `events`") || strings.Contains(query, "FROM `decisions`")) {
+			edgeQueries++
+		}
+	})))
+	assert.NoError(t, dbClient.FlushAlerts("1h", 0))
+	assert.Equal(t, 0, edgeQueries)
+
+	remaining, err := dbClient.Ent.Alert.Query().Order(ent.Asc(alert.FieldID)).IDs(dbClient.CTX)
+	assert.NoError(t, err)
+	remainingIDs := make([]string, 0, len(remaining))
+	for _, id := range remaining {
+		remainingIDs = append(remainingIDs, strconv.Itoa(id))
+	}
+	assert.Equal(t, ids[4:], remainingIDs)
+	nbEvents, err := dbClient.Ent.Event.Query().Count(dbClient.CTX)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, nbEvents)
+}
+
+func TestFlushArchivedAlerts(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	_, err := dbClient.CreateAlertBulk("test", newTestAlerts(10))
+	assert.NoError(t, err)
+	nbArchived, err := dbClient.ArchiveAlertsWithFilter(map[string][]string{"ip": {"1.2.3.1"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, nbArchived)
+	_, err = dbClient.Ent.Alert.Update().Where(alert.ArchivedEQ(true)).SetArchivedAt(time.Now().Add(-2 * time.Hour)).Save(dbClient.CTX)
+	assert.NoError(t, err)
+
+	/*only the ids of the archived alerts are read*/
+	loadQueries := 0
+	dbClient.Ent = ent.NewClient(ent.Driver(dialect.Debug(dbClient.drv, func(args ...interface{}) {
+		query := fmt.Sprint(args...)
+		if strings.Contains(query, "`alerts`.`scenario`") || (!strings.Contains(query, "DELETE") && (strings.Contains(query, "FROM `events`") || strings.Contains(query, "FROM `decisions`"))) {
+			loadQueries++
+		}
+	})))
+	dbClient.ArchiveGracePeriod = time.Hour
+	assert.NoError(t, dbClient.FlushAlerts("", 0))
+	assert.Equal(t, 0, loadQueries)
+
+	total, err := dbClient.TotalAlerts()
+	assert.NoError(t, err)
+	assert.Equal(t, 9, total)
+	nbDecisions, err := dbClient.Ent.Decision.Query().Where(decision.ValueEQ("1.2.3.1")).Count(dbClient.CTX)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, nbDecisions)
+}
+
+func TestFlushAlertsMaxItems(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	ids, err := dbClient.CreateAlertBulk("test", newTestAlerts(10))
+	assert.NoError(t, err)
+	/*the creation order is the reverse of the ids, so that the oldest alerts are the last ones inserted*/
+	for i, id := range ids {
+		alertID, err := strconv.Atoi(id)
+		assert.NoError(t, err)
+		_, err = dbClient.Ent.Alert.UpdateOneID(alertID).SetCreatedAt(time.Now().Add(-time.Duration(i) * time.Hour)).Save(dbClient.CTX)
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, dbClient.FlushAlerts("", 4))
+	remaining, err := dbClient.Ent.Alert.Query().Order(ent.Asc(alert.FieldID)).All(dbClient.CTX)
+	assert.NoError(t, err)
+	remainingIDs := make([]string, 0, len(remaining))
+	for _, alertItem := range remaining {
+		remainingIDs = append(remainingIDs, strconv.Itoa(alertItem.ID))
+	}
+	assert.Equal(t, ids[:4], remainingIDs)
+	nbDecisions, err := dbClient.Ent.Decision.Query().Count(dbClient.CTX)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, nbDecisions)
+}
+
+func BenchmarkFlushAlertsMaxItems(b *testing.B) {
+	dbClient, cleanup := getDBClient(b)
+	defer cleanup()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		if _, err := dbClient.CreateAlertBulk("test", newTestAlerts(1000)); err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+		if err := dbClient.FlushAlerts("", 100); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestCountAlertsWithFilter(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := newTestAlerts(5)
+	userScope := "Username"
+	for _, alertItem := range alerts[3:] {
+		alertItem.Source.Scope = &userScope
+	}
+	simulated := true
+	alerts[2].Simulated = &simulated
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+
+	count, err := dbClient.CountAlertsWithFilter(map[string][]string{"scope": {"Ip"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	count, err = dbClient.CountAlertsWithFilter(map[string][]string{"scope": {"Ip"}, "simulated": {"false"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	/*the page bounds don't change the count*/
+	count, err = dbClient.CountAlertsWithFilter(map[string][]string{"limit": {"1"}, "offset": {"1"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, count)
+
+	_, err = dbClient.CountAlertsWithFilter(map[string][]string{"unknown": {"1"}})
+	assert.True(t, errors.Is(err, InvalidFilter))
+}
+
+func TestAlertOriginFilters(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := newTestAlerts(4)
+	for i, origin := range []string{"crowdsec", "cscli", "CAPI", "lists:firehol"} {
+		origin := origin
+		alerts[i].Decisions[0].Origin = &origin
+	}
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+
+	sourceValues := func(filter map[string][]string) []string {
+		alerts, err := dbClient.QueryAlertWithFilter(filter)
+		assert.NoError(t, err)
+		values := make([]string, 0, len(alerts))
+		for _, alertItem := range alerts {
+			values = append(values, alertItem.SourceValue)
+		}
+		return values
+	}
+
+	assert.ElementsMatch(t, []string{"1.2.3.0", "1.2.3.2"}, sourceValues(map[string][]string{"origin": {"crowdsec, CAPI"}}))
+	assert.ElementsMatch(t, []string{"1.2.3.1", "1.2.3.3"}, sourceValues(map[string][]string{"origin_not": {"crowdsec,CAPI"}}))
+	assert.ElementsMatch(t, []string{"1.2.3.0", "1.2.3.1", "1.2.3.3"}, sourceValues(map[string][]string{"include_capi": {"false"}}))
+	assert.Len(t, sourceValues(map[string][]string{"include_capi": {"true"}}), 4)
+
+	/*a typo must not silently return the CAPI alerts*/
+	_, err = dbClient.QueryAlertWithFilter(map[string][]string{"include_capi": {"flase"}})
+	assert.Equal(t, InvalidFilter, errors.Cause(err))
+}
+
+func TestAlertDecisionScopeFilter(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := newTestAlerts(3)
+	alerts[1].Decisions[0] = newTestDecision("FR", "Country", "ban", "4h")
+	alerts[2].Decisions[0] = newTestDecision("1.2.4.0/24", types.Range, "ban", "4h")
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+
+	countries, err := dbClient.QueryAlertWithFilter(map[string][]string{"decision_scope": {"Country"}})
+	assert.NoError(t, err)
+	if assert.Len(t, countries, 1) {
+		assert.Equal(t, "1.2.3.1", countries[0].SourceValue)
+	}
+
+	/*the scopes are normalized like the alert scope ones*/
+	ipOrRange, err := dbClient.QueryAlertWithFilter(map[string][]string{"decision_scope": {"ip,range"}})
+	assert.NoError(t, err)
+	assert.Len(t, ipOrRange, 2)
+
+	_, err = dbClient.QueryAlertWithFilter(map[string][]string{"decision_scope": {""}})
+	assert.True(t, errors.Is(err, InvalidFilter))
+}
+
+func TestAlertSinceUntilDaysWeeks(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := newTestAlerts(2)
+	old := time.Now().UTC().Add(-10 * 24 * time.Hour).Format(time.RFC3339)
+	alerts[1].StartAt = &old
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+
+	recent, err := dbClient.QueryAlertWithFilter(map[string][]string{"since": {"1w"}})
+	assert.NoError(t, err)
+	if assert.Len(t, recent, 1) {
+		assert.Equal(t, "1.2.3.0", recent[0].SourceValue)
+	}
+	older, err := dbClient.QueryAlertWithFilter(map[string][]string{"until": {"1w2d"}})
+	assert.NoError(t, err)
+	if assert.Len(t, older, 1) {
+		assert.Equal(t, "1.2.3.1", older[0].SourceValue)
+	}
+
+	for _, filter := range []map[string][]string{
+		{"since": {"0s"}},
+		{"until": {"-2d"}},
+	} {
+		_, err = dbClient.QueryAlertWithFilter(filter)
+		assert.True(t, errors.Is(err, InvalidFilter), "%v: %v", filter, err)
+	}
+}
+
+func TestAlertCreatedBeforeAfter(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	now := time.Now().UTC()
+	createdAt := []time.Time{
+		time.Date(2023, time.January, 5, 0, 0, 0, 0, time.UTC),
+		time.Date(2023, time.January, 31, 12, 0, 0, 0, time.UTC),
+		time.Date(2023, time.February, 10, 0, 0, 0, 0, time.UTC),
+		now.Add(-2 * time.Hour),
+		now.Add(-30 * time.Minute),
+	}
+	alerts := newTestAlerts(len(createdAt))
+	for i := range alerts {
+		startAt := createdAt[i].Format(time.RFC3339)
+		alerts[i].StartAt = &startAt
+	}
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+	for i, date := range createdAt {
+		err := dbClient.Ent.Alert.Update().Where(alert.SourceValueEQ(*alerts[i].Source.Value)).SetCreatedAt(date).Exec(dbClient.CTX)
+		assert.NoError(t, err)
+	}
+	sourceValues := func(filter map[string][]string) []string {
+		alerts, err := dbClient.QueryAlertWithFilter(filter)
+		assert.NoError(t, err)
+		values := make([]string, 0, len(alerts))
+		for _, alertItem := range alerts {
+			values = append(values, alertItem.SourceValue)
+		}
+		return values
+	}
+
+	assert.ElementsMatch(t, []string{"1.2.3.0", "1.2.3.1"}, sourceValues(map[string][]string{
+		"created_after":  {"2023-01-01T00:00:00Z"},
+		"created_before": {"2023-01-31T23:59:59Z"},
+	}))
+	/*the tightest bound wins*/
+	assert.ElementsMatch(t, []string{"1.2.3.4"}, sourceValues(map[string][]string{
+		"created_after": {now.Add(-3 * time.Hour).Format(time.RFC3339)},
+		"since":         {"1h"},
+	}))
+	assert.ElementsMatch(t, []string{"1.2.3.3", "1.2.3.4"}, sourceValues(map[string][]string{
+		"created_after": {now.Add(-3 * time.Hour).Format(time.RFC3339)},
+		"since":         {"1w"},
+	}))
+	/*a duration is still accepted, as FlushAlerts does, with the same units as since*/
+	assert.ElementsMatch(t, []string{"1.2.3.0", "1.2.3.1", "1.2.3.2"}, sourceValues(map[string][]string{"created_before": {"7d"}}))
+	assert.ElementsMatch(t, []string{"1.2.3.0", "1.2.3.1", "1.2.3.2"}, sourceValues(map[string][]string{"created_before": {"1w"}}))
+
+	for _, filter := range []map[string][]string{
+		{"created_after": {"2023-13-01"}},
+		{"created_before": {"yesterday"}},
+	} {
+		_, err = dbClient.QueryAlertWithFilter(filter)
+		assert.Equal(t, ParseTimeFail, errors.Cause(err), "%v", filter)
+	}
+}
+
+func TestCreateAlertBulkSize(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	/*count the alerts insert statements, one per batch*/
+	nbBatches := 0
+	dbClient.Ent = ent.NewClient(ent.Driver(dialect.Debug(dbClient.drv, func(args ...interface{}) {
+		if strings.Contains(fmt.Sprint(args...), "INSERT INTO `alerts`") {
+			nbBatches++
+		}
+	})))
+
+	dbClient.AlertBulkSize = 5
+	ids, err := dbClient.CreateAlertBulk("test", newTestAlerts(12))
+	assert.NoError(t, err)
+	assert.Len(t, ids, 12)
+	assert.Equal(t, 3, nbBatches)
+	total, err := dbClient.TotalAlerts()
+	assert.NoError(t, err)
+	assert.Equal(t, 12, total)
+
+	/*an invalid size falls back to the default one*/
+	dbClient.AlertBulkSize = -1
+	nbBatches = 0
+	ids, err = dbClient.CreateAlertBulk("test", newTestAlerts(defaultAlertBulkSize+1))
+	assert.NoError(t, err)
+	assert.Len(t, ids, defaultAlertBulkSize+1)
+	assert.Equal(t, 2, nbBatches)
+	total, err = dbClient.TotalAlerts()
+	assert.NoError(t, err)
+	assert.Equal(t, 12+defaultAlertBulkSize+1, total)
+}
+
+func TestCreateAlertBulkMissingFields(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := newTestAlerts(3)
+	alerts[1].Source = nil
+	alerts[1].Simulated = nil
+	alerts[1].Decisions[0].Origin = nil
+	var ids []string
+	var err error
+	assert.NotPanics(t, func() {
+		ids, err = dbClient.CreateAlertBulk("test", alerts)
+	})
+	assert.Equal(t, InvalidAlert, errors.Cause(err))
+	assert.Contains(t, err.Error(), "missing mandatory fields: decisions[0].origin, simulated, source")
+	assert.Empty(t, ids)
+
+	/*the alerts of the failed batch aren't inserted*/
+	total, err := dbClient.TotalAlerts()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, total)
+
+	_, err = dbClient.CreateAlertBulk("test", []*models.Alert{nil})
+	assert.Equal(t, InvalidAlert, errors.Cause(err))
+}
+
+func TestCreateAlertBulkPartialFailure(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	/*an invalid alert in the second batch (20 alerts per batch) aborts the call once the first one is committed*/
+	alerts := newTestAlerts(30)
+	alerts[25].Scenario = nil
+	ids, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.Equal(t, InvalidAlert, errors.Cause(err))
+	assert.Len(t, ids, 20)
+
+	stored, err := dbClient.Ent.Alert.Query().IDs(dbClient.CTX)
+	assert.NoError(t, err)
+	storedIDs := make([]string, 0, len(stored))
+	for _, id := range stored {
+		storedIDs = append(storedIDs, strconv.Itoa(id))
+	}
+	assert.ElementsMatch(t, storedIDs, ids)
+
+	/*the caller can resume after the alerts that were inserted*/
+	alerts[25] = newTestAlert("crowdsecurity/test", "1.2.3.25")
+	ids, err = dbClient.CreateAlertBulk("test", alerts[len(ids):])
+	assert.NoError(t, err)
+	assert.Len(t, ids, 10)
+	total, err := dbClient.TotalAlerts()
+	assert.NoError(t, err)
+	assert.Equal(t, 30, total)
+}
+
+func TestCountAlertsToFlush(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	_, err := dbClient.CreateAlertBulk("test", newTestAlerts(10))
+	assert.NoError(t, err)
+	/*the 4 first alerts are 2 hours old*/
+	_, err = dbClient.Ent.Alert.Update().
+		Where(alert.IDLTE(4)).
+		SetCreatedAt(time.Now().Add(-2 * time.Hour)).
+		Save(dbClient.CTX)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		maxAge   time.Duration
+		maxItems int
+		expected int
+	}{
+		{"nothing", 0, 0, 0},
+		{"age only", time.Hour, 0, 4},
+		{"count only", 0, 7, 3},
+		{"count below the max", 0, 20, 0},
+		{"old alerts enough for the max", time.Hour, 7, 4},
+		{"more than the old alerts for the max", time.Hour, 3, 7},
+	}
+	for _, test := range tests {
+		nb, err := dbClient.CountAlertsToFlush(test.maxAge, test.maxItems)
+		assert.NoError(t, err, test.name)
+		assert.Equal(t, test.expected, nb, test.name)
+	}
+
+	/*the flush deletes exactly the counted alerts*/
+	assert.NoError(t, dbClient.FlushAlerts("1h", 3))
+	total, err := dbClient.TotalAlerts()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, total)
+}
+
+func TestCreateAlertBulkStoresUTC(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := newTestAlerts(1)
+	startAt := time.Now().Add(-time.Hour).In(time.FixedZone("", 5*3600)).Format(time.RFC3339)
+	alerts[0].StartAt = &startAt
+	alerts[0].StopAt = &startAt
+	alerts[0].Events[0].Timestamp = &startAt
+	alerts[0].Decisions[0].Until = time.Now().Add(time.Hour).In(time.FixedZone("", 5*3600)).Format(time.RFC3339)
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+
+	expected, err := time.Parse(time.RFC3339, startAt)
+	assert.NoError(t, err)
+	stored, err := dbClient.Ent.Alert.Query().WithEvents().WithDecisions().Only(dbClient.CTX)
+	assert.NoError(t, err)
+	for name, storedTime := range map[string]time.Time{
+		"started_at": stored.StartedAt,
+		"stopped_at": stored.StoppedAt,
+		"event time": stored.Edges.Events[0].Time,
+		"until":      stored.Edges.Decisions[0].Until,
+	} {
+		_, offset := storedTime.Zone()
+		assert.Equal(t, 0, offset, name)
+	}
+	assert.True(t, expected.Equal(stored.StartedAt), "%s != %s", expected, stored.StartedAt)
+	assert.True(t, expected.Equal(stored.Edges.Events[0].Time))
+}
+
+func TestAlertMachineIDFilter(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	password := strfmt.Password("password")
+	for _, machineID := range []string{"machine1", "machine2"} {
+		machineID := machineID
+		_, err := dbClient.CreateMachine(&machineID, &password, "127.0.0.1", true, false)
+		assert.NoError(t, err)
+	}
+	alerts := newTestAlerts(4)
+	for i, machineID := range []string{"machine1", "machine1", "machine2", "unknown"} {
+		_, err := dbClient.CreateAlertBulk(machineID, alerts[i:i+1])
+		assert.NoError(t, err)
+	}
+
+	machineAlerts, err := dbClient.QueryAlertWithFilter(map[string][]string{"machine_id": {"machine1"}})
+	assert.NoError(t, err)
+	assert.Len(t, machineAlerts, 2)
+	for _, alertItem := range machineAlerts {
+		assert.Equal(t, "machine1", alertItem.Edges.Owner.MachineId)
+	}
+
+	orphans, err := dbClient.QueryAlertWithFilter(map[string][]string{"machine_id": {"none"}})
+	assert.NoError(t, err)
+	if assert.Len(t, orphans, 1) {
+		assert.Equal(t, "1.2.3.3", orphans[0].SourceValue)
+	}
+}
+
+func TestGetAlertByID(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	ids, err := dbClient.CreateAlertBulk("test", newTestAlerts(2))
+	assert.NoError(t, err)
+	id, err := strconv.Atoi(ids[1])
+	assert.NoError(t, err)
+
+	alertItem, err := dbClient.GetAlertByID(id)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.3.1", alertItem.SourceValue)
+	assert.Len(t, alertItem.Edges.Decisions, 1)
+	assert.Len(t, alertItem.Edges.Events, 1)
+	assert.Len(t, alertItem.Edges.Metas, 1)
+
+	_, err = dbClient.GetAlertByID(id + 1)
+	assert.Equal(t, ItemNotFound, errors.Cause(err))
+
+	assert.NoError(t, dbClient.Ent.Close())
+	_, err = dbClient.GetAlertByID(id)
+	assert.Equal(t, QueryFail, errors.Cause(err))
+}
+
+func TestAlertMetaFilter(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := newTestAlerts(3)
+	for i, path := range []string{"/admin/login", "/index.html", "/wp-admin"} {
+		alerts[i].Meta = append(alerts[i].Meta, &models.MetaItems0{Key: "http_path", Value: path})
+	}
+	alerts[2].Meta = append(alerts[2].Meta, &models.MetaItems0{Key: "user_agent", Value: "curl/7.68"})
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+
+	exact, err := dbClient.QueryAlertWithFilter(map[string][]string{"meta": {"source_ip:1.2.3.1"}})
+	assert.NoError(t, err)
+	if assert.Len(t, exact, 1) {
+		assert.Equal(t, "1.2.3.1", exact[0].SourceValue)
+	}
+
+	admin, err := dbClient.QueryAlertWithFilter(map[string][]string{"meta": {"http_path:admin"}})
+	assert.NoError(t, err)
+	assert.Len(t, admin, 2)
+
+	/*the value is only looked for in the metas with the key*/
+	admin, err = dbClient.QueryAlertWithFilter(map[string][]string{"meta": {"user_agent:admin"}})
+	assert.NoError(t, err)
+	assert.Len(t, admin, 0)
+
+	admin, err = dbClient.QueryAlertWithFilter(map[string][]string{"meta": {"http_path:admin", "user_agent:curl"}})
+	assert.NoError(t, err)
+	if assert.Len(t, admin, 1) {
+		assert.Equal(t, "1.2.3.2", admin[0].SourceValue)
+	}
+
+	_, err = dbClient.QueryAlertWithFilter(map[string][]string{"meta": {"http_path"}})
+	assert.True(t, errors.Is(err, InvalidFilter))
+}
+
+func TestQueryAlertWithFilterCtxDeadline(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	_, err := dbClient.CreateAlertBulk("test", newTestAlerts(3))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	_, err = dbClient.QueryAlertWithFilterCtx(ctx, map[string][]string{})
+	assert.Equal(t, context.DeadlineExceeded, errors.Cause(err))
+
+	alerts, err := dbClient.QueryAlertWithFilterCtx(context.Background(), map[string][]string{})
+	assert.NoError(t, err)
+	assert.Len(t, alerts, 3)
+}
+
+func TestRetryLocked(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	/*the first attempt of each write finds the database locked*/
+	lockedOnce := func(op ent.Op) ent.Hook {
+		attempts := 0
+		return func(next ent.Mutator) ent.Mutator {
+			return ent.MutateFunc(func(ctx context.Context, m ent.Mutation) (ent.Value, error) {
+				if m.Op().Is(op) {
+					if attempts++; attempts == 1 {
+						return nil, errors.New("database is locked")
+					}
+				}
+				return next.Mutate(ctx, m)
+			})
+		}
+	}
+	dbClient.Ent.Alert.Use(lockedOnce(ent.OpCreate))
+	dbClient.Ent.Decision.Use(lockedOnce(ent.OpDelete))
+
+	ids, err := dbClient.CreateAlertBulk("test", newTestAlerts(3))
+	assert.NoError(t, err)
+	assert.Len(t, ids, 3)
+	alerts, err := dbClient.Ent.Alert.Query().All(dbClient.CTX)
+	assert.NoError(t, err)
+	assert.Len(t, alerts, 3)
+
+	assert.NoError(t, dbClient.DeleteAlertGraph(alerts[0]))
+	nbDecisions, err := dbClient.Ent.Decision.Query().Count(dbClient.CTX)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, nbDecisions)
+
+	/*a database locked for longer than the attempts fails the write*/
+	dbClient.LockedMaxAttempts = 1
+	dbClient.Ent.Meta.Use(lockedOnce(ent.OpDelete))
+	err = dbClient.DeleteAlertGraph(alerts[1])
+	assert.Equal(t, DeleteFail, errors.Cause(err))
+}
+
+func TestAlertScenarioVersionAndLikeFilters(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := []*models.Alert{
+		newTestAlert("crowdsecurity/http-probing", "1.2.3.0"),
+		newTestAlert("crowdsecurity/http-bad-user-agent", "1.2.3.1"),
+		newTestAlert("crowdsecurity/ssh-bf", "1.2.3.2"),
+	}
+	newVersion := "0.2"
+	alerts[1].ScenarioVersion = &newVersion
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+
+	oldVersion, err := dbClient.QueryAlertWithFilter(map[string][]string{"scenario_version": {"0.1"}})
+	assert.NoError(t, err)
+	assert.Len(t, oldVersion, 2)
+
+	http, err := dbClient.QueryAlertWithFilter(map[string][]string{"scenario_like": {"/http-"}})
+	assert.NoError(t, err)
+	assert.Len(t, http, 2)
+
+	http, err = dbClient.QueryAlertWithFilter(map[string][]string{"scenario_like": {"/http-"}, "scenario_version": {"0.2"}})
+	assert.NoError(t, err)
+	if assert.Len(t, http, 1) {
+		assert.Equal(t, "1.2.3.1", http[0].SourceValue)
+	}
+
+	for _, filter := range []map[string][]string{
+		{"scenario_version": {""}},
+		{"scenario_like": {""}},
+	} {
+		_, err = dbClient.QueryAlertWithFilter(filter)
+		assert.True(t, errors.Is(err, InvalidFilter), "%v: %v", filter, err)
+	}
+}
+
+func TestAlertsCountByScenario(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := []*models.Alert{
+		newTestAlert("crowdsecurity/ssh-bf", "1.2.3.0"),
+		newTestAlert("crowdsecurity/ssh-bf", "1.2.3.1"),
+		newTestAlert("crowdsecurity/http-probing", "1.2.3.2"),
+		newTestAlert("crowdsecurity/http-probing", "1.2.3.3"),
+		newTestAlert("crowdsecurity/http-probing", "1.2.3.4"),
+		newTestAlert("crowdsecurity/smb-bf", "1.2.3.5"),
+	}
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+	/*the smb alert is out of the window*/
+	_, err = dbClient.Ent.Alert.Update().
+		Where(alert.ScenarioEQ("crowdsecurity/smb-bf")).
+		SetCreatedAt(time.Now().Add(-2 * time.Hour)).
+		Save(dbClient.CTX)
+	assert.NoError(t, err)
+
+	counts, err := dbClient.AlertsCountByScenario(time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"crowdsecurity/ssh-bf": 2, "crowdsecurity/http-probing": 3}, counts)
+
+	counts, err = dbClient.AlertsCountByScenario(time.Now().Add(-3 * time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, counts["crowdsecurity/smb-bf"])
+}
+
+func TestTopSourceValues(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := []*models.Alert{}
+	for ip, nbAlerts := range map[string]int{"1.2.3.1": 1, "1.2.3.2": 4, "1.2.3.3": 2, "1.2.3.4": 2} {
+		for i := 0; i < nbAlerts; i++ {
+			alerts = append(alerts, newTestAlert("crowdsecurity/test", ip))
+		}
+	}
+	username := newTestAlert("crowdsecurity/ssh-bf", "root")
+	usernameScope := "username"
+	username.Source.Scope = &usernameScope
+	alerts = append(alerts, username, username)
+	old := newTestAlert("crowdsecurity/test", "1.2.3.9")
+	_, err := dbClient.CreateAlertBulk("test", append(alerts, old, old, old, old, old))
+	assert.NoError(t, err)
+	/*the alerts on 1.2.3.9 are out of the window*/
+	_, err = dbClient.Ent.Alert.Update().
+		Where(alert.SourceValueEQ("1.2.3.9")).
+		SetCreatedAt(time.Now().Add(-2 * time.Hour)).
+		Save(dbClient.CTX)
+	assert.NoError(t, err)
+
+	/*the groups are sorted and limited by the database, not after loading all of them*/
+	queries := []string{}
+	dbClient.Ent = ent.NewClient(ent.Driver(dialect.Debug(dbClient.drv, func(args ...interface{}) {
+		queries = append(queries, fmt.Sprint(args...))
+	})))
+	top, err := dbClient.TopSourceValues(time.Now().Add(-time.Hour), 3, "")
+	assert.NoError(t, err)
+	if assert.Len(t, queries, 1) {
+		assert.Contains(t, queries[0], "ORDER BY `count` DESC")
+		assert.Contains(t, queries[0], "LIMIT 3")
+	}
+	assert.Equal(t, []SourceValueCount{
+		{Value: "1.2.3.2", Count: 4},
+		{Value: "1.2.3.3", Count: 2},
+		{Value: "1.2.3.4", Count: 2},
+	}, top)
+
+	top, err = dbClient.TopSourceValues(time.Now().Add(-time.Hour), 0, "ip")
+	assert.NoError(t, err)
+	assert.Len(t, top, 4)
+	top, err = dbClient.TopSourceValues(time.Now().Add(-time.Hour), 0, "username")
+	assert.NoError(t, err)
+	assert.Equal(t, []SourceValueCount{{Value: "root", Count: 2}}, top)
+
+	top, err = dbClient.TopSourceValues(time.Now().Add(-3*time.Hour), 1, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []SourceValueCount{{Value: "1.2.3.9", Count: 5}}, top)
+}
+
+func TestSumEventsCount(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	sum, err := dbClient.SumEventsCount(time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, sum)
+
+	alerts := newTestAlerts(4)
+	for i, count := range []int32{3, 10, 25, 1000} {
+		count := count
+		alerts[i].EventsCount = &count
+	}
+	simulated := true
+	alerts[1].Simulated = &simulated
+	_, err = dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+	/*the last alert is out of the window*/
+	_, err = dbClient.Ent.Alert.Update().
+		Where(alert.EventsCountEQ(1000)).
+		SetCreatedAt(time.Now().Add(-2 * time.Hour)).
+		Save(dbClient.CTX)
+	assert.NoError(t, err)
+
+	sum, err = dbClient.SumEventsCount(time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 38, sum)
+	sum, err = dbClient.SumEventsCount(time.Now().Add(-3 * time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 1038, sum)
+}
+
+func TestAlertsCountByDecisionOrigin(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	withOrigin := func(alertItem *models.Alert, origins ...string) *models.Alert {
+		alertItem.Decisions = nil
+		for _, origin := range origins {
+			origin := origin
+			decisionItem := newTestDecision(*alertItem.Source.Value, types.Ip, "ban", "4h")
+			decisionItem.Origin = &origin
+			alertItem.Decisions = append(alertItem.Decisions, decisionItem)
+		}
+		return alertItem
+	}
+	alerts := []*models.Alert{
+		withOrigin(newTestAlert("crowdsecurity/ssh-bf", "1.2.3.0"), "crowdsec"),
+		withOrigin(newTestAlert("crowdsecurity/ssh-bf", "1.2.3.1"), "crowdsec", "crowdsec"),
+		withOrigin(newTestAlert("community-blocklist", "1.2.3.2"), "CAPI", "CAPI", "CAPI"),
+		withOrigin(newTestAlert("crowdsecurity/http-probing", "1.2.3.3"), "crowdsec", "cscli"),
+		withOrigin(newTestAlert("crowdsecurity/smb-bf", "1.2.3.4"), "crowdsec"),
+	}
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+	/*the smb alert is out of the window*/
+	_, err = dbClient.Ent.Alert.Update().
+		Where(alert.ScenarioEQ("crowdsecurity/smb-bf")).
+		SetCreatedAt(time.Now().Add(-2 * time.Hour)).
+		Save(dbClient.CTX)
+	assert.NoError(t, err)
+
+	/*an alert counts once per origin of its decisions*/
+	counts, err := dbClient.AlertsCountByDecisionOrigin(time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"crowdsec": 3, "CAPI": 1, "cscli": 1}, counts)
+
+	counts, err = dbClient.AlertsCountByDecisionOrigin(time.Now().Add(-3 * time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 4, counts["crowdsec"])
+}
+
+func TestAlertRepeatedFilterValues(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := []*models.Alert{
+		newTestAlert("crowdsecurity/ssh-bf", "1.2.3.0"),
+		newTestAlert("crowdsecurity/http-probing", "1.2.3.1"),
+		newTestAlert("crowdsecurity/smb-bf", "1.2.3.2"),
+	}
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+
+	single, err := dbClient.QueryAlertWithFilter(map[string][]string{"scenario": {"crowdsecurity/ssh-bf"}})
+	assert.NoError(t, err)
+	if assert.Len(t, single, 1) {
+		assert.Equal(t, "1.2.3.0", single[0].SourceValue)
+	}
+	/*not a prefix match*/
+	single, err = dbClient.QueryAlertWithFilter(map[string][]string{"scenario": {"crowdsecurity/ssh"}})
+	assert.NoError(t, err)
+	assert.Len(t, single, 0)
+
+	either, err := dbClient.QueryAlertWithFilter(map[string][]string{"scenario": {"crowdsecurity/ssh-bf", "crowdsecurity/smb-bf"}})
+	assert.NoError(t, err)
+	assert.Len(t, either, 2)
+
+	either, err = dbClient.QueryAlertWithFilter(map[string][]string{"value": {"1.2.3.1", "1.2.3.2"}, "scope": {"Range", "ip"}})
+	assert.NoError(t, err)
+	assert.Len(t, either, 2)
+}
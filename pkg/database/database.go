@@ -2,12 +2,20 @@ package database
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/alert"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/decision"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/migrate"
 	"github.com/crowdsecurity/crowdsec/pkg/types"
+	"github.com/facebook/ent/dialect"
+	entsql "github.com/facebook/ent/dialect/sql"
 	"github.com/go-co-op/gocron"
 	_ "github.com/go-sql-driver/mysql"
 	_ "github.com/lib/pq"
@@ -16,37 +24,92 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+const (
+	defaultLockedMaxAttempts = 5                     // attempts of a write on a locked database, unless Client.LockedMaxAttempts is set
+	lockedRetryDelay         = 50 * time.Millisecond // wait before the second attempt, doubled after each one
+	maxLockedRetryDelay      = time.Second           // longest wait between two attempts
+	defaultMachineCacheTTL   = 30 * time.Second      // unless Client.MachineCacheTTL is set
+	defaultMachineCacheSize  = 100                   // unless Client.MachineCacheSize is set
+	healthCheckTimeout       = 2 * time.Second       // longest Ping and SchemaVersion can take
+)
+
 type Client struct {
 	Ent *ent.Client
 	CTX context.Context
 	Log *log.Logger
+	/*Logger is used by all the operations of the client, NewClient defaults it to Log, configured with the log level of the database config*/
+	Logger *log.Entry
+	/*MaxClockSkew is how far in the future an alert timestamp can be before being considered bogus, 0 disables the check*/
+	MaxClockSkew time.Duration
+	/*ClampClockSkew makes CreateAlertBulk replace bogus future timestamps with now() instead of rejecting the alert*/
+	ClampClockSkew bool
+	/*SkipOwnerLookup makes CreateAlertBulk store alerts without owner, without looking up the machine (trusted ingestion of ownerless alerts)*/
+	SkipOwnerLookup bool
+	/*MinDecisionDuration is the shortest decision duration CreateAlertBulk accepts, durations that are zero or negative are always refused*/
+	MinDecisionDuration time.Duration
+	/*ClampDecisionDuration makes CreateAlertBulk raise too short decision durations to MinDecisionDuration instead of rejecting the alert*/
+	ClampDecisionDuration bool
+	/*MaintenanceThreshold is the number of alerts FlushAlerts must delete to run PostFlushMaintenance afterwards, 0 disables it*/
+	MaintenanceThreshold int
+	/*DedupDecisions makes CreateAlertBulk extend the expiration of an active decision with the same value, scope, type and origin instead of creating a new one,
+	so that a retried batch doesn't duplicate its decisions. The scenario isn't part of the key : the CAPI decisions on a same ip for different scenarios are merged too*/
+	DedupDecisions bool
+	/*UpsertDecisions makes CreateAlertBulk set the expiration of an active decision with the same value, scope, type, origin and simulated flag, brought by an alert
+	of the same machine, instead of creating a new one, so that a retried batch doesn't duplicate its decisions while other machines keep their own.
+	The ownerless alerts (ie. the CAPI ones) share the same key : their decisions on a same value are merged whatever the scenario. DedupDecisions takes precedence*/
+	UpsertDecisions bool
+	/*SwapReversedIPBounds makes CreateAlertBulk swap the start and end ips of a decision when they are reversed, instead of rejecting the alert*/
+	SwapReversedIPBounds bool
+	/*RejectUnknownDecisionTypes makes CreateAlertBulk refuse the alerts with a decision type that isn't in KnownDecisionTypes, instead of storing it with a warning*/
+	RejectUnknownDecisionTypes bool
+	/*ArchiveGracePeriod is how long FlushAlerts keeps the archived alerts before deleting them, 0 keeps them until the age or number of alerts limits are reached*/
+	ArchiveGracePeriod time.Duration
+	/*AlertBulkSize is the number of alerts CreateAlertBulk inserts per transaction, defaultAlertBulkSize if not positive*/
+	AlertBulkSize int
+	/*LockedMaxAttempts is how many times CreateAlertBulk and DeleteAlertGraph try a write while the SQLite database is locked by another one, defaultLockedMaxAttempts if not positive*/
+	LockedMaxAttempts int
+	/*MachineCacheTTL is how long CreateAlertBulk reuses a machine it looked up, defaultMachineCacheTTL if 0, a negative value disables the cache*/
+	MachineCacheTTL time.Duration
+	/*MachineCacheSize is the number of machines kept in the cache, defaultMachineCacheSize if not positive*/
+	MachineCacheSize int
+	/*the raw driver, for the backend specific statements ent doesn't provide*/
+	drv      *entsql.Driver
+	machines *machineCache
+	/*afterAlertsPageCount is called between the count and the page queries of QueryAlertsPage if set, for tests*/
+	afterAlertsPageCount func()
 }
 
 func NewClient(config *csconfig.DatabaseCfg) (*Client, error) {
-	var client *ent.Client
+	return NewClientWithLogger(config, nil)
+}
+
+// NewClientWithLogger is NewClient with the logger to use instead of the global one (ie. with a component field, or its own level)
+func NewClientWithLogger(config *csconfig.DatabaseCfg, logger *log.Entry) (*Client, error) {
+	var drv *entsql.Driver
 	var err error
 	if config == nil {
 		return &Client{}, fmt.Errorf("DB config is empty")
 	}
 	switch config.Type {
 	case "sqlite":
-		client, err = ent.Open("sqlite3", fmt.Sprintf("file:%s?_busy_timeout=100000&_fk=1", config.DbPath))
+		drv, err = entsql.Open(dialect.SQLite, fmt.Sprintf("file:%s?_busy_timeout=100000&_fk=1", config.DbPath))
 		if err != nil {
 			return &Client{}, fmt.Errorf("failed opening connection to sqlite: %v", err)
 		}
 	case "mysql":
-		client, err = ent.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=True", config.User, config.Password, config.Host, config.Port, config.DbName))
+		drv, err = entsql.Open(dialect.MySQL, fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=True", config.User, config.Password, config.Host, config.Port, config.DbName))
 		if err != nil {
 			return &Client{}, fmt.Errorf("failed opening connection to mysql: %v", err)
 		}
 	case "postgres", "postgresql":
-		client, err = ent.Open("postgres", fmt.Sprintf("host=%s port=%d user=%s dbname=%s password=%s", config.Host, config.Port, config.User, config.DbName, config.Password))
+		drv, err = entsql.Open(dialect.Postgres, fmt.Sprintf("host=%s port=%d user=%s dbname=%s password=%s", config.Host, config.Port, config.User, config.DbName, config.Password))
 		if err != nil {
 			return &Client{}, fmt.Errorf("failed opening connection to postgres: %v", err)
 		}
 	default:
 		return &Client{}, fmt.Errorf("unknown database type")
 	}
+	client := ent.NewClient(ent.Driver(drv))
 
 	/*The logger that will be used by db operations*/
 	clog := log.New()
@@ -56,14 +119,27 @@ func NewClient(config *csconfig.DatabaseCfg) (*Client, error) {
 	if config.LogLevel != nil {
 		clog.SetLevel(*config.LogLevel)
 		if *config.LogLevel >= log.TraceLevel {
-			log.Debugf("Enabling request debug")
+			clog.Debugf("Enabling request debug")
 			client = client.Debug()
 		}
 	}
 	if err = client.Schema.Create(context.Background()); err != nil {
 		return nil, fmt.Errorf("failed creating schema resources: %v", err)
 	}
-	return &Client{Ent: client, CTX: context.Background(), Log: clog}, nil
+	if logger == nil {
+		logger = log.NewEntry(clog)
+	}
+	return &Client{Ent: client, CTX: context.Background(), Log: clog, Logger: logger, drv: drv, machines: newMachineCache()}, nil
+}
+
+func (c *Client) logger() *log.Entry {
+	if c.Logger != nil {
+		return c.Logger
+	}
+	if c.Log != nil {
+		return log.NewEntry(c.Log)
+	}
+	return log.NewEntry(log.StandardLogger())
 }
 
 func (c *Client) StartFlushScheduler(config *csconfig.FlushDBCfg) (*gocron.Scheduler, error) {
@@ -86,3 +162,76 @@ func (c *Client) StartFlushScheduler(config *csconfig.FlushDBCfg) (*gocron.Sched
 
 	return scheduler, nil
 }
+
+// PostFlushMaintenance rebuilds the indexes of the alerts and decisions tables and refreshes their statistics,
+// to keep the query plans efficient after a large deletion
+func (c *Client) PostFlushMaintenance() error {
+	var statements []string
+
+	if c.drv == nil {
+		return fmt.Errorf("no database driver available for maintenance")
+	}
+	switch c.drv.Dialect() {
+	case dialect.SQLite:
+		statements = []string{"REINDEX", "ANALYZE"}
+	case dialect.Postgres:
+		statements = []string{
+			fmt.Sprintf("REINDEX TABLE %s", alert.Table),
+			fmt.Sprintf("REINDEX TABLE %s", decision.Table),
+			fmt.Sprintf("ANALYZE %s", alert.Table),
+			fmt.Sprintf("ANALYZE %s", decision.Table),
+		}
+	case dialect.MySQL:
+		/*OPTIMIZE would rebuild the whole tables, analyzing them is enough to refresh the index statistics*/
+		statements = []string{fmt.Sprintf("ANALYZE TABLE %s, %s", alert.Table, decision.Table)}
+	default:
+		return fmt.Errorf("no maintenance available for '%s'", c.drv.Dialect())
+	}
+	for _, statement := range statements {
+		c.logger().Debugf("running maintenance: %s", statement)
+		if _, err := c.drv.ExecContext(c.CTX, statement); err != nil {
+			c.logger().Warningf("PostFlushMaintenance : %s", err)
+			return errors.Wrapf(UpdateFail, "'%s': %s", statement, err)
+		}
+	}
+	return nil
+}
+
+// Ping checks that the database is reachable with a trivial read, for the readiness probe of the LAPI.
+// It returns a ConnectionError if the connection is down
+func (c *Client) Ping() error {
+	ctx, cancel := context.WithTimeout(c.CTX, healthCheckTimeout)
+	defer cancel()
+	if _, err := c.Ent.Alert.Query().Limit(1).IDs(ctx); err != nil {
+		c.logger().Warningf("Ping : %s", err)
+		return wrapDBError(err, QueryFail, "reading alerts: %s", err)
+	}
+	return nil
+}
+
+// SchemaVersion checks that every table of the database has all the columns the client expects, ie. that the migration has been applied,
+// and returns a digest of this expected schema, to compare the versions of the clients sharing a database
+func (c *Client) SchemaVersion() (string, error) {
+	if c.drv == nil {
+		return "", fmt.Errorf("no database driver available to check the schema")
+	}
+	ctx, cancel := context.WithTimeout(c.CTX, healthCheckTimeout)
+	defer cancel()
+	digest := sha256.New()
+	for _, table := range migrate.Tables {
+		columns := make([]string, 0, len(table.Columns))
+		for _, column := range table.Columns {
+			columns = append(columns, column.Name)
+		}
+		fmt.Fprintf(digest, "%s:%s;", table.Name, strings.Join(columns, ","))
+		/*selecting all the columns fails if one of them is missing, whatever the backend*/
+		query, args := entsql.Dialect(c.drv.Dialect()).Select(columns...).From(entsql.Table(table.Name)).Limit(1).Query()
+		rows, err := c.drv.DB().QueryContext(ctx, query, args...)
+		if err != nil {
+			c.logger().Warningf("SchemaVersion : %s", err)
+			return "", wrapDBError(err, QueryFail, "table '%s' doesn't match the expected schema: %s", table.Name, err)
+		}
+		rows.Close()
+	}
+	return hex.EncodeToString(digest.Sum(nil))[:12], nil
+}
@@ -0,0 +1,243 @@
+package database
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/models"
+	"github.com/crowdsecurity/crowdsec/pkg/types"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+// getDBClient returns a client on a fresh sqlite database, and the function to call to get rid of it
+func getDBClient(t testing.TB) (*Client, func()) {
+	dir, err := ioutil.TempDir("", "crowdsec-db-test")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %s", err)
+	}
+	dbClient, err := NewClient(&csconfig.DatabaseCfg{
+		Type:   "sqlite",
+		DbPath: filepath.Join(dir, "crowdsec.db"),
+	})
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("unable to create db client: %s", err)
+	}
+	return dbClient, func() {
+		dbClient.Ent.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func newTestDecision(value string, scope string, decisionType string, duration string) *models.Decision {
+	origin := "crowdsec"
+	scenario := "crowdsecurity/test"
+	return &models.Decision{
+		Duration: &duration,
+		Origin:   &origin,
+		Scenario: &scenario,
+		Scope:    &scope,
+		Type:     &decisionType,
+		Value:    &value,
+	}
+}
+
+// newTestAlert returns a valid alert on ip sourceIP, with a 4h ban decision on it
+func newTestAlert(scenario string, sourceIP string) *models.Alert {
+	var capacity int32 = 5
+	var eventsCount int32 = 1
+	leakspeed := "10s"
+	message := "test alert"
+	scenarioHash := "hash"
+	scenarioVersion := "0.1"
+	simulated := false
+	scope := types.Ip
+	value := sourceIP
+	now := time.Now().UTC().Format(time.RFC3339)
+	startAt := now
+	stopAt := now
+	timestamp := now
+
+	decision := newTestDecision(sourceIP, types.Ip, "ban", "4h")
+	startIP, endIP, _ := GetIpsFromIpRange(sourceIP + "/32")
+	decision.StartIP = startIP
+	decision.EndIP = endIP
+
+	return &models.Alert{
+		Capacity:        &capacity,
+		Decisions:       []*models.Decision{decision},
+		Events:          []*models.Event{{Timestamp: &timestamp, Meta: models.Meta{{Key: "source_ip", Value: sourceIP}}}},
+		EventsCount:     &eventsCount,
+		Leakspeed:       &leakspeed,
+		Message:         &message,
+		Meta:            models.Meta{{Key: "source_ip", Value: sourceIP}},
+		Scenario:        &scenario,
+		ScenarioHash:    &scenarioHash,
+		ScenarioVersion: &scenarioVersion,
+		Simulated:       &simulated,
+		Source:          &models.Source{Scope: &scope, Value: &value, IP: sourceIP},
+		StartAt:         &startAt,
+		StopAt:          &stopAt,
+	}
+}
+
+func newTestAlerts(nb int) []*models.Alert {
+	alerts := make([]*models.Alert, 0, nb)
+	for i := 0; i < nb; i++ {
+		alerts = append(alerts, newTestAlert("crowdsecurity/test", Int2ip(uint32(0x01020300+i)).String()))
+	}
+	return alerts
+}
+
+/*tables as created by the schema before the alerts gained the acknowledged and archived columns*/
+var baselineSchema = []string{
+	"CREATE TABLE `alerts`(`id` integer PRIMARY KEY AUTOINCREMENT NOT NULL, `created_at` datetime NOT NULL, `updated_at` datetime NOT NULL, `scenario` varchar(255) NOT NULL, `bucket_id` varchar(255) NULL DEFAULT '', `message` varchar(255) NULL DEFAULT '', `events_count` integer NULL, `started_at` datetime NULL, `stopped_at` datetime NULL, `source_ip` varchar(255) NULL, `source_range` varchar(255) NULL, `source_as_number` varchar(255) NULL, `source_as_name` varchar(255) NULL, `source_country` varchar(255) NULL, `source_latitude` real NULL, `source_longitude` real NULL, `source_scope` varchar(255) NULL, `source_value` varchar(255) NULL, `capacity` integer NULL, `leak_speed` varchar(255) NULL, `scenario_version` varchar(255) NULL, `scenario_hash` varchar(255) NULL, `simulated` bool NOT NULL, `machine_alerts` integer NULL, FOREIGN KEY(`machine_alerts`) REFERENCES `machines`(`id`) ON DELETE SET NULL)",
+	"CREATE TABLE `bouncers`(`id` integer PRIMARY KEY AUTOINCREMENT NOT NULL, `created_at` datetime NOT NULL, `updated_at` datetime NOT NULL, `name` varchar(255) UNIQUE NOT NULL, `api_key` varchar(255) NOT NULL, `revoked` bool NOT NULL, `ip_address` varchar(255) NULL DEFAULT '', `type` varchar(255) NULL, `version` varchar(255) NULL, `until` datetime NULL, `last_pull` datetime NOT NULL)",
+	"CREATE TABLE `decisions`(`id` integer PRIMARY KEY AUTOINCREMENT NOT NULL, `created_at` datetime NOT NULL, `updated_at` datetime NOT NULL, `until` datetime NOT NULL, `scenario` varchar(255) NOT NULL, `type` varchar(255) NOT NULL, `start_ip` integer NULL, `end_ip` integer NULL, `scope` varchar(255) NOT NULL, `value` varchar(255) NOT NULL, `origin` varchar(255) NOT NULL, `simulated` bool NOT NULL, `alert_decisions` integer NULL, FOREIGN KEY(`alert_decisions`) REFERENCES `alerts`(`id`) ON DELETE SET NULL)",
+	"CREATE TABLE `events`(`id` integer PRIMARY KEY AUTOINCREMENT NOT NULL, `created_at` datetime NOT NULL, `updated_at` datetime NOT NULL, `time` datetime NOT NULL, `serialized` varchar(255) NOT NULL, `alert_events` integer NULL, FOREIGN KEY(`alert_events`) REFERENCES `alerts`(`id`) ON DELETE SET NULL)",
+	"CREATE TABLE `machines`(`id` integer PRIMARY KEY AUTOINCREMENT NOT NULL, `created_at` datetime NOT NULL, `updated_at` datetime NOT NULL, `machine_id` varchar(255) UNIQUE NOT NULL, `password` varchar(255) NOT NULL, `ip_address` varchar(255) NOT NULL, `scenarios` varchar(255) NULL, `version` varchar(255) NULL, `is_validated` bool NOT NULL, `status` varchar(255) NULL)",
+	"CREATE TABLE `meta`(`id` integer PRIMARY KEY AUTOINCREMENT NOT NULL, `created_at` datetime NOT NULL, `updated_at` datetime NOT NULL, `key` varchar(255) NOT NULL, `value` varchar(255) NOT NULL, `alert_metas` integer NULL, FOREIGN KEY(`alert_metas`) REFERENCES `alerts`(`id`) ON DELETE SET NULL)",
+}
+
+func TestMigrateBaselineDatabase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "crowdsec-db-test")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	dbPath := filepath.Join(dir, "crowdsec.db")
+
+	db, err := sql.Open("sqlite3", "file:"+dbPath)
+	if err != nil {
+		t.Fatalf("unable to open db: %s", err)
+	}
+	for _, statement := range baselineSchema {
+		_, err = db.Exec(statement)
+		assert.NoError(t, err)
+	}
+	now := time.Now()
+	_, err = db.Exec("INSERT INTO `alerts` (`created_at`, `updated_at`, `scenario`, `source_scope`, `source_value`, `simulated`) VALUES (?, ?, 'crowdsecurity/ssh-bf', 'Ip', '1.2.3.4', false)", now, now)
+	assert.NoError(t, err)
+	assert.NoError(t, db.Close())
+
+	dbClient, err := NewClient(&csconfig.DatabaseCfg{
+		Type:   "sqlite",
+		DbPath: dbPath,
+	})
+	if err != nil {
+		t.Fatalf("unable to migrate the database: %s", err)
+	}
+	defer dbClient.Ent.Close()
+
+	/*the alert stored before the migration is neither acknowledged nor archived*/
+	alerts, err := dbClient.QueryAlertWithFilter(map[string][]string{"acknowledged": {"false"}})
+	assert.NoError(t, err)
+	if assert.Len(t, alerts, 1) {
+		assert.Equal(t, "1.2.3.4", alerts[0].SourceValue)
+		assert.False(t, alerts[0].Acknowledged)
+		assert.False(t, alerts[0].Archived)
+	}
+	nbAcknowledged, err := dbClient.AcknowledgeAlerts([]int{alerts[0].ID}, "admin")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, nbAcknowledged)
+	alerts, err = dbClient.QueryAlertWithFilter(map[string][]string{"acknowledged": {"true"}})
+	assert.NoError(t, err)
+	assert.Len(t, alerts, 1)
+
+	_, err = dbClient.CreateAlertBulk("test", newTestAlerts(1))
+	assert.NoError(t, err)
+	alerts, err = dbClient.QueryAlertWithFilter(map[string][]string{"acknowledged": {"false"}})
+	assert.NoError(t, err)
+	assert.Len(t, alerts, 1)
+}
+
+func TestPostFlushMaintenance(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	_, err := dbClient.CreateAlertBulk("test", newTestAlerts(10))
+	assert.NoError(t, err)
+	assert.NoError(t, dbClient.PostFlushMaintenance())
+
+	/*maintenance runs once the flush deleted more alerts than the threshold*/
+	dbClient.MaintenanceThreshold = 5
+	assert.NoError(t, dbClient.FlushAlerts("", 2))
+	nbAlerts, err := dbClient.TotalAlerts()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, nbAlerts)
+
+	assert.Error(t, (&Client{}).PostFlushMaintenance())
+}
+
+func TestClientLogger(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+	assert.NotNil(t, dbClient.Logger)
+
+	logger, hook := test.NewNullLogger()
+	logger.SetLevel(log.DebugLevel)
+	dbClient.Logger = logger.WithField("component", "database")
+
+	_, err := dbClient.CreateAlertBulk("test", newTestAlerts(1))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, hook.AllEntries())
+	for _, entry := range hook.AllEntries() {
+		assert.Equal(t, "database", entry.Data["component"])
+	}
+}
+
+func TestClientLoggerLevel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "crowdsec-db-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	level := log.ErrorLevel
+	dbClient, err := NewClient(&csconfig.DatabaseCfg{
+		Type:     "sqlite",
+		DbPath:   filepath.Join(dir, "crowdsec.db"),
+		LogLevel: &level,
+	})
+	assert.NoError(t, err)
+	defer dbClient.Ent.Close()
+
+	/*the level of the database config applies to all the operations, not only to the ones logging through Log*/
+	hook := test.NewLocal(dbClient.Log)
+	_, err = dbClient.CreateAlertBulk("test", newTestAlerts(1))
+	assert.NoError(t, err)
+	_, err = dbClient.GetAlertByID(42)
+	assert.Error(t, err)
+	assert.Empty(t, hook.AllEntries())
+
+	dbClient.Log.SetLevel(log.InfoLevel)
+	_, err = dbClient.CreateAlertBulk("test", newTestAlerts(1))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, hook.AllEntries())
+}
+
+func TestPing(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+	assert.NoError(t, dbClient.Ping())
+
+	version, err := dbClient.SchemaVersion()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, version)
+	again, err := dbClient.SchemaVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, version, again)
+
+	_, err = dbClient.drv.DB().Exec("ALTER TABLE alerts RENAME COLUMN scenario TO old_scenario")
+	assert.NoError(t, err)
+	_, err = dbClient.SchemaVersion()
+	assert.Equal(t, QueryFail, errors.Cause(err))
+
+	dbClient.Ent.Close()
+	assert.Error(t, dbClient.Ping())
+}
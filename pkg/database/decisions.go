@@ -1,7 +1,10 @@
 package database
 
 import (
+	"encoding/csv"
 	"fmt"
+	"io"
+	"sort"
 	"strings"
 	"time"
 
@@ -9,50 +12,48 @@ import (
 
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent"
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent/decision"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/predicate"
 	"github.com/crowdsecurity/crowdsec/pkg/types"
+	"github.com/facebook/ent/dialect"
+	"github.com/facebook/ent/dialect/sql"
 	"github.com/pkg/errors"
-	log "github.com/sirupsen/logrus"
 )
 
+/*decision types that allow a value, as opposed to the ones that restrict it (ban, captcha...)*/
+var allowDecisionTypes = []string{"whitelist", "allow"}
+
 func BuildDecisionRequestWithFilter(query *ent.DecisionQuery, filter map[string][]string) (*ent.DecisionQuery, error) {
 	var err error
-	var startIP, endIP int64
+	var ipSize int
+	var startIP, startSuffix, endIP, endSuffix int64
 
-	/*the simulated filter is a bit different : if it's not present *or* set to false, specifically exclude records with simulated to true */
-	if v, ok := filter["simulated"]; ok {
-		if v[0] == "false" {
-			query = query.Where(decision.SimulatedEQ(false))
-		}
-		delete(filter, "simulated")
-	} else {
+	/*the simulated filter is a bit different : if it's not present *or* set to false, specifically exclude records with simulated to true.
+	include_simulated is an alias of simulated, read here without touching the caller's filter*/
+	simulated, ok := filter["simulated"]
+	if !ok {
+		simulated, ok = filter["include_simulated"]
+	}
+	if !ok || simulated[0] == "false" {
 		query = query.Where(decision.SimulatedEQ(false))
 	}
 
 	for param, value := range filter {
 		switch param {
+		case "simulated", "include_simulated":
+			continue
 		case "scope":
-			var scope string = value[0]
-			if strings.ToLower(scope) == "ip" {
-				scope = types.Ip
-			} else if strings.ToLower(scope) == "range" {
-				scope = types.Range
-			}
-			query = query.Where(decision.ScopeEQ(scope))
+			query = query.Where(decision.ScopeEQ(normalizeScope(value[0])))
 		case "value":
 			query = query.Where(decision.ValueEQ(value[0]))
 		case "type":
 			query = query.Where(decision.TypeEQ(value[0]))
 		case "ip":
-			isValidIP := IsIpv4(value[0])
-			if !isValidIP {
-				return nil, errors.Wrapf(InvalidIPOrRange, "unable to parse '%s': %s", value[0], err)
-			}
-			startIP, endIP, err = GetIpsFromIpRange(value[0] + "/32")
+			ipSize, startIP, startSuffix, endIP, endSuffix, err = getIpBoundsFromIp(value[0])
 			if err != nil {
 				return nil, errors.Wrapf(InvalidIPOrRange, "unable to convert '%s' to int interval: %s", value[0], err)
 			}
 		case "range":
-			startIP, endIP, err = GetIpsFromIpRange(value[0])
+			ipSize, startIP, startSuffix, endIP, endSuffix, err = GetIpBoundsFromIpRange(value[0])
 			if err != nil {
 				return nil, errors.Wrapf(InvalidIPOrRange, "unable to convert '%s' to int interval: %s", value[0], err)
 			}
@@ -61,21 +62,9 @@ func BuildDecisionRequestWithFilter(query *ent.DecisionQuery, filter map[string]
 		}
 	}
 
-	if startIP != 0 && endIP != 0 {
-		/*the user is checking for a single IP*/
-		if startIP == endIP {
-			//DECISION_START <= IP_Q >= DECISON_END
-			query = query.Where(decision.And(
-				decision.StartIPLTE(startIP),
-				decision.EndIPGTE(endIP),
-			))
-		} else { /*the user is checking for a RANGE */
-			//START_Q >= DECISION_START AND END_Q <= DECISION_END
-			query = query.Where(decision.And(
-				decision.StartIPGTE(startIP),
-				decision.EndIPLTE(endIP),
-			))
-		}
+	/*an IPv4 filter must not compare its bounds with the ones of the IPv6 decisions, and the other way around*/
+	if ipFilter := decisionIPFilter(ipSize, startIP, startSuffix, endIP, endSuffix); ipFilter != nil {
+		query = query.Where(ipFilter)
 	}
 	return query, nil
 }
@@ -83,6 +72,15 @@ func BuildDecisionRequestWithFilter(query *ent.DecisionQuery, filter map[string]
 func (c *Client) QueryDecisionWithFilter(filter map[string][]string) ([]*ent.Decision, error) {
 	var data []*ent.Decision
 	var err error
+	var withAlert bool
+
+	/*with_alert loads the alert that led to each decision, to get its context (message etc.) without extra queries*/
+	if v, ok := filter["with_alert"]; ok {
+		if withAlert, err = strconv.ParseBool(v[0]); err != nil {
+			return []*ent.Decision{}, errors.Wrapf(ParseType, "'%s' is not a boolean: %s", v[0], err)
+		}
+		delete(filter, "with_alert")
+	}
 
 	decisions := c.Ent.Decision.Query().
 		Where(decision.UntilGTE(time.Now()))
@@ -92,6 +90,15 @@ func (c *Client) QueryDecisionWithFilter(filter map[string][]string) ([]*ent.Dec
 		return []*ent.Decision{}, err
 	}
 
+	if withAlert {
+		data, err = decisions.WithOwner().All(c.CTX)
+		if err != nil {
+			c.logger().Warningf("QueryDecisionWithFilter : %s", err)
+			return []*ent.Decision{}, errors.Wrap(QueryFail, "query decision with alert failed")
+		}
+		return data, nil
+	}
+
 	err = decisions.Select(
 		decision.FieldID,
 		decision.FieldUntil,
@@ -102,19 +109,418 @@ func (c *Client) QueryDecisionWithFilter(filter map[string][]string) ([]*ent.Dec
 		decision.FieldValue,
 		decision.FieldScope,
 		decision.FieldOrigin,
+		decision.FieldSimulated,
 	).Scan(c.CTX, &data)
 	if err != nil {
-		log.Warningf("QueryDecisionWithFilter : %s", err)
+		c.logger().Warningf("QueryDecisionWithFilter : %s", err)
 		return []*ent.Decision{}, errors.Wrap(QueryFail, "query decision failed")
 	}
 
 	return data, nil
 }
 
+// HasActiveDecisionForValue tells if there is an active, non-simulated decision on exactly value
+func (c *Client) HasActiveDecisionForValue(value string) (bool, error) {
+	exist, err := c.Ent.Decision.Query().
+		Where(decision.ValueEQ(value), decision.UntilGTE(time.Now()), decision.SimulatedEQ(false)).
+		Exist(c.CTX)
+	if err != nil {
+		c.logger().Warningf("HasActiveDecisionForValue : %s", err)
+		return false, errors.Wrapf(QueryFail, "active decision on '%s'", value)
+	}
+	return exist, nil
+}
+
+// GetActiveDecisionsByIP returns the active decisions covering ip (either on it or on a range including it), simulated ones included only if includeSimulated is set.
+// Only the decisions table is read, which makes it cheaper than looking the ip up through the alerts.
+func (c *Client) GetActiveDecisionsByIP(ip string, includeSimulated bool) ([]*ent.Decision, error) {
+	ipSize, startIP, startSuffix, endIP, _, err := getIpBoundsFromIp(ip)
+	if err != nil {
+		return []*ent.Decision{}, errors.Wrapf(InvalidIPOrRange, "unable to convert '%s' to int interval: %s", ip, err)
+	}
+	covering := decision.And(ipv4Decision(), decision.StartIPLTE(startIP), decision.EndIPGTE(endIP))
+	if ipSize == 16 {
+		covering = ipv6DecisionContains(startIP, startSuffix)
+	}
+	query := c.Ent.Decision.Query().Where(covering, decision.UntilGTE(time.Now()))
+	if !includeSimulated {
+		query = query.Where(decision.SimulatedEQ(false))
+	}
+	decisions, err := query.All(c.CTX)
+	if err != nil {
+		c.logger().Warningf("GetActiveDecisionsByIP : %s", err)
+		return []*ent.Decision{}, errors.Wrapf(QueryFail, "active decisions on '%s'", ip)
+	}
+	return decisions, nil
+}
+
+// GetDecisionsInRange returns the active decisions on ips or ranges that overlap the cidr, ie. that cover at least one of its addresses :
+// the decisions on a range containing the cidr, or on ips and ranges inside it, but also on ranges going across one of its bounds.
+// The decisions entirely within the cidr are the ones the range filters of the alerts and decisions match
+func (c *Client) GetDecisionsInRange(cidr string) ([]*ent.Decision, error) {
+	ipSize, startIP, startSuffix, endIP, endSuffix, err := GetIpBoundsFromIpRange(cidr)
+	if err != nil {
+		return []*ent.Decision{}, errors.Wrapf(InvalidIPOrRange, "unable to convert '%s' to int interval: %s", cidr, err)
+	}
+	/*DECISION_START <= END_Q and DECISION_END >= START_Q*/
+	overlapping := decision.And(ipv4Decision(), decision.StartIPLTE(endIP), decision.EndIPGTE(startIP))
+	if ipSize == 16 {
+		overlapping = ipv6DecisionOverlaps(startIP, startSuffix, endIP, endSuffix)
+	}
+	decisions, err := c.Ent.Decision.Query().
+		Where(overlapping, decision.UntilGTE(time.Now())).
+		Where(decision.Or(decision.ScopeEqualFold(types.Ip), decision.ScopeEqualFold(types.Range))).
+		Order(ent.Asc(decision.FieldID)).
+		All(c.CTX)
+	if err != nil {
+		c.logger().Warningf("GetDecisionsInRange : %s", err)
+		return []*ent.Decision{}, errors.Wrapf(QueryFail, "active decisions in '%s'", cidr)
+	}
+	return decisions, nil
+}
+
+// GetDecisionIDsByFilter returns only the IDs of the active decisions matching the filter, without hydrating them
+func (c *Client) GetDecisionIDsByFilter(filter map[string][]string) ([]int, error) {
+	var err error
+
+	decisions := c.Ent.Decision.Query().
+		Where(decision.UntilGTE(time.Now()))
+
+	decisions, err = BuildDecisionRequestWithFilter(decisions, filter)
+	if err != nil {
+		return []int{}, err
+	}
+
+	ids, err := decisions.Select(decision.FieldID).Ints(c.CTX)
+	if err != nil {
+		c.logger().Warningf("GetDecisionIDsByFilter : %s", err)
+		return []int{}, errors.Wrap(QueryFail, "query decision ids failed")
+	}
+	return ids, nil
+}
+
+// ExportActiveDecisionsCSV writes the active decisions to w as CSV, fetching them paginationSize at a time.
+// Simulated decisions are meant for reporting only : they are exported (and flagged as such) only if includeSimulated is set.
+func (c *Client) ExportActiveDecisionsCSV(w io.Writer, includeSimulated bool) (int, error) {
+	var nbExported int
+	lastID := 0
+
+	csvWriter := csv.NewWriter(w)
+	if err := csvWriter.Write([]string{"value", "scope", "type", "origin", "until", "simulated"}); err != nil {
+		return 0, errors.Wrapf(MarshalFail, "csv header: %s", err)
+	}
+	for {
+		query := c.Ent.Decision.Query().
+			Where(decision.UntilGTE(time.Now())).
+			Where(decision.IDGT(lastID))
+		if !includeSimulated {
+			query = query.Where(decision.SimulatedEQ(false))
+		}
+		decisions, err := query.
+			Order(ent.Asc(decision.FieldID)).
+			Limit(paginationSize).
+			All(c.CTX)
+		if err != nil {
+			c.logger().Warningf("ExportActiveDecisionsCSV : %s", err)
+			return nbExported, errors.Wrapf(QueryFail, "active decisions after id %d", lastID)
+		}
+		for _, decisionItem := range decisions {
+			err := csvWriter.Write([]string{
+				decisionItem.Value,
+				decisionItem.Scope,
+				decisionItem.Type,
+				decisionItem.Origin,
+				decisionItem.Until.Format(time.RFC3339),
+				strconv.FormatBool(decisionItem.Simulated),
+			})
+			if err != nil {
+				return nbExported, errors.Wrapf(MarshalFail, "decision %d: %s", decisionItem.ID, err)
+			}
+			nbExported++
+			lastID = decisionItem.ID
+		}
+		csvWriter.Flush()
+		if err := csvWriter.Error(); err != nil {
+			return nbExported, errors.Wrapf(MarshalFail, "writing csv: %s", err)
+		}
+		if len(decisions) < paginationSize {
+			break
+		}
+	}
+	return nbExported, nil
+}
+
+// DecisionDurationHistogram counts the active decisions by duration (until - created_at) : a decision goes into the smallest bucket it fits in, or in the overflow one.
+// The decisions are bucketed by the database, only the count of each bucket is read. Being a reporting query, the simulated decisions are meant to be counted (includeSimulated set).
+func (c *Client) DecisionDurationHistogram(buckets []time.Duration, includeSimulated bool) (map[string]int, error) {
+	var durationExpr string
+
+	if len(buckets) == 0 {
+		return map[string]int{}, errors.Wrap(InvalidFilter, "at least one bucket is needed")
+	}
+	if c.drv == nil {
+		return map[string]int{}, errors.Wrap(QueryFail, "no database driver available")
+	}
+	sortedBuckets := make([]time.Duration, len(buckets))
+	copy(sortedBuckets, buckets)
+	sort.Slice(sortedBuckets, func(i, j int) bool { return sortedBuckets[i] < sortedBuckets[j] })
+
+	labels := make([]string, 0, len(sortedBuckets)+1)
+	for _, bucket := range sortedBuckets {
+		labels = append(labels, "<="+bucket.String())
+	}
+	labels = append(labels, ">"+sortedBuckets[len(sortedBuckets)-1].String())
+	ret := make(map[string]int)
+	for _, label := range labels {
+		ret[label] = 0
+	}
+
+	selector := sql.Dialect(c.drv.Dialect()).Select().From(sql.Table(decision.Table))
+	until, createdAt := selector.C(decision.FieldUntil), selector.C(decision.FieldCreatedAt)
+	/*the duration of the decisions in seconds*/
+	switch c.drv.Dialect() {
+	case dialect.SQLite:
+		durationExpr = fmt.Sprintf("(julianday(%s) - julianday(%s)) * 86400", until, createdAt)
+	case dialect.MySQL:
+		durationExpr = fmt.Sprintf("TIMESTAMPDIFF(SECOND, %s, %s)", createdAt, until)
+	case dialect.Postgres:
+		durationExpr = fmt.Sprintf("EXTRACT(EPOCH FROM (%s - %s))", until, createdAt)
+	default:
+		return map[string]int{}, errors.Wrapf(QueryFail, "no duration histogram available for '%s'", c.drv.Dialect())
+	}
+	/*the index of the bucket of each decision, the overflow one being the last*/
+	bucketExpr := "CASE"
+	for i, bucket := range sortedBuckets {
+		bucketExpr += fmt.Sprintf(" WHEN %s <= %s THEN %d", durationExpr, strconv.FormatFloat(bucket.Seconds(), 'f', -1, 64), i)
+	}
+	bucketExpr += fmt.Sprintf(" ELSE %d END", len(sortedBuckets))
+
+	where := sql.GTE(until, time.Now())
+	if !includeSimulated {
+		where = sql.And(where, sql.EQ(selector.C(decision.FieldSimulated), false))
+	}
+	query, args := selector.
+		Select(sql.As(bucketExpr, "bucket"), sql.As(sql.Count("*"), "count")).
+		Where(where).
+		GroupBy("bucket").
+		Query()
+	rows, err := c.drv.DB().QueryContext(c.CTX, query, args...)
+	if err != nil {
+		c.logger().Warningf("DecisionDurationHistogram : %s", err)
+		return map[string]int{}, wrapDBError(err, QueryFail, "decisions duration: %s", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var bucket, count int
+		if err := rows.Scan(&bucket, &count); err != nil {
+			c.logger().Warningf("DecisionDurationHistogram : %s", err)
+			return map[string]int{}, errors.Wrapf(QueryFail, "decisions duration: %s", err)
+		}
+		ret[labels[bucket]] = count
+	}
+	if err := rows.Err(); err != nil {
+		c.logger().Warningf("DecisionDurationHistogram : %s", err)
+		return map[string]int{}, wrapDBError(err, QueryFail, "decisions duration: %s", err)
+	}
+	return ret, nil
+}
+
+// DecisionOverlapByOrigin returns, for each pair of origins (as "origin1/origin2", sorted), how many values have an active decision from both.
+// Being a reporting query, the simulated decisions are meant to be included (includeSimulated set).
+func (c *Client) DecisionOverlapByOrigin(includeSimulated bool) (map[string]int, error) {
+	var data []struct {
+		Value  string `json:"value"`
+		Origin string `json:"origin"`
+	}
+
+	query := c.Ent.Decision.Query().
+		Where(decision.UntilGTE(time.Now()))
+	if !includeSimulated {
+		query = query.Where(decision.SimulatedEQ(false))
+	}
+	err := query.
+		GroupBy(decision.FieldValue, decision.FieldOrigin).
+		Scan(c.CTX, &data)
+	if err != nil {
+		c.logger().Warningf("DecisionOverlapByOrigin : %s", err)
+		return map[string]int{}, errors.Wrap(QueryFail, "group decisions by value and origin")
+	}
+
+	originsByValue := make(map[string][]string)
+	for _, item := range data {
+		originsByValue[item.Value] = append(originsByValue[item.Value], item.Origin)
+	}
+	ret := make(map[string]int)
+	for _, origins := range originsByValue {
+		sort.Strings(origins)
+		for i := 0; i < len(origins); i++ {
+			for j := i + 1; j < len(origins); j++ {
+				ret[origins[i]+"/"+origins[j]]++
+			}
+		}
+	}
+	return ret, nil
+}
+
+// FindConflictingDecisions returns the active decisions on values that are both allowed (see allowDecisionTypes) and restricted by other decisions
+func (c *Client) FindConflictingDecisions() ([]*ent.Decision, error) {
+	var data []struct {
+		Value string `json:"value"`
+		Type  string `json:"type"`
+	}
+
+	err := c.Ent.Decision.Query().
+		Where(decision.UntilGTE(time.Now())).
+		Where(decision.SimulatedEQ(false)).
+		GroupBy(decision.FieldValue, decision.FieldType).
+		Scan(c.CTX, &data)
+	if err != nil {
+		c.logger().Warningf("FindConflictingDecisions : %s", err)
+		return []*ent.Decision{}, errors.Wrap(QueryFail, "group decisions by value and type")
+	}
+
+	allowed := make(map[string]bool)
+	restricted := make(map[string]bool)
+	for _, item := range data {
+		if isAllowDecisionType(item.Type) {
+			allowed[item.Value] = true
+		} else {
+			restricted[item.Value] = true
+		}
+	}
+	conflictingValues := make([]string, 0)
+	for value := range allowed {
+		if restricted[value] {
+			conflictingValues = append(conflictingValues, value)
+		}
+	}
+	sort.Strings(conflictingValues)
+
+	ret := make([]*ent.Decision, 0)
+	/*fetch by pages of values to avoid 'too many SQL variable'*/
+	for start := 0; start < len(conflictingValues); start += paginationSize {
+		end := start + paginationSize
+		if end > len(conflictingValues) {
+			end = len(conflictingValues)
+		}
+		decisions, err := c.Ent.Decision.Query().
+			Where(decision.UntilGTE(time.Now())).
+			Where(decision.SimulatedEQ(false)).
+			Where(decision.ValueIn(conflictingValues[start:end]...)).
+			Order(ent.Asc(decision.FieldValue), ent.Asc(decision.FieldID)).
+			All(c.CTX)
+		if err != nil {
+			c.logger().Warningf("FindConflictingDecisions : %s", err)
+			return []*ent.Decision{}, errors.Wrap(QueryFail, "conflicting decisions")
+		}
+		ret = append(ret, decisions...)
+	}
+	return ret, nil
+}
+
+func isAllowDecisionType(decisionType string) bool {
+	for _, allowType := range allowDecisionTypes {
+		if strings.EqualFold(decisionType, allowType) {
+			return true
+		}
+	}
+	return false
+}
+
+// LastDecisionPerScenario returns, for each scenario, the creation time of its most recent decision.
+// Being a reporting query, the simulated decisions are meant to be included (includeSimulated set).
+func (c *Client) LastDecisionPerScenario(includeSimulated bool) (map[string]time.Time, error) {
+	var groups []struct {
+		Scenario string         `json:"scenario"`
+		Last     aggregatedTime `json:"last"`
+	}
+
+	query := c.Ent.Decision.Query()
+	if !includeSimulated {
+		query = query.Where(decision.SimulatedEQ(false))
+	}
+	err := query.
+		GroupBy(decision.FieldScenario).
+		Aggregate(ent.As(ent.Max(decision.FieldCreatedAt), "last")).
+		Scan(c.CTX, &groups)
+	if err != nil {
+		c.logger().Warningf("LastDecisionPerScenario : %s", err)
+		return map[string]time.Time{}, errors.Wrap(QueryFail, "most recent decision by scenario")
+	}
+
+	ret := make(map[string]time.Time, len(groups))
+	for _, group := range groups {
+		ret[group.Scenario] = group.Last.Time
+	}
+	return ret, nil
+}
+
+// LongestActiveDecisionPerValue returns, for the limit values with the longest lasting active decisions,
+// the decision expiring last, the longest lasting first. Being a reporting query, the simulated decisions are meant to be included (includeSimulated set).
+func (c *Client) LongestActiveDecisionPerValue(limit int, includeSimulated bool) ([]*ent.Decision, error) {
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	var groups []struct {
+		Value    string         `json:"value"`
+		MaxUntil aggregatedTime `json:"max_until"`
+	}
+
+	now := time.Now()
+	active := []predicate.Decision{decision.UntilGTE(now)}
+	if !includeSimulated {
+		active = append(active, decision.SimulatedEQ(false))
+	}
+	/*the values are picked by the database, from the one whose decision expires last*/
+	err := c.Ent.Decision.Query().
+		Where(active...).
+		Order(func(s *sql.Selector, _ func(string) bool) {
+			s.OrderBy(sql.Desc("max_until"), sql.Asc(decision.FieldValue))
+		}).
+		Limit(limit).
+		GroupBy(decision.FieldValue).
+		Aggregate(ent.As(ent.Max(decision.FieldUntil), "max_until")).
+		Scan(c.CTX, &groups)
+	if err != nil {
+		c.logger().Warningf("LongestActiveDecisionPerValue : %s", err)
+		return []*ent.Decision{}, errors.Wrap(QueryFail, "group active decisions by value")
+	}
+	if len(groups) == 0 {
+		return []*ent.Decision{}, nil
+	}
+	values := make([]string, 0, len(groups))
+	for _, group := range groups {
+		values = append(values, group.Value)
+	}
+	/*then only the decisions of those values are fetched : the first one of each value is its longest*/
+	decisions, err := c.Ent.Decision.Query().
+		Where(decision.ValueIn(values...)).
+		Where(active...).
+		Order(ent.Desc(decision.FieldUntil), ent.Desc(decision.FieldID)).
+		All(c.CTX)
+	if err != nil {
+		c.logger().Warningf("LongestActiveDecisionPerValue : %s", err)
+		return []*ent.Decision{}, errors.Wrapf(QueryFail, "active decisions of %d values", len(values))
+	}
+	longest := make(map[string]*ent.Decision, len(values))
+	for _, decisionItem := range decisions {
+		if _, ok := longest[decisionItem.Value]; !ok {
+			longest[decisionItem.Value] = decisionItem
+		}
+	}
+	ret := make([]*ent.Decision, 0, len(values))
+	for _, value := range values {
+		if decisionItem, ok := longest[value]; ok {
+			ret = append(ret, decisionItem)
+		}
+	}
+	return ret, nil
+}
+
 func (c *Client) QueryAllDecisions() ([]*ent.Decision, error) {
 	data, err := c.Ent.Decision.Query().Where(decision.UntilGT(time.Now())).All(c.CTX)
 	if err != nil {
-		log.Warningf("QueryAllDecisions : %s", err)
+		c.logger().Warningf("QueryAllDecisions : %s", err)
 		return []*ent.Decision{}, errors.Wrap(QueryFail, "get all decisions")
 	}
 	return data, nil
@@ -123,16 +529,76 @@ func (c *Client) QueryAllDecisions() ([]*ent.Decision, error) {
 func (c *Client) QueryExpiredDecisions() ([]*ent.Decision, error) {
 	data, err := c.Ent.Decision.Query().Where(decision.UntilLT(time.Now())).All(c.CTX)
 	if err != nil {
-		log.Warningf("QueryExpiredDecisions : %s", err)
+		c.logger().Warningf("QueryExpiredDecisions : %s", err)
 		return []*ent.Decision{}, errors.Wrap(QueryFail, "expired decisions")
 	}
 	return data, nil
 }
 
+// CountExpiredDecisions returns the number of decisions that are expired, ie. the ones a purge would remove
+func (c *Client) CountExpiredDecisions() (int, error) {
+	count, err := c.Ent.Decision.Query().Where(decision.UntilLT(time.Now())).Count(c.CTX)
+	if err != nil {
+		c.logger().Warningf("CountExpiredDecisions : %s", err)
+		return 0, errors.Wrap(QueryFail, "count expired decisions")
+	}
+	return count, nil
+}
+
+// CountActiveDecisions returns the number of decisions that are not expired, leaving out the simulated ones unless includeSimulated is set
+func (c *Client) CountActiveDecisions(includeSimulated bool) (int, error) {
+	query := c.Ent.Decision.Query().Where(decision.UntilGTE(time.Now()))
+	if !includeSimulated {
+		query = query.Where(decision.SimulatedEQ(false))
+	}
+	count, err := query.Count(c.CTX)
+	if err != nil {
+		c.logger().Warningf("CountActiveDecisions : %s", err)
+		return 0, errors.Wrap(QueryFail, "count active decisions")
+	}
+	return count, nil
+}
+
+// CountActiveDecisionsByScope returns the number of decisions that are not expired by scope, leaving out the simulated ones unless includeSimulated is set
+func (c *Client) CountActiveDecisionsByScope(includeSimulated bool) (map[string]int, error) {
+	var groups []struct {
+		Scope string `json:"scope"`
+		Count int    `json:"count"`
+	}
+	query := c.Ent.Decision.Query().Where(decision.UntilGTE(time.Now()))
+	if !includeSimulated {
+		query = query.Where(decision.SimulatedEQ(false))
+	}
+	err := query.
+		GroupBy(decision.FieldScope).
+		Aggregate(ent.Count()).
+		Scan(c.CTX, &groups)
+	if err != nil {
+		c.logger().Warningf("CountActiveDecisionsByScope : %s", err)
+		return map[string]int{}, errors.Wrap(QueryFail, "group active decisions by scope")
+	}
+	ret := make(map[string]int, len(groups))
+	for _, group := range groups {
+		ret[group.Scope] = group.Count
+	}
+	return ret, nil
+}
+
+// FlushExpiredDecisions deletes the expired decisions, keeping their alerts and events, and returns how many were deleted.
+// Bouncers learn about expired decisions from the decisions stream : the ones deleted before a bouncer pulled them are not reported to it.
+func (c *Client) FlushExpiredDecisions() (int, error) {
+	nbDeleted, err := c.Ent.Decision.Delete().Where(decision.UntilLT(time.Now())).Exec(c.CTX)
+	if err != nil {
+		c.logger().Warningf("FlushExpiredDecisions : %s", err)
+		return 0, errors.Wrap(DeleteFail, "expired decisions")
+	}
+	return nbDeleted, nil
+}
+
 func (c *Client) QueryExpiredDecisionsSince(since time.Time) ([]*ent.Decision, error) {
 	data, err := c.Ent.Decision.Query().Where(decision.UntilLT(time.Now())).Where(decision.UntilGT(since)).All(c.CTX)
 	if err != nil {
-		log.Warningf("QueryExpiredDecisionsSince : %s", err)
+		c.logger().Warningf("QueryExpiredDecisionsSince : %s", err)
 		return []*ent.Decision{}, errors.Wrap(QueryFail, "expired decisions")
 	}
 	return data, nil
@@ -141,24 +607,71 @@ func (c *Client) QueryExpiredDecisionsSince(since time.Time) ([]*ent.Decision, e
 func (c *Client) QueryNewDecisionsSince(since time.Time) ([]*ent.Decision, error) {
 	data, err := c.Ent.Decision.Query().Where(decision.CreatedAtGT(since)).All(c.CTX)
 	if err != nil {
-		log.Warningf("QueryNewDecisionsSince : %s", err)
+		c.logger().Warningf("QueryNewDecisionsSince : %s", err)
 		return []*ent.Decision{}, errors.Wrapf(QueryFail, "new decisions since '%s'", since.String())
 	}
 	return data, nil
 }
 
+// GetDecisionsSince returns the decisions that changed after since, for a bouncer to only pull what changed since its last poll :
+// the ones created or updated (ie. extended or deleted) since then, and the ones that expired since then.
+// The decisions whose Until is past are to be removed by the bouncer, the other ones to be added (or refreshed).
+func (c *Client) GetDecisionsSince(since time.Time) ([]*ent.Decision, error) {
+	decisions, err := c.Ent.Decision.Query().
+		Where(decision.Or(
+			decision.CreatedAtGT(since),
+			decision.UpdatedAtGT(since),
+			decision.And(decision.UntilGT(since), decision.UntilLTE(time.Now())),
+		)).
+		Order(ent.Asc(decision.FieldID)).
+		All(c.CTX)
+	if err != nil {
+		c.logger().Warningf("GetDecisionsSince : %s", err)
+		return []*ent.Decision{}, errors.Wrapf(QueryFail, "decisions changed since '%s'", since.String())
+	}
+	return decisions, nil
+}
+
+// GetDecisionByID returns the decision with its alert, or an ItemNotFound error if there is no such decision
+func (c *Client) GetDecisionByID(decisionID int) (*ent.Decision, error) {
+	decisionItem, err := c.Ent.Decision.Query().Where(decision.IDEQ(decisionID)).WithOwner().Only(c.CTX)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			c.logger().Warningf("GetDecisionByID (not found): %s", err)
+			return &ent.Decision{}, errors.Wrapf(ItemNotFound, "decision with id '%d'", decisionID)
+		}
+		c.logger().Warningf("GetDecisionByID : %s", err)
+		return &ent.Decision{}, wrapDBError(err, QueryFail, "decision with id '%d': %s", decisionID, err)
+	}
+	return decisionItem, nil
+}
+
 func (c *Client) DeleteDecisionById(decisionId int) error {
 	err := c.Ent.Decision.DeleteOneID(decisionId).Exec(c.CTX)
 	if err != nil {
-		log.Warningf("DeleteDecisionById : %s", err)
+		c.logger().Warningf("DeleteDecisionById : %s", err)
 		return errors.Wrapf(DeleteFail, "decision with id '%d' doesn't exist", decisionId)
 	}
 	return nil
 }
 
+// DeleteDecisionsByValue deletes the decisions on exactly value in scope (ie. to unban an ip), leaving their alerts in place,
+// and returns how many were deleted. Only the value is compared : a Range decision including an ip isn't deleted along with the Ip ones.
+func (c *Client) DeleteDecisionsByValue(value string, scope string) (int, error) {
+	nbDeleted, err := c.Ent.Decision.Delete().
+		Where(decision.ValueEQ(value), decision.ScopeEQ(normalizeScope(scope))).
+		Exec(c.CTX)
+	if err != nil {
+		c.logger().Warningf("DeleteDecisionsByValue : %s", err)
+		return 0, errors.Wrapf(DeleteFail, "decisions on %s '%s'", scope, value)
+	}
+	return nbDeleted, nil
+}
+
 func (c *Client) DeleteDecisionsWithFilter(filter map[string][]string) (string, error) {
 	var err error
-	var startIP, endIP int64
+	var ipSize int
+	var startIP, startSuffix, endIP, endSuffix int64
 
 	decisions := c.Ent.Decision.Delete()
 
@@ -171,44 +684,26 @@ func (c *Client) DeleteDecisionsWithFilter(filter map[string][]string) (string,
 		case "type":
 			decisions = decisions.Where(decision.TypeEQ(value[0]))
 		case "ip":
-			isValidIP := IsIpv4(value[0])
-			if !isValidIP {
-				return "0", errors.Wrap(InvalidIPOrRange, fmt.Sprintf("unable to parse '%s': %s", value[0], err))
-			}
-			startIP, endIP, err = GetIpsFromIpRange(value[0] + "/32")
+			ipSize, startIP, startSuffix, endIP, endSuffix, err = getIpBoundsFromIp(value[0])
 			if err != nil {
 				return "0", errors.Wrap(InvalidIPOrRange, fmt.Sprintf("unable to convert '%s' to int interval: %s", value[0], err))
 			}
 		case "range":
-			startIP, endIP, err = GetIpsFromIpRange(value[0])
+			ipSize, startIP, startSuffix, endIP, endSuffix, err = GetIpBoundsFromIpRange(value[0])
 			if err != nil {
 				return "0", errors.Wrap(InvalidIPOrRange, fmt.Sprintf("unable to convert '%s' to int interval: %s", value[0], err))
 			}
 		default:
 			return "0", errors.Wrap(InvalidFilter, fmt.Sprintf("'%s' doesn't exist", param))
 		}
-
-		if startIP != 0 && endIP != 0 {
-			/*the user is checking for a single IP*/
-			if startIP == endIP {
-				//DECISION_START <= IP_Q >= DECISON_END
-				decisions = decisions.Where(decision.And(
-					decision.StartIPLTE(startIP),
-					decision.EndIPGTE(endIP),
-				))
-			} else { /*the user is checking for a RANGE */
-				//START_Q >= DECISION_START AND END_Q <= DECISION_END
-				decisions = decisions.Where(decision.And(
-					decision.StartIPGTE(startIP),
-					decision.EndIPLTE(endIP),
-				))
-			}
-		}
+	}
+	if ipFilter := decisionIPFilter(ipSize, startIP, startSuffix, endIP, endSuffix); ipFilter != nil {
+		decisions = decisions.Where(ipFilter)
 	}
 
 	nbDeleted, err := decisions.Exec(c.CTX)
 	if err != nil {
-		log.Warningf("DeleteDecisionsWithFilter : %s", err)
+		c.logger().Warningf("DeleteDecisionsWithFilter : %s", err)
 		return "0", errors.Wrap(DeleteFail, "decisions with provided filter")
 	}
 	return strconv.Itoa(nbDeleted), nil
@@ -217,7 +712,8 @@ func (c *Client) DeleteDecisionsWithFilter(filter map[string][]string) (string,
 // SoftDeleteDecisionsWithFilter udpate the expiration time to now() for the decisions matching the filter
 func (c *Client) SoftDeleteDecisionsWithFilter(filter map[string][]string) (string, error) {
 	var err error
-	var startIP, endIP int64
+	var ipSize int
+	var startIP, startSuffix, endIP, endSuffix int64
 
 	decisions := c.Ent.Decision.Update().Where(decision.UntilGT(time.Now()))
 	for param, value := range filter {
@@ -229,43 +725,25 @@ func (c *Client) SoftDeleteDecisionsWithFilter(filter map[string][]string) (stri
 		case "type":
 			decisions = decisions.Where(decision.TypeEQ(value[0]))
 		case "ip":
-			isValidIP := IsIpv4(value[0])
-			if !isValidIP {
-				return "0", errors.Wrapf(InvalidIPOrRange, "unable to parse '%s': %s", value[0], err)
-			}
-			startIP, endIP, err = GetIpsFromIpRange(value[0] + "/32")
+			ipSize, startIP, startSuffix, endIP, endSuffix, err = getIpBoundsFromIp(value[0])
 			if err != nil {
 				return "0", errors.Wrapf(InvalidIPOrRange, "unable to convert '%s' to int interval: %s", value[0], err)
 			}
 		case "range":
-			startIP, endIP, err = GetIpsFromIpRange(value[0])
+			ipSize, startIP, startSuffix, endIP, endSuffix, err = GetIpBoundsFromIpRange(value[0])
 			if err != nil {
 				return "0", errors.Wrapf(InvalidIPOrRange, "unable to convert '%s' to int interval: %s", value[0], err)
 			}
 		default:
 			return "0", errors.Wrapf(InvalidFilter, "'%s' doesn't exist", param)
 		}
-
-		if startIP != 0 && endIP != 0 {
-			/*the user is checking for a single IP*/
-			if startIP == endIP {
-				//DECISION_START <= IP_Q >= DECISON_END
-				decisions = decisions.Where(decision.And(
-					decision.StartIPLTE(startIP),
-					decision.EndIPGTE(endIP),
-				))
-			} else { /*the user is checking for a RANGE */
-				//START_Q >= DECISION_START AND END_Q <= DECISION_END
-				decisions = decisions.Where(decision.And(
-					decision.StartIPGTE(startIP),
-					decision.EndIPLTE(endIP),
-				))
-			}
-		}
+	}
+	if ipFilter := decisionIPFilter(ipSize, startIP, startSuffix, endIP, endSuffix); ipFilter != nil {
+		decisions = decisions.Where(ipFilter)
 	}
 	nbDeleted, err := decisions.SetUntil(time.Now()).Save(c.CTX)
 	if err != nil {
-		log.Warningf("SoftDeleteDecisionsWithFilter : %s", err)
+		c.logger().Warningf("SoftDeleteDecisionsWithFilter : %s", err)
 		return "0", errors.Wrap(DeleteFail, "soft delete decisions with provided filter")
 	}
 	return strconv.Itoa(nbDeleted), nil
@@ -275,7 +753,7 @@ func (c *Client) SoftDeleteDecisionsWithFilter(filter map[string][]string) (stri
 func (c *Client) SoftDeleteDecisionByID(decisionID int) error {
 	nbUpdated, err := c.Ent.Decision.Update().Where(decision.IDEQ(decisionID)).SetUntil(time.Now()).Save(c.CTX)
 	if err != nil || nbUpdated == 0 {
-		log.Warningf("SoftDeleteDecisionByID : %v (nb soft deleted: %d)", err, nbUpdated)
+		c.logger().Warningf("SoftDeleteDecisionByID : %v (nb soft deleted: %d)", err, nbUpdated)
 		return errors.Wrapf(DeleteFail, "decision with id '%d' doesn't exist", decisionID)
 	}
 
@@ -284,3 +762,40 @@ func (c *Client) SoftDeleteDecisionByID(decisionID int) error {
 	}
 	return nil
 }
+
+// UpdateDecisionExpiry sets the expiration of the decision to newUntil, ie. to extend an active decision instead of creating a new one
+func (c *Client) UpdateDecisionExpiry(decisionID int, newUntil time.Time) error {
+	_, err := c.Ent.Decision.UpdateOneID(decisionID).SetUntil(newUntil.UTC()).Save(c.CTX)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return errors.Wrapf(ItemNotFound, "decision with id '%d' doesn't exist", decisionID)
+		}
+		c.logger().Warningf("UpdateDecisionExpiry : %s", err)
+		return errors.Wrapf(UpdateFail, "decision with id '%d'", decisionID)
+	}
+	return nil
+}
+
+// RefreshDecisionsExpiry sets the expiration of the active decisions from origin on any of values to newUntil, ie. to extend the bans of
+// a blocklist pushed again instead of creating new decisions, and returns how many were updated. The expired (or soft deleted) decisions
+// are left as they are, not to bring back old bans. The values are updated refreshBatchSize at a time to stay under the number of SQL variables SQLite allows.
+func (c *Client) RefreshDecisionsExpiry(origin string, values []string, newUntil time.Time) (int, error) {
+	nbUpdated := 0
+	now := time.Now()
+	for start := 0; start < len(values); start += refreshBatchSize {
+		end := start + refreshBatchSize
+		if end > len(values) {
+			end = len(values)
+		}
+		nb, err := c.Ent.Decision.Update().
+			Where(decision.OriginEQ(origin), decision.ValueIn(values[start:end]...), decision.UntilGT(now)).
+			SetUntil(newUntil.UTC()).
+			Save(c.CTX)
+		if err != nil {
+			c.logger().Warningf("RefreshDecisionsExpiry : %s", err)
+			return nbUpdated, wrapDBError(err, UpdateFail, "decisions from '%s': %s", origin, err)
+		}
+		nbUpdated += nb
+	}
+	return nbUpdated, nil
+}
@@ -0,0 +1,766 @@
+package database
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/decision"
+	"github.com/crowdsecurity/crowdsec/pkg/models"
+	"github.com/crowdsecurity/crowdsec/pkg/types"
+	"github.com/facebook/ent/dialect"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecisionDurationHistogram(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alertItem := newTestAlert("crowdsecurity/test", "1.2.3.4")
+	alertItem.Decisions = nil
+	for _, duration := range []string{"30m", "4h", "4h", "48h"} {
+		alertItem.Decisions = append(alertItem.Decisions, newTestDecision("1.2.3.4", types.Ip, "ban", duration))
+	}
+	/*neither the expired nor the simulated decisions are counted*/
+	expired := newTestAlert("crowdsecurity/test", "1.2.3.5")
+	simulated := newTestAlert("crowdsecurity/test", "1.2.3.6")
+	_, err := dbClient.CreateAlertBulk("test", []*models.Alert{alertItem, expired, simulated})
+	assert.NoError(t, err)
+	_, err = dbClient.Ent.Decision.Update().Where(decision.ValueEQ("1.2.3.5")).SetUntil(time.Now().Add(-time.Hour)).Save(dbClient.CTX)
+	assert.NoError(t, err)
+	_, err = dbClient.Ent.Decision.Update().Where(decision.ValueEQ("1.2.3.6")).SetSimulated(true).Save(dbClient.CTX)
+	assert.NoError(t, err)
+
+	/*the buckets don't need to be sorted*/
+	histogram, err := dbClient.DecisionDurationHistogram([]time.Duration{24 * time.Hour, time.Hour, 2 * time.Hour}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"<=1h0m0s": 1, "<=2h0m0s": 0, "<=24h0m0s": 2, ">24h0m0s": 1}, histogram)
+
+	histogram, err = dbClient.DecisionDurationHistogram([]time.Duration{24 * time.Hour, time.Hour, 2 * time.Hour}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"<=1h0m0s": 1, "<=2h0m0s": 0, "<=24h0m0s": 3, ">24h0m0s": 1}, histogram)
+
+	_, err = dbClient.DecisionDurationHistogram(nil, true)
+	assert.Equal(t, InvalidFilter, errors.Cause(err))
+}
+
+func TestGetDecisionIDsByFilter(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := newTestAlerts(3)
+	alerts[1].Decisions[0].Type = &[]string{"captcha"}[0]
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+	_, err = dbClient.Ent.Decision.Update().Where(decision.ValueEQ("1.2.3.2")).SetUntil(time.Now().Add(-time.Hour)).Save(dbClient.CTX)
+	assert.NoError(t, err)
+	ban, err := dbClient.Ent.Decision.Query().Where(decision.ValueEQ("1.2.3.0")).OnlyID(dbClient.CTX)
+	assert.NoError(t, err)
+	captcha, err := dbClient.Ent.Decision.Query().Where(decision.ValueEQ("1.2.3.1")).OnlyID(dbClient.CTX)
+	assert.NoError(t, err)
+
+	/*only the active decisions*/
+	ids, err := dbClient.GetDecisionIDsByFilter(map[string][]string{})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []int{ban, captcha}, ids)
+	ids, err = dbClient.GetDecisionIDsByFilter(map[string][]string{"type": {"ban"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{ban}, ids)
+	ids, err = dbClient.GetDecisionIDsByFilter(map[string][]string{"ip": {"1.2.3.2"}})
+	assert.NoError(t, err)
+	assert.Empty(t, ids)
+
+	_, err = dbClient.GetDecisionIDsByFilter(map[string][]string{"color": {"red"}})
+	assert.Equal(t, InvalidFilter, errors.Cause(err))
+}
+
+func TestExportActiveDecisionsCSV(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	/*spans over more than one page*/
+	alerts := newTestAlerts(paginationSize + 2)
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+	_, err = dbClient.Ent.Decision.Update().Where(decision.ValueEQ("1.2.3.0")).SetUntil(time.Now().Add(-time.Hour)).Save(dbClient.CTX)
+	assert.NoError(t, err)
+	nbSimulated, err := dbClient.Ent.Decision.Update().Where(decision.ValueEQ("1.2.3.1")).SetSimulated(true).Save(dbClient.CTX)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, nbSimulated)
+
+	buf := &bytes.Buffer{}
+	nbExported, err := dbClient.ExportActiveDecisionsCSV(buf, false)
+	assert.NoError(t, err)
+	assert.Equal(t, paginationSize, nbExported)
+	records, err := csv.NewReader(buf).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, records, paginationSize+1)
+	assert.Equal(t, []string{"value", "scope", "type", "origin", "until", "simulated"}, records[0])
+	assert.Equal(t, []string{"1.2.3.2", types.Ip, "ban", "crowdsec"}, records[1][:4])
+	_, err = time.Parse(time.RFC3339, records[1][4])
+	assert.NoError(t, err)
+	assert.Equal(t, "false", records[1][5])
+	assert.Equal(t, "1.2.3.101", records[paginationSize][0])
+
+	buf.Reset()
+	nbExported, err = dbClient.ExportActiveDecisionsCSV(buf, true)
+	assert.NoError(t, err)
+	assert.Equal(t, paginationSize+1, nbExported)
+	records, err = csv.NewReader(buf).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1.2.3.1", "true"}, []string{records[1][0], records[1][5]})
+}
+
+func TestQueryDecisionIncludeSimulated(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	_, err := dbClient.CreateAlertBulk("test", newTestAlerts(2))
+	assert.NoError(t, err)
+	_, err = dbClient.Ent.Decision.Update().Where(decision.ValueEQ("1.2.3.1")).SetSimulated(true).Save(dbClient.CTX)
+	assert.NoError(t, err)
+
+	/*bouncer-facing queries leave the simulated decisions out by default*/
+	decisions, err := dbClient.QueryDecisionWithFilter(map[string][]string{})
+	assert.NoError(t, err)
+	assert.Len(t, decisions, 1)
+	assert.Equal(t, "1.2.3.0", decisions[0].Value)
+
+	simulated := map[string]bool{}
+	filter := map[string][]string{"include_simulated": {"true"}}
+	decisions, err = dbClient.QueryDecisionWithFilter(filter)
+	assert.NoError(t, err)
+	/*the caller's filter is left untouched*/
+	assert.Equal(t, map[string][]string{"include_simulated": {"true"}}, filter)
+	for _, decisionItem := range decisions {
+		simulated[decisionItem.Value] = decisionItem.Simulated
+	}
+	assert.Equal(t, map[string]bool{"1.2.3.0": false, "1.2.3.1": true}, simulated)
+
+	decisions, err = dbClient.QueryDecisionWithFilter(map[string][]string{"include_simulated": {"false"}})
+	assert.NoError(t, err)
+	assert.Len(t, decisions, 1)
+}
+
+func TestCountExpiredDecisions(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := newTestAlerts(4)
+	for _, alertItem := range alerts[:3] {
+		alertItem.Decisions[0].Until = time.Now().UTC().Add(-time.Hour).Format(time.RFC3339)
+	}
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+
+	count, err := dbClient.CountExpiredDecisions()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+	/*counting doesn't delete anything, and matches what a purge removes*/
+	nbDeleted, err := dbClient.Ent.Decision.Delete().Where(decision.UntilLT(time.Now())).Exec(dbClient.CTX)
+	assert.NoError(t, err)
+	assert.Equal(t, count, nbDeleted)
+
+	count, err = dbClient.CountExpiredDecisions()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestDecisionOverlapByOrigin(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := make([]*models.Alert, 0)
+	for _, item := range []struct {
+		value     string
+		origin    string
+		expired   bool
+		simulated bool
+	}{
+		{value: "1.2.3.0", origin: "crowdsec"},
+		{value: "1.2.3.0", origin: "cscli"},
+		{value: "1.2.3.0", origin: "lists"},
+		{value: "1.2.3.1", origin: "lists"},
+		{value: "1.2.3.1", origin: "cscli"},
+		/*the same origin twice isn't an overlap*/
+		{value: "1.2.3.1", origin: "cscli"},
+		{value: "1.2.3.2", origin: "crowdsec"},
+		{value: "1.2.3.2", origin: "crowdsec"},
+		/*neither are expired decisions*/
+		{value: "1.2.3.3", origin: "crowdsec"},
+		{value: "1.2.3.3", origin: "lists", expired: true},
+		/*simulated decisions overlap only if they're included*/
+		{value: "1.2.3.4", origin: "crowdsec"},
+		{value: "1.2.3.4", origin: "lists", simulated: true},
+	} {
+		alertItem := newTestAlert("crowdsecurity/test", item.value)
+		origin := item.origin
+		alertItem.Decisions[0].Origin = &origin
+		if item.expired {
+			alertItem.Decisions[0].Until = time.Now().UTC().Add(-time.Hour).Format(time.RFC3339)
+		}
+		simulated := item.simulated
+		alertItem.Simulated = &simulated
+		alerts = append(alerts, alertItem)
+	}
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+
+	overlap, err := dbClient.DecisionOverlapByOrigin(false)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{
+		"crowdsec/cscli": 1,
+		"crowdsec/lists": 1,
+		"cscli/lists":    2,
+	}, overlap)
+
+	overlap, err = dbClient.DecisionOverlapByOrigin(true)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{
+		"crowdsec/cscli": 1,
+		"crowdsec/lists": 2,
+		"cscli/lists":    2,
+	}, overlap)
+}
+
+func TestHasActiveDecisionForValue(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := newTestAlerts(3)
+	alerts[1].Decisions[0].Until = time.Now().UTC().Add(-time.Hour).Format(time.RFC3339)
+	/*a range decision doesn't count : the value has to match exactly*/
+	alerts[2].Decisions[0].Value = &[]string{"1.2.4.0/24"}[0]
+	alerts[2].Decisions[0].Scope = &[]string{types.Range}[0]
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+	_, err = dbClient.CreateAlertBulk("test", []*models.Alert{newTestAlert("crowdsecurity/test", "1.2.3.9")})
+	assert.NoError(t, err)
+	_, err = dbClient.Ent.Decision.Update().Where(decision.ValueEQ("1.2.3.9")).SetSimulated(true).Save(dbClient.CTX)
+	assert.NoError(t, err)
+
+	for value, expected := range map[string]bool{
+		"1.2.3.0":    true,
+		"1.2.3.1":    false,
+		"1.2.4.1":    false,
+		"1.2.4.0/24": true,
+		"1.2.3.9":    false,
+		"5.6.7.8":    false,
+	} {
+		exist, err := dbClient.HasActiveDecisionForValue(value)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, exist, value)
+	}
+}
+
+func TestFindConflictingDecisions(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := newTestAlerts(2)
+	/*1.2.3.0 is both banned and whitelisted, 1.2.3.1 is only banned*/
+	alerts[0].Decisions = append(alerts[0].Decisions, newTestDecision("1.2.3.0", "Ip", "whitelist", "4h"))
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+
+	conflicts, err := dbClient.FindConflictingDecisions()
+	assert.NoError(t, err)
+	if assert.Len(t, conflicts, 2) {
+		assert.Equal(t, "1.2.3.0", conflicts[0].Value)
+		assert.Equal(t, "ban", conflicts[0].Type)
+		assert.Equal(t, "1.2.3.0", conflicts[1].Value)
+		assert.Equal(t, "whitelist", conflicts[1].Type)
+	}
+}
+
+func TestQueryDecisionWithAlert(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	_, err := dbClient.CreateAlertBulk("test", newTestAlerts(1))
+	assert.NoError(t, err)
+
+	decisions, err := dbClient.QueryDecisionWithFilter(map[string][]string{"with_alert": {"true"}})
+	assert.NoError(t, err)
+	if assert.Len(t, decisions, 1) && assert.NotNil(t, decisions[0].Edges.Owner) {
+		assert.Equal(t, "test alert", decisions[0].Edges.Owner.Message)
+	}
+
+	decisions, err = dbClient.QueryDecisionWithFilter(map[string][]string{})
+	assert.NoError(t, err)
+	if assert.Len(t, decisions, 1) {
+		assert.Nil(t, decisions[0].Edges.Owner)
+	}
+}
+
+func TestLastDecisionPerScenario(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := newTestAlerts(3)
+	other := "crowdsecurity/other"
+	alerts[2].Decisions[0].Scenario = &other
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+	/*the decision with the highest id of the scenario isn't its most recent one (ie. imported afterwards)*/
+	imported, err := dbClient.Ent.Decision.Query().Where(decision.ScenarioEQ("crowdsecurity/test")).Order(ent.Desc(decision.FieldID)).First(dbClient.CTX)
+	assert.NoError(t, err)
+	_, err = imported.Update().SetCreatedAt(time.Now().UTC().Add(-24 * time.Hour).Truncate(time.Second)).Save(dbClient.CTX)
+	assert.NoError(t, err)
+
+	decisions, err := dbClient.QueryAllDecisions()
+	assert.NoError(t, err)
+	expected := map[string]time.Time{}
+	for _, decisionItem := range decisions {
+		if last, ok := expected[decisionItem.Scenario]; !ok || decisionItem.CreatedAt.After(last) {
+			expected[decisionItem.Scenario] = decisionItem.CreatedAt
+		}
+	}
+
+	lastDecisions, err := dbClient.LastDecisionPerScenario(true)
+	assert.NoError(t, err)
+	assert.Len(t, lastDecisions, 2)
+	for scenario, createdAt := range expected {
+		assert.True(t, createdAt.Equal(lastDecisions[scenario]), "scenario %s", scenario)
+	}
+}
+
+func TestLongestActiveDecisionPerValue(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := newTestAlerts(3)
+	alerts[0].Decisions = append(alerts[0].Decisions, newTestDecision("1.2.3.0", "Ip", "ban", "48h"))
+	alerts[1].Decisions[0].Duration = new(string)
+	*alerts[1].Decisions[0].Duration = "24h"
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+
+	longest, err := dbClient.LongestActiveDecisionPerValue(0, false)
+	assert.NoError(t, err)
+	assert.Len(t, longest, 3)
+	expected := []struct {
+		value    string
+		duration time.Duration
+	}{{"1.2.3.0", 48 * time.Hour}, {"1.2.3.1", 24 * time.Hour}, {"1.2.3.2", 4 * time.Hour}}
+	for i, decisionItem := range longest {
+		assert.Equal(t, expected[i].value, decisionItem.Value)
+		assert.WithinDuration(t, time.Now().Add(expected[i].duration), decisionItem.Until, time.Minute)
+	}
+
+	/*the values are grouped and limited by the database, then only their decisions are fetched*/
+	queries := []string{}
+	dbClient.Ent = ent.NewClient(ent.Driver(dialect.Debug(dbClient.drv, func(args ...interface{}) {
+		queries = append(queries, fmt.Sprint(args...))
+	})))
+	longest, err = dbClient.LongestActiveDecisionPerValue(1, false)
+	assert.NoError(t, err)
+	if assert.Len(t, longest, 1) {
+		assert.Equal(t, "1.2.3.0", longest[0].Value)
+		assert.WithinDuration(t, time.Now().Add(48*time.Hour), longest[0].Until, time.Minute)
+	}
+	if assert.Len(t, queries, 2) {
+		assert.Contains(t, queries[0], "GROUP BY `value`")
+		assert.Contains(t, queries[0], "LIMIT 1")
+	}
+
+	longest, err = dbClient.LongestActiveDecisionPerValue(0, false)
+	assert.NoError(t, err)
+	assert.Len(t, longest, 3)
+	dbClient.Ent = ent.NewClient(ent.Driver(dbClient.drv))
+
+	/*a simulated decision is the longest of its value only if they're included*/
+	_, err = dbClient.Ent.Decision.Update().Where(decision.ValueEQ("1.2.3.2")).SetSimulated(true).Save(dbClient.CTX)
+	assert.NoError(t, err)
+	longest, err = dbClient.LongestActiveDecisionPerValue(0, false)
+	assert.NoError(t, err)
+	assert.Len(t, longest, 2)
+	longest, err = dbClient.LongestActiveDecisionPerValue(0, true)
+	assert.NoError(t, err)
+	if assert.Len(t, longest, 3) {
+		assert.Equal(t, "1.2.3.2", longest[2].Value)
+		assert.True(t, longest[2].Simulated)
+	}
+	dbClient.Ent = ent.NewClient(ent.Driver(dbClient.drv))
+	_, err = dbClient.Ent.Decision.Delete().Exec(dbClient.CTX)
+	assert.NoError(t, err)
+	longest, err = dbClient.LongestActiveDecisionPerValue(0, false)
+	assert.NoError(t, err)
+	assert.Empty(t, longest)
+}
+
+func TestGetActiveDecisionsByIP(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := newTestAlerts(3)
+	rangeDecision := newTestDecision("1.2.4.0/24", types.Range, "ban", "4h")
+	rangeDecision.StartIP, rangeDecision.EndIP, _ = GetIpsFromIpRange("1.2.4.0/24")
+	alerts[0].Decisions = append(alerts[0].Decisions, rangeDecision)
+	alerts[1].Decisions[0].Until = time.Now().UTC().Add(-time.Hour).Format(time.RFC3339)
+	alerts[2].Decisions[0] = newTestDecision("2001:db8::/64", types.Range, "captcha", "4h")
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+
+	decisions, err := dbClient.GetActiveDecisionsByIP("1.2.3.0", false)
+	assert.NoError(t, err)
+	if assert.Len(t, decisions, 1) {
+		assert.Equal(t, "1.2.3.0", decisions[0].Value)
+	}
+
+	decisions, err = dbClient.GetActiveDecisionsByIP("1.2.4.42", false)
+	assert.NoError(t, err)
+	if assert.Len(t, decisions, 1) {
+		assert.Equal(t, "1.2.4.0/24", decisions[0].Value)
+	}
+
+	decisions, err = dbClient.GetActiveDecisionsByIP("2001:db8::42", false)
+	assert.NoError(t, err)
+	if assert.Len(t, decisions, 1) {
+		assert.Equal(t, "captcha", decisions[0].Type)
+	}
+
+	/*the decision on 1.2.3.1 is expired*/
+	for _, ip := range []string{"1.2.3.1", "1.2.5.1", "2001:db9::1"} {
+		decisions, err = dbClient.GetActiveDecisionsByIP(ip, false)
+		assert.NoError(t, err)
+		assert.Empty(t, decisions, ip)
+	}
+
+	_, err = dbClient.GetActiveDecisionsByIP("1.2.3", false)
+	assert.Equal(t, InvalidIPOrRange, errors.Cause(err))
+}
+
+func TestGetDecisionsInRange(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	newDecision := func(value string, scope string) *models.Decision {
+		decisionItem := newTestDecision(value, scope, "ban", "4h")
+		cidr := value
+		if scope == types.Ip {
+			cidr += "/32"
+		}
+		decisionItem.StartIP, decisionItem.EndIP, _ = GetIpsFromIpRange(cidr)
+		return decisionItem
+	}
+	alertItem := newTestAlert("crowdsecurity/test", "10.0.0.5")
+	expired := newDecision("10.0.0.6", types.Ip)
+	expired.Until = time.Now().UTC().Add(-time.Hour).Format(time.RFC3339)
+	alertItem.Decisions = []*models.Decision{
+		/*inside the query range*/
+		newDecision("10.0.0.5", types.Ip),
+		newDecision("10.0.0.128/25", types.Range),
+		/*overlapping it : the range covers it entirely*/
+		newDecision("10.0.0.0/16", types.Range),
+		/*outside*/
+		newDecision("10.0.1.0/24", types.Range),
+		newDecision("10.0.1.1", types.Ip),
+		newTestDecision("root", "username", "ban", "4h"),
+		expired,
+		newTestDecision("2001:db8::/48", types.Range, "ban", "4h"),
+	}
+	_, err := dbClient.CreateAlertBulk("test", []*models.Alert{alertItem})
+	assert.NoError(t, err)
+
+	values := func(cidr string) []string {
+		decisions, err := dbClient.GetDecisionsInRange(cidr)
+		assert.NoError(t, err)
+		ret := make([]string, 0, len(decisions))
+		for _, decisionItem := range decisions {
+			ret = append(ret, decisionItem.Value)
+		}
+		return ret
+	}
+
+	assert.Equal(t, []string{"10.0.0.5", "10.0.0.128/25", "10.0.0.0/16"}, values("10.0.0.0/24"))
+	/*a query range inside a decision range still finds it*/
+	assert.Equal(t, []string{"10.0.0.128/25", "10.0.0.0/16"}, values("10.0.0.200/30"))
+	assert.Empty(t, values("10.1.0.0/16"))
+	assert.Equal(t, []string{"2001:db8::/48"}, values("2001:db8::/64"))
+	assert.Empty(t, values("2001:db9::/64"))
+
+	_, err = dbClient.GetDecisionsInRange("10.0.0.0/33")
+	assert.Equal(t, InvalidIPOrRange, errors.Cause(err))
+}
+
+func TestDecisionFiltersAddressFamily(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	newAlert := func() *models.Alert {
+		alertItem := newTestAlert("crowdsecurity/test", "1.2.3.4")
+		v4Range := newTestDecision("1.2.3.0/24", types.Range, "ban", "4h")
+		v4Range.StartIP, v4Range.EndIP, _ = GetIpsFromIpRange("1.2.3.0/24")
+		/*the halves of these IPv6 bounds fall within the IPv4 ones compared by the filters*/
+		alertItem.Decisions = append(alertItem.Decisions, v4Range,
+			newTestDecision("2001:db8::/32", types.Range, "ban", "4h"),
+			newTestDecision("::/1", types.Range, "ban", "4h"),
+			newTestDecision("2001:db8::1", types.Ip, "ban", "4h"),
+		)
+		return alertItem
+	}
+	_, err := dbClient.CreateAlertBulk("test", []*models.Alert{newAlert()})
+	assert.NoError(t, err)
+
+	values := func(filter map[string][]string) []string {
+		decisions, err := dbClient.QueryDecisionWithFilter(filter)
+		assert.NoError(t, err)
+		ret := make([]string, 0, len(decisions))
+		for _, decisionItem := range decisions {
+			ret = append(ret, decisionItem.Value)
+		}
+		return ret
+	}
+	assert.ElementsMatch(t, []string{"1.2.3.4", "1.2.3.0/24"}, values(map[string][]string{"range": {"0.0.0.0/0"}}))
+	assert.ElementsMatch(t, []string{"1.2.3.4", "1.2.3.0/24"}, values(map[string][]string{"range": {"1.0.0.0/8"}}))
+	assert.ElementsMatch(t, []string{"1.2.3.4", "1.2.3.0/24"}, values(map[string][]string{"ip": {"1.2.3.4"}}))
+	assert.ElementsMatch(t, []string{"2001:db8::/32", "2001:db8::1"}, values(map[string][]string{"range": {"2001:db8::/16"}}))
+	assert.ElementsMatch(t, []string{"2001:db8::/32", "::/1", "2001:db8::1"}, values(map[string][]string{"ip": {"2001:db8::1"}}))
+
+	/*deleting the IPv4 decisions leaves the IPv6 ones alone*/
+	nbDeleted, err := dbClient.SoftDeleteDecisionsWithFilter(map[string][]string{"range": {"0.0.0.0/0"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "2", nbDeleted)
+	assert.ElementsMatch(t, []string{"2001:db8::/32", "::/1", "2001:db8::1"}, values(map[string][]string{}))
+
+	nbDeleted, err = dbClient.DeleteDecisionsWithFilter(map[string][]string{"ip": {"2001:db8::1"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "3", nbDeleted)
+	_, err = dbClient.CreateAlertBulk("test", []*models.Alert{newAlert()})
+	assert.NoError(t, err)
+	/*the expired IPv4 decisions are deleted along with the active ones*/
+	nbDeleted, err = dbClient.DeleteDecisionsWithFilter(map[string][]string{"range": {"0.0.0.0/0"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "4", nbDeleted)
+	assert.ElementsMatch(t, []string{"2001:db8::/32", "::/1", "2001:db8::1"}, values(map[string][]string{}))
+}
+
+func TestUpdateDecisionExpiry(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	_, err := dbClient.CreateAlertBulk("test", newTestAlerts(1))
+	assert.NoError(t, err)
+	decisionItem, err := dbClient.Ent.Decision.Query().Only(dbClient.CTX)
+	assert.NoError(t, err)
+
+	newUntil := decisionItem.Until.Add(time.Hour)
+	assert.NoError(t, dbClient.UpdateDecisionExpiry(decisionItem.ID, newUntil))
+	updated, err := dbClient.Ent.Decision.Get(dbClient.CTX, decisionItem.ID)
+	assert.NoError(t, err)
+	assert.True(t, newUntil.Equal(updated.Until), "%s != %s", newUntil, updated.Until)
+
+	err = dbClient.UpdateDecisionExpiry(decisionItem.ID+1, newUntil)
+	assert.Equal(t, ItemNotFound, errors.Cause(err))
+}
+
+func TestGetDecisionByID(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	ids, err := dbClient.CreateAlertBulk("test", newTestAlerts(1))
+	assert.NoError(t, err)
+	decisionItem, err := dbClient.Ent.Decision.Query().Only(dbClient.CTX)
+	assert.NoError(t, err)
+
+	found, err := dbClient.GetDecisionByID(decisionItem.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.3.0", found.Value)
+	if assert.NotNil(t, found.Edges.Owner) {
+		assert.Equal(t, ids[0], strconv.Itoa(found.Edges.Owner.ID))
+	}
+
+	_, err = dbClient.GetDecisionByID(decisionItem.ID + 1)
+	assert.Equal(t, ItemNotFound, errors.Cause(err))
+}
+
+func TestRefreshDecisionsExpiry(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	/*a blocklist of 100 ips, and a local ban on one of them that must be left alone*/
+	alertItem := newTestAlert("crowdsecurity/community-blocklist", "1.2.3.4")
+	alertItem.Decisions = nil
+	values := make([]string, 0, 100)
+	for i := 0; i < 100; i++ {
+		value := Int2ip(uint32(0x0a000000 + i)).String()
+		values = append(values, value)
+		decisionItem := newTestDecision(value, types.Ip, "ban", "1h")
+		origin := "lists"
+		decisionItem.Origin = &origin
+		alertItem.Decisions = append(alertItem.Decisions, decisionItem)
+	}
+	localAlert := newTestAlert("crowdsecurity/ssh-bf", values[0])
+	/*an expired ban from a previous push of the list, that must stay expired*/
+	oldAlert := newTestAlert("crowdsecurity/community-blocklist", "1.2.3.4")
+	oldDecision := newTestDecision(values[1], types.Ip, "ban", "1h")
+	oldOrigin := "lists"
+	oldDecision.Origin = &oldOrigin
+	oldDecision.Until = time.Now().UTC().Add(-time.Hour).Format(time.RFC3339)
+	oldAlert.Decisions = []*models.Decision{oldDecision}
+	_, err := dbClient.CreateAlertBulk("test", []*models.Alert{alertItem, localAlert, oldAlert})
+	assert.NoError(t, err)
+	/*and a soft deleted one*/
+	deleted, err := dbClient.Ent.Decision.Query().Where(decision.OriginEQ("lists"), decision.ValueEQ(values[2])).Only(dbClient.CTX)
+	assert.NoError(t, err)
+	assert.NoError(t, dbClient.SoftDeleteDecisionByID(deleted.ID))
+
+	newUntil := time.Now().UTC().Add(24 * time.Hour).Truncate(time.Second)
+	nbUpdated, err := dbClient.RefreshDecisionsExpiry("lists", append(values, "192.168.0.1"), newUntil)
+	assert.NoError(t, err)
+	assert.Equal(t, 99, nbUpdated)
+
+	refreshed, err := dbClient.Ent.Decision.Query().Where(decision.OriginEQ("lists"), decision.IDNEQ(deleted.ID)).All(dbClient.CTX)
+	assert.NoError(t, err)
+	assert.Len(t, refreshed, 100)
+	nbExpired := 0
+	for _, decisionItem := range refreshed {
+		if decisionItem.Until.Before(time.Now()) {
+			nbExpired++
+			continue
+		}
+		assert.True(t, newUntil.Equal(decisionItem.Until), "%s != %s", newUntil, decisionItem.Until)
+	}
+	assert.Equal(t, 1, nbExpired)
+	deleted, err = dbClient.Ent.Decision.Get(dbClient.CTX, deleted.ID)
+	assert.NoError(t, err)
+	assert.True(t, deleted.Until.Before(time.Now()))
+	local, err := dbClient.Ent.Decision.Query().Where(decision.OriginNEQ("lists")).Only(dbClient.CTX)
+	assert.NoError(t, err)
+	assert.True(t, local.Until.Before(newUntil))
+
+	nbUpdated, err = dbClient.RefreshDecisionsExpiry("lists", nil, newUntil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, nbUpdated)
+}
+
+func TestDeleteDecisionsByValue(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := newTestAlerts(2)
+	alerts[0].Decisions = append(alerts[0].Decisions, newTestDecision("1.2.3.0", types.Ip, "captcha", "4h"))
+	alerts[1].Decisions[0] = newTestDecision("1.2.3.0/24", types.Range, "ban", "4h")
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+
+	/*the scope is normalized, and the range including the ip is kept*/
+	nbDeleted, err := dbClient.DeleteDecisionsByValue("1.2.3.0", "ip")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, nbDeleted)
+	remaining, err := dbClient.Ent.Decision.Query().All(dbClient.CTX)
+	assert.NoError(t, err)
+	if assert.Len(t, remaining, 1) {
+		assert.Equal(t, "1.2.3.0/24", remaining[0].Value)
+	}
+	total, err := dbClient.TotalAlerts()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, total)
+
+	nbDeleted, err = dbClient.DeleteDecisionsByValue("1.2.3.0", types.Ip)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, nbDeleted)
+}
+
+func TestFlushExpiredDecisions(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := newTestAlerts(3)
+	for _, alertItem := range alerts[:2] {
+		alertItem.Decisions[0].Until = time.Now().UTC().Add(-time.Hour).Format(time.RFC3339)
+	}
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+
+	nbDeleted, err := dbClient.FlushExpiredDecisions()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, nbDeleted)
+
+	remaining, err := dbClient.Ent.Decision.Query().All(dbClient.CTX)
+	assert.NoError(t, err)
+	if assert.Len(t, remaining, 1) {
+		assert.Equal(t, "1.2.3.2", remaining[0].Value)
+	}
+	/*the alerts and their events are kept*/
+	total, err := dbClient.TotalAlerts()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, total)
+	nbEvents, err := dbClient.Ent.Event.Query().Count(dbClient.CTX)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, nbEvents)
+
+	nbDeleted, err = dbClient.FlushExpiredDecisions()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, nbDeleted)
+}
+
+func TestCountActiveDecisions(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	alerts := newTestAlerts(5)
+	/*1.2.3.0 is expired, 1.2.3.1 is simulated, and 1.2.3.2 also has a range decision*/
+	alerts[0].Decisions[0].Until = time.Now().UTC().Add(-time.Hour).Format(time.RFC3339)
+	simulated := true
+	alerts[1].Simulated = &simulated
+	alerts[2].Decisions = append(alerts[2].Decisions, newTestDecision("1.2.3.0/24", types.Range, "ban", "4h"))
+	_, err := dbClient.CreateAlertBulk("test", alerts)
+	assert.NoError(t, err)
+
+	count, err := dbClient.CountActiveDecisions(false)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, count)
+	count, err = dbClient.CountActiveDecisions(true)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, count)
+
+	byScope, err := dbClient.CountActiveDecisionsByScope(false)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{types.Ip: 3, types.Range: 1}, byScope)
+	byScope, err = dbClient.CountActiveDecisionsByScope(true)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{types.Ip: 4, types.Range: 1}, byScope)
+}
+
+func TestGetDecisionsSince(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	_, err := dbClient.CreateAlertBulk("test", newTestAlerts(4))
+	assert.NoError(t, err)
+	/*the decisions on 1.2.3.0, 1.2.3.1 and 1.2.3.3 were pulled 2 hours ago, 1.2.3.3 expired half an hour ago*/
+	twoHoursAgo := time.Now().Add(-2 * time.Hour)
+	_, err = dbClient.Ent.Decision.Update().
+		Where(decision.ValueIn("1.2.3.0", "1.2.3.1", "1.2.3.3")).
+		SetCreatedAt(twoHoursAgo).
+		SetUpdatedAt(twoHoursAgo).
+		Save(dbClient.CTX)
+	assert.NoError(t, err)
+	_, err = dbClient.Ent.Decision.Update().
+		Where(decision.ValueEQ("1.2.3.3")).
+		SetUntil(time.Now().Add(-30 * time.Minute)).
+		SetUpdatedAt(twoHoursAgo).
+		Save(dbClient.CTX)
+	assert.NoError(t, err)
+
+	/*1.2.3.1 is unbanned after the last pull*/
+	lastPull := time.Now().Add(-time.Hour)
+	decisionItem, err := dbClient.Ent.Decision.Query().Where(decision.ValueEQ("1.2.3.1")).Only(dbClient.CTX)
+	assert.NoError(t, err)
+	assert.NoError(t, dbClient.SoftDeleteDecisionByID(decisionItem.ID))
+
+	changed, err := dbClient.GetDecisionsSince(lastPull)
+	assert.NoError(t, err)
+	expired := map[string]bool{}
+	for _, decisionItem := range changed {
+		expired[decisionItem.Value] = !decisionItem.Until.After(time.Now())
+	}
+	assert.Equal(t, map[string]bool{"1.2.3.1": true, "1.2.3.2": false, "1.2.3.3": true}, expired)
+}
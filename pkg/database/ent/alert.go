@@ -61,6 +61,20 @@ type Alert struct {
 	ScenarioHash string `json:"scenarioHash,omitempty"`
 	// Simulated holds the value of the "simulated" field.
 	Simulated bool `json:"simulated,omitempty"`
+	// Tags holds the value of the "tags" field.
+	Tags string `json:"tags,omitempty"`
+	// BatchId holds the value of the "batchId" field.
+	BatchId string `json:"batchId,omitempty"`
+	// Acknowledged holds the value of the "acknowledged" field.
+	Acknowledged bool `json:"acknowledged,omitempty"`
+	// AcknowledgedAt holds the value of the "acknowledgedAt" field.
+	AcknowledgedAt time.Time `json:"acknowledgedAt,omitempty"`
+	// AcknowledgedBy holds the value of the "acknowledgedBy" field.
+	AcknowledgedBy string `json:"acknowledgedBy,omitempty"`
+	// Archived holds the value of the "archived" field.
+	Archived bool `json:"archived,omitempty"`
+	// ArchivedAt holds the value of the "archivedAt" field.
+	ArchivedAt time.Time `json:"archivedAt,omitempty"`
 	// Edges holds the relations/edges for other nodes in the graph.
 	// The values are being populated by the AlertQuery when eager-loading is set.
 	Edges          AlertEdges `json:"edges"`
@@ -149,6 +163,13 @@ func (*Alert) scanValues() []interface{} {
 		&sql.NullString{},  // scenarioVersion
 		&sql.NullString{},  // scenarioHash
 		&sql.NullBool{},    // simulated
+		&sql.NullString{},  // tags
+		&sql.NullString{},  // batchId
+		&sql.NullBool{},    // acknowledged
+		&sql.NullTime{},    // acknowledgedAt
+		&sql.NullString{},  // acknowledgedBy
+		&sql.NullBool{},    // archived
+		&sql.NullTime{},    // archivedAt
 	}
 }
 
@@ -281,7 +302,42 @@ func (a *Alert) assignValues(values ...interface{}) error {
 	} else if value.Valid {
 		a.Simulated = value.Bool
 	}
-	values = values[22:]
+	if value, ok := values[22].(*sql.NullString); !ok {
+		return fmt.Errorf("unexpected type %T for field tags", values[22])
+	} else if value.Valid {
+		a.Tags = value.String
+	}
+	if value, ok := values[23].(*sql.NullString); !ok {
+		return fmt.Errorf("unexpected type %T for field batchId", values[23])
+	} else if value.Valid {
+		a.BatchId = value.String
+	}
+	if value, ok := values[24].(*sql.NullBool); !ok {
+		return fmt.Errorf("unexpected type %T for field acknowledged", values[24])
+	} else if value.Valid {
+		a.Acknowledged = value.Bool
+	}
+	if value, ok := values[25].(*sql.NullTime); !ok {
+		return fmt.Errorf("unexpected type %T for field acknowledgedAt", values[25])
+	} else if value.Valid {
+		a.AcknowledgedAt = value.Time
+	}
+	if value, ok := values[26].(*sql.NullString); !ok {
+		return fmt.Errorf("unexpected type %T for field acknowledgedBy", values[26])
+	} else if value.Valid {
+		a.AcknowledgedBy = value.String
+	}
+	if value, ok := values[27].(*sql.NullBool); !ok {
+		return fmt.Errorf("unexpected type %T for field archived", values[27])
+	} else if value.Valid {
+		a.Archived = value.Bool
+	}
+	if value, ok := values[28].(*sql.NullTime); !ok {
+		return fmt.Errorf("unexpected type %T for field archivedAt", values[28])
+	} else if value.Valid {
+		a.ArchivedAt = value.Time
+	}
+	values = values[29:]
 	if len(values) == len(alert.ForeignKeys) {
 		if value, ok := values[0].(*sql.NullInt64); !ok {
 			return fmt.Errorf("unexpected type %T for edge-field machine_alerts", value)
@@ -380,6 +436,20 @@ func (a *Alert) String() string {
 	builder.WriteString(a.ScenarioHash)
 	builder.WriteString(", simulated=")
 	builder.WriteString(fmt.Sprintf("%v", a.Simulated))
+	builder.WriteString(", tags=")
+	builder.WriteString(a.Tags)
+	builder.WriteString(", batchId=")
+	builder.WriteString(a.BatchId)
+	builder.WriteString(", acknowledged=")
+	builder.WriteString(fmt.Sprintf("%v", a.Acknowledged))
+	builder.WriteString(", acknowledgedAt=")
+	builder.WriteString(a.AcknowledgedAt.Format(time.ANSIC))
+	builder.WriteString(", acknowledgedBy=")
+	builder.WriteString(a.AcknowledgedBy)
+	builder.WriteString(", archived=")
+	builder.WriteString(fmt.Sprintf("%v", a.Archived))
+	builder.WriteString(", archivedAt=")
+	builder.WriteString(a.ArchivedAt.Format(time.ANSIC))
 	builder.WriteByte(')')
 	return builder.String()
 }
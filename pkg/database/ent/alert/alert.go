@@ -55,6 +55,20 @@ const (
 	FieldScenarioHash = "scenario_hash"
 	// FieldSimulated holds the string denoting the simulated field in the database.
 	FieldSimulated = "simulated"
+	// FieldTags holds the string denoting the tags field in the database.
+	FieldTags = "tags"
+	// FieldBatchId holds the string denoting the batchid field in the database.
+	FieldBatchId = "batch_id"
+	// FieldAcknowledged holds the string denoting the acknowledged field in the database.
+	FieldAcknowledged = "acknowledged"
+	// FieldAcknowledgedAt holds the string denoting the acknowledgedat field in the database.
+	FieldAcknowledgedAt = "acknowledged_at"
+	// FieldAcknowledgedBy holds the string denoting the acknowledgedby field in the database.
+	FieldAcknowledgedBy = "acknowledged_by"
+	// FieldArchived holds the string denoting the archived field in the database.
+	FieldArchived = "archived"
+	// FieldArchivedAt holds the string denoting the archivedat field in the database.
+	FieldArchivedAt = "archived_at"
 
 	// EdgeOwner holds the string denoting the owner edge name in mutations.
 	EdgeOwner = "owner"
@@ -122,6 +136,13 @@ var Columns = []string{
 	FieldScenarioVersion,
 	FieldScenarioHash,
 	FieldSimulated,
+	FieldTags,
+	FieldBatchId,
+	FieldAcknowledged,
+	FieldAcknowledgedAt,
+	FieldAcknowledgedBy,
+	FieldArchived,
+	FieldArchivedAt,
 }
 
 // ForeignKeys holds the SQL foreign-keys that are owned by the Alert type.
@@ -161,4 +182,8 @@ var (
 	DefaultStoppedAt func() time.Time
 	// DefaultSimulated holds the default value on creation for the simulated field.
 	DefaultSimulated bool
+	// DefaultAcknowledged holds the default value on creation for the acknowledged field.
+	DefaultAcknowledged bool
+	// DefaultArchived holds the default value on creation for the archived field.
+	DefaultArchived bool
 )
@@ -247,6 +247,55 @@ func Simulated(v bool) predicate.Alert {
 	})
 }
 
+// Tags applies equality check predicate on the "tags" field. It's identical to TagsEQ.
+func Tags(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.EQ(s.C(FieldTags), v))
+	})
+}
+
+// BatchId applies equality check predicate on the "batchId" field. It's identical to BatchIdEQ.
+func BatchId(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.EQ(s.C(FieldBatchId), v))
+	})
+}
+
+// Acknowledged applies equality check predicate on the "acknowledged" field. It's identical to AcknowledgedEQ.
+func Acknowledged(v bool) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.EQ(s.C(FieldAcknowledged), v))
+	})
+}
+
+// AcknowledgedAt applies equality check predicate on the "acknowledgedAt" field. It's identical to AcknowledgedAtEQ.
+func AcknowledgedAt(v time.Time) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.EQ(s.C(FieldAcknowledgedAt), v))
+	})
+}
+
+// AcknowledgedBy applies equality check predicate on the "acknowledgedBy" field. It's identical to AcknowledgedByEQ.
+func AcknowledgedBy(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.EQ(s.C(FieldAcknowledgedBy), v))
+	})
+}
+
+// Archived applies equality check predicate on the "archived" field. It's identical to ArchivedEQ.
+func Archived(v bool) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.EQ(s.C(FieldArchived), v))
+	})
+}
+
+// ArchivedAt applies equality check predicate on the "archivedAt" field. It's identical to ArchivedAtEQ.
+func ArchivedAt(v time.Time) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.EQ(s.C(FieldArchivedAt), v))
+	})
+}
+
 // CreatedAtEQ applies the EQ predicate on the "created_at" field.
 func CreatedAtEQ(v time.Time) predicate.Alert {
 	return predicate.Alert(func(s *sql.Selector) {
@@ -2564,6 +2613,617 @@ func SimulatedNEQ(v bool) predicate.Alert {
 	})
 }
 
+// TagsEQ applies the EQ predicate on the "tags" field.
+func TagsEQ(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.EQ(s.C(FieldTags), v))
+	})
+}
+
+// TagsNEQ applies the NEQ predicate on the "tags" field.
+func TagsNEQ(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.NEQ(s.C(FieldTags), v))
+	})
+}
+
+// TagsIn applies the In predicate on the "tags" field.
+func TagsIn(vs ...string) predicate.Alert {
+	v := make([]interface{}, len(vs))
+	for i := range v {
+		v[i] = vs[i]
+	}
+	return predicate.Alert(func(s *sql.Selector) {
+		// if not arguments were provided, append the FALSE constants,
+		// since we can't apply "IN ()". This will make this predicate falsy.
+		if len(v) == 0 {
+			s.Where(sql.False())
+			return
+		}
+		s.Where(sql.In(s.C(FieldTags), v...))
+	})
+}
+
+// TagsNotIn applies the NotIn predicate on the "tags" field.
+func TagsNotIn(vs ...string) predicate.Alert {
+	v := make([]interface{}, len(vs))
+	for i := range v {
+		v[i] = vs[i]
+	}
+	return predicate.Alert(func(s *sql.Selector) {
+		// if not arguments were provided, append the FALSE constants,
+		// since we can't apply "IN ()". This will make this predicate falsy.
+		if len(v) == 0 {
+			s.Where(sql.False())
+			return
+		}
+		s.Where(sql.NotIn(s.C(FieldTags), v...))
+	})
+}
+
+// TagsGT applies the GT predicate on the "tags" field.
+func TagsGT(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.GT(s.C(FieldTags), v))
+	})
+}
+
+// TagsGTE applies the GTE predicate on the "tags" field.
+func TagsGTE(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.GTE(s.C(FieldTags), v))
+	})
+}
+
+// TagsLT applies the LT predicate on the "tags" field.
+func TagsLT(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.LT(s.C(FieldTags), v))
+	})
+}
+
+// TagsLTE applies the LTE predicate on the "tags" field.
+func TagsLTE(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.LTE(s.C(FieldTags), v))
+	})
+}
+
+// TagsContains applies the Contains predicate on the "tags" field.
+func TagsContains(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.Contains(s.C(FieldTags), v))
+	})
+}
+
+// TagsHasPrefix applies the HasPrefix predicate on the "tags" field.
+func TagsHasPrefix(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.HasPrefix(s.C(FieldTags), v))
+	})
+}
+
+// TagsHasSuffix applies the HasSuffix predicate on the "tags" field.
+func TagsHasSuffix(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.HasSuffix(s.C(FieldTags), v))
+	})
+}
+
+// TagsIsNil applies the IsNil predicate on the "tags" field.
+func TagsIsNil() predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.IsNull(s.C(FieldTags)))
+	})
+}
+
+// TagsNotNil applies the NotNil predicate on the "tags" field.
+func TagsNotNil() predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.NotNull(s.C(FieldTags)))
+	})
+}
+
+// TagsEqualFold applies the EqualFold predicate on the "tags" field.
+func TagsEqualFold(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.EqualFold(s.C(FieldTags), v))
+	})
+}
+
+// TagsContainsFold applies the ContainsFold predicate on the "tags" field.
+func TagsContainsFold(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.ContainsFold(s.C(FieldTags), v))
+	})
+}
+
+// BatchIdEQ applies the EQ predicate on the "batchId" field.
+func BatchIdEQ(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.EQ(s.C(FieldBatchId), v))
+	})
+}
+
+// BatchIdNEQ applies the NEQ predicate on the "batchId" field.
+func BatchIdNEQ(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.NEQ(s.C(FieldBatchId), v))
+	})
+}
+
+// BatchIdIn applies the In predicate on the "batchId" field.
+func BatchIdIn(vs ...string) predicate.Alert {
+	v := make([]interface{}, len(vs))
+	for i := range v {
+		v[i] = vs[i]
+	}
+	return predicate.Alert(func(s *sql.Selector) {
+		// if not arguments were provided, append the FALSE constants,
+		// since we can't apply "IN ()". This will make this predicate falsy.
+		if len(v) == 0 {
+			s.Where(sql.False())
+			return
+		}
+		s.Where(sql.In(s.C(FieldBatchId), v...))
+	})
+}
+
+// BatchIdNotIn applies the NotIn predicate on the "batchId" field.
+func BatchIdNotIn(vs ...string) predicate.Alert {
+	v := make([]interface{}, len(vs))
+	for i := range v {
+		v[i] = vs[i]
+	}
+	return predicate.Alert(func(s *sql.Selector) {
+		// if not arguments were provided, append the FALSE constants,
+		// since we can't apply "IN ()". This will make this predicate falsy.
+		if len(v) == 0 {
+			s.Where(sql.False())
+			return
+		}
+		s.Where(sql.NotIn(s.C(FieldBatchId), v...))
+	})
+}
+
+// BatchIdGT applies the GT predicate on the "batchId" field.
+func BatchIdGT(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.GT(s.C(FieldBatchId), v))
+	})
+}
+
+// BatchIdGTE applies the GTE predicate on the "batchId" field.
+func BatchIdGTE(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.GTE(s.C(FieldBatchId), v))
+	})
+}
+
+// BatchIdLT applies the LT predicate on the "batchId" field.
+func BatchIdLT(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.LT(s.C(FieldBatchId), v))
+	})
+}
+
+// BatchIdLTE applies the LTE predicate on the "batchId" field.
+func BatchIdLTE(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.LTE(s.C(FieldBatchId), v))
+	})
+}
+
+// BatchIdContains applies the Contains predicate on the "batchId" field.
+func BatchIdContains(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.Contains(s.C(FieldBatchId), v))
+	})
+}
+
+// BatchIdHasPrefix applies the HasPrefix predicate on the "batchId" field.
+func BatchIdHasPrefix(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.HasPrefix(s.C(FieldBatchId), v))
+	})
+}
+
+// BatchIdHasSuffix applies the HasSuffix predicate on the "batchId" field.
+func BatchIdHasSuffix(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.HasSuffix(s.C(FieldBatchId), v))
+	})
+}
+
+// BatchIdIsNil applies the IsNil predicate on the "batchId" field.
+func BatchIdIsNil() predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.IsNull(s.C(FieldBatchId)))
+	})
+}
+
+// BatchIdNotNil applies the NotNil predicate on the "batchId" field.
+func BatchIdNotNil() predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.NotNull(s.C(FieldBatchId)))
+	})
+}
+
+// BatchIdEqualFold applies the EqualFold predicate on the "batchId" field.
+func BatchIdEqualFold(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.EqualFold(s.C(FieldBatchId), v))
+	})
+}
+
+// BatchIdContainsFold applies the ContainsFold predicate on the "batchId" field.
+func BatchIdContainsFold(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.ContainsFold(s.C(FieldBatchId), v))
+	})
+}
+
+// AcknowledgedEQ applies the EQ predicate on the "acknowledged" field.
+func AcknowledgedEQ(v bool) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.EQ(s.C(FieldAcknowledged), v))
+	})
+}
+
+// AcknowledgedNEQ applies the NEQ predicate on the "acknowledged" field.
+func AcknowledgedNEQ(v bool) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.NEQ(s.C(FieldAcknowledged), v))
+	})
+}
+
+// AcknowledgedIsNil applies the IsNil predicate on the "acknowledged" field.
+func AcknowledgedIsNil() predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.IsNull(s.C(FieldAcknowledged)))
+	})
+}
+
+// AcknowledgedNotNil applies the NotNil predicate on the "acknowledged" field.
+func AcknowledgedNotNil() predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.NotNull(s.C(FieldAcknowledged)))
+	})
+}
+
+// AcknowledgedAtEQ applies the EQ predicate on the "acknowledgedAt" field.
+func AcknowledgedAtEQ(v time.Time) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.EQ(s.C(FieldAcknowledgedAt), v))
+	})
+}
+
+// AcknowledgedAtNEQ applies the NEQ predicate on the "acknowledgedAt" field.
+func AcknowledgedAtNEQ(v time.Time) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.NEQ(s.C(FieldAcknowledgedAt), v))
+	})
+}
+
+// AcknowledgedAtIn applies the In predicate on the "acknowledgedAt" field.
+func AcknowledgedAtIn(vs ...time.Time) predicate.Alert {
+	v := make([]interface{}, len(vs))
+	for i := range v {
+		v[i] = vs[i]
+	}
+	return predicate.Alert(func(s *sql.Selector) {
+		// if not arguments were provided, append the FALSE constants,
+		// since we can't apply "IN ()". This will make this predicate falsy.
+		if len(v) == 0 {
+			s.Where(sql.False())
+			return
+		}
+		s.Where(sql.In(s.C(FieldAcknowledgedAt), v...))
+	})
+}
+
+// AcknowledgedAtNotIn applies the NotIn predicate on the "acknowledgedAt" field.
+func AcknowledgedAtNotIn(vs ...time.Time) predicate.Alert {
+	v := make([]interface{}, len(vs))
+	for i := range v {
+		v[i] = vs[i]
+	}
+	return predicate.Alert(func(s *sql.Selector) {
+		// if not arguments were provided, append the FALSE constants,
+		// since we can't apply "IN ()". This will make this predicate falsy.
+		if len(v) == 0 {
+			s.Where(sql.False())
+			return
+		}
+		s.Where(sql.NotIn(s.C(FieldAcknowledgedAt), v...))
+	})
+}
+
+// AcknowledgedAtGT applies the GT predicate on the "acknowledgedAt" field.
+func AcknowledgedAtGT(v time.Time) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.GT(s.C(FieldAcknowledgedAt), v))
+	})
+}
+
+// AcknowledgedAtGTE applies the GTE predicate on the "acknowledgedAt" field.
+func AcknowledgedAtGTE(v time.Time) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.GTE(s.C(FieldAcknowledgedAt), v))
+	})
+}
+
+// AcknowledgedAtLT applies the LT predicate on the "acknowledgedAt" field.
+func AcknowledgedAtLT(v time.Time) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.LT(s.C(FieldAcknowledgedAt), v))
+	})
+}
+
+// AcknowledgedAtLTE applies the LTE predicate on the "acknowledgedAt" field.
+func AcknowledgedAtLTE(v time.Time) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.LTE(s.C(FieldAcknowledgedAt), v))
+	})
+}
+
+// AcknowledgedAtIsNil applies the IsNil predicate on the "acknowledgedAt" field.
+func AcknowledgedAtIsNil() predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.IsNull(s.C(FieldAcknowledgedAt)))
+	})
+}
+
+// AcknowledgedAtNotNil applies the NotNil predicate on the "acknowledgedAt" field.
+func AcknowledgedAtNotNil() predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.NotNull(s.C(FieldAcknowledgedAt)))
+	})
+}
+
+// AcknowledgedByEQ applies the EQ predicate on the "acknowledgedBy" field.
+func AcknowledgedByEQ(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.EQ(s.C(FieldAcknowledgedBy), v))
+	})
+}
+
+// AcknowledgedByNEQ applies the NEQ predicate on the "acknowledgedBy" field.
+func AcknowledgedByNEQ(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.NEQ(s.C(FieldAcknowledgedBy), v))
+	})
+}
+
+// AcknowledgedByIn applies the In predicate on the "acknowledgedBy" field.
+func AcknowledgedByIn(vs ...string) predicate.Alert {
+	v := make([]interface{}, len(vs))
+	for i := range v {
+		v[i] = vs[i]
+	}
+	return predicate.Alert(func(s *sql.Selector) {
+		// if not arguments were provided, append the FALSE constants,
+		// since we can't apply "IN ()". This will make this predicate falsy.
+		if len(v) == 0 {
+			s.Where(sql.False())
+			return
+		}
+		s.Where(sql.In(s.C(FieldAcknowledgedBy), v...))
+	})
+}
+
+// AcknowledgedByNotIn applies the NotIn predicate on the "acknowledgedBy" field.
+func AcknowledgedByNotIn(vs ...string) predicate.Alert {
+	v := make([]interface{}, len(vs))
+	for i := range v {
+		v[i] = vs[i]
+	}
+	return predicate.Alert(func(s *sql.Selector) {
+		// if not arguments were provided, append the FALSE constants,
+		// since we can't apply "IN ()". This will make this predicate falsy.
+		if len(v) == 0 {
+			s.Where(sql.False())
+			return
+		}
+		s.Where(sql.NotIn(s.C(FieldAcknowledgedBy), v...))
+	})
+}
+
+// AcknowledgedByGT applies the GT predicate on the "acknowledgedBy" field.
+func AcknowledgedByGT(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.GT(s.C(FieldAcknowledgedBy), v))
+	})
+}
+
+// AcknowledgedByGTE applies the GTE predicate on the "acknowledgedBy" field.
+func AcknowledgedByGTE(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.GTE(s.C(FieldAcknowledgedBy), v))
+	})
+}
+
+// AcknowledgedByLT applies the LT predicate on the "acknowledgedBy" field.
+func AcknowledgedByLT(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.LT(s.C(FieldAcknowledgedBy), v))
+	})
+}
+
+// AcknowledgedByLTE applies the LTE predicate on the "acknowledgedBy" field.
+func AcknowledgedByLTE(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.LTE(s.C(FieldAcknowledgedBy), v))
+	})
+}
+
+// AcknowledgedByContains applies the Contains predicate on the "acknowledgedBy" field.
+func AcknowledgedByContains(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.Contains(s.C(FieldAcknowledgedBy), v))
+	})
+}
+
+// AcknowledgedByHasPrefix applies the HasPrefix predicate on the "acknowledgedBy" field.
+func AcknowledgedByHasPrefix(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.HasPrefix(s.C(FieldAcknowledgedBy), v))
+	})
+}
+
+// AcknowledgedByHasSuffix applies the HasSuffix predicate on the "acknowledgedBy" field.
+func AcknowledgedByHasSuffix(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.HasSuffix(s.C(FieldAcknowledgedBy), v))
+	})
+}
+
+// AcknowledgedByIsNil applies the IsNil predicate on the "acknowledgedBy" field.
+func AcknowledgedByIsNil() predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.IsNull(s.C(FieldAcknowledgedBy)))
+	})
+}
+
+// AcknowledgedByNotNil applies the NotNil predicate on the "acknowledgedBy" field.
+func AcknowledgedByNotNil() predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.NotNull(s.C(FieldAcknowledgedBy)))
+	})
+}
+
+// AcknowledgedByEqualFold applies the EqualFold predicate on the "acknowledgedBy" field.
+func AcknowledgedByEqualFold(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.EqualFold(s.C(FieldAcknowledgedBy), v))
+	})
+}
+
+// AcknowledgedByContainsFold applies the ContainsFold predicate on the "acknowledgedBy" field.
+func AcknowledgedByContainsFold(v string) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.ContainsFold(s.C(FieldAcknowledgedBy), v))
+	})
+}
+
+// ArchivedEQ applies the EQ predicate on the "archived" field.
+func ArchivedEQ(v bool) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.EQ(s.C(FieldArchived), v))
+	})
+}
+
+// ArchivedNEQ applies the NEQ predicate on the "archived" field.
+func ArchivedNEQ(v bool) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.NEQ(s.C(FieldArchived), v))
+	})
+}
+
+// ArchivedIsNil applies the IsNil predicate on the "archived" field.
+func ArchivedIsNil() predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.IsNull(s.C(FieldArchived)))
+	})
+}
+
+// ArchivedNotNil applies the NotNil predicate on the "archived" field.
+func ArchivedNotNil() predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.NotNull(s.C(FieldArchived)))
+	})
+}
+
+// ArchivedAtEQ applies the EQ predicate on the "archivedAt" field.
+func ArchivedAtEQ(v time.Time) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.EQ(s.C(FieldArchivedAt), v))
+	})
+}
+
+// ArchivedAtNEQ applies the NEQ predicate on the "archivedAt" field.
+func ArchivedAtNEQ(v time.Time) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.NEQ(s.C(FieldArchivedAt), v))
+	})
+}
+
+// ArchivedAtIn applies the In predicate on the "archivedAt" field.
+func ArchivedAtIn(vs ...time.Time) predicate.Alert {
+	v := make([]interface{}, len(vs))
+	for i := range v {
+		v[i] = vs[i]
+	}
+	return predicate.Alert(func(s *sql.Selector) {
+		// if not arguments were provided, append the FALSE constants,
+		// since we can't apply "IN ()". This will make this predicate falsy.
+		if len(v) == 0 {
+			s.Where(sql.False())
+			return
+		}
+		s.Where(sql.In(s.C(FieldArchivedAt), v...))
+	})
+}
+
+// ArchivedAtNotIn applies the NotIn predicate on the "archivedAt" field.
+func ArchivedAtNotIn(vs ...time.Time) predicate.Alert {
+	v := make([]interface{}, len(vs))
+	for i := range v {
+		v[i] = vs[i]
+	}
+	return predicate.Alert(func(s *sql.Selector) {
+		// if not arguments were provided, append the FALSE constants,
+		// since we can't apply "IN ()". This will make this predicate falsy.
+		if len(v) == 0 {
+			s.Where(sql.False())
+			return
+		}
+		s.Where(sql.NotIn(s.C(FieldArchivedAt), v...))
+	})
+}
+
+// ArchivedAtGT applies the GT predicate on the "archivedAt" field.
+func ArchivedAtGT(v time.Time) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.GT(s.C(FieldArchivedAt), v))
+	})
+}
+
+// ArchivedAtGTE applies the GTE predicate on the "archivedAt" field.
+func ArchivedAtGTE(v time.Time) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.GTE(s.C(FieldArchivedAt), v))
+	})
+}
+
+// ArchivedAtLT applies the LT predicate on the "archivedAt" field.
+func ArchivedAtLT(v time.Time) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.LT(s.C(FieldArchivedAt), v))
+	})
+}
+
+// ArchivedAtLTE applies the LTE predicate on the "archivedAt" field.
+func ArchivedAtLTE(v time.Time) predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.LTE(s.C(FieldArchivedAt), v))
+	})
+}
+
+// ArchivedAtIsNil applies the IsNil predicate on the "archivedAt" field.
+func ArchivedAtIsNil() predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.IsNull(s.C(FieldArchivedAt)))
+	})
+}
+
+// ArchivedAtNotNil applies the NotNil predicate on the "archivedAt" field.
+func ArchivedAtNotNil() predicate.Alert {
+	return predicate.Alert(func(s *sql.Selector) {
+		s.Where(sql.NotNull(s.C(FieldArchivedAt)))
+	})
+}
+
 // HasOwner applies the HasEdge predicate on the "owner" edge.
 func HasOwner() predicate.Alert {
 	return predicate.Alert(func(s *sql.Selector) {
@@ -324,6 +324,110 @@ func (ac *AlertCreate) SetNillableSimulated(b *bool) *AlertCreate {
 	return ac
 }
 
+// SetTags sets the tags field.
+func (ac *AlertCreate) SetTags(s string) *AlertCreate {
+	ac.mutation.SetTags(s)
+	return ac
+}
+
+// SetNillableTags sets the tags field if the given value is not nil.
+func (ac *AlertCreate) SetNillableTags(s *string) *AlertCreate {
+	if s != nil {
+		ac.SetTags(*s)
+	}
+	return ac
+}
+
+// SetBatchId sets the batchId field.
+func (ac *AlertCreate) SetBatchId(s string) *AlertCreate {
+	ac.mutation.SetBatchId(s)
+	return ac
+}
+
+// SetNillableBatchId sets the batchId field if the given value is not nil.
+func (ac *AlertCreate) SetNillableBatchId(s *string) *AlertCreate {
+	if s != nil {
+		ac.SetBatchId(*s)
+	}
+	return ac
+}
+
+// SetAcknowledged sets the acknowledged field.
+func (ac *AlertCreate) SetAcknowledged(b bool) *AlertCreate {
+	ac.mutation.SetAcknowledged(b)
+	return ac
+}
+
+// SetNillableAcknowledged sets the acknowledged field if the given value is not nil.
+func (ac *AlertCreate) SetNillableAcknowledged(b *bool) *AlertCreate {
+	if b != nil {
+		ac.SetAcknowledged(*b)
+	}
+	return ac
+}
+
+// SetAcknowledgedAt sets the acknowledgedAt field.
+func (ac *AlertCreate) SetAcknowledgedAt(t time.Time) *AlertCreate {
+	ac.mutation.SetAcknowledgedAt(t)
+	return ac
+}
+
+// SetNillableAcknowledgedAt sets the acknowledgedAt field if the given value is not nil.
+func (ac *AlertCreate) SetNillableAcknowledgedAt(t *time.Time) *AlertCreate {
+	if t != nil {
+		ac.SetAcknowledgedAt(*t)
+	}
+	return ac
+}
+
+// SetAcknowledgedBy sets the acknowledgedBy field.
+func (ac *AlertCreate) SetAcknowledgedBy(s string) *AlertCreate {
+	ac.mutation.SetAcknowledgedBy(s)
+	return ac
+}
+
+// SetNillableAcknowledgedBy sets the acknowledgedBy field if the given value is not nil.
+func (ac *AlertCreate) SetNillableAcknowledgedBy(s *string) *AlertCreate {
+	if s != nil {
+		ac.SetAcknowledgedBy(*s)
+	}
+	return ac
+}
+
+// SetArchived sets the archived field.
+func (ac *AlertCreate) SetArchived(b bool) *AlertCreate {
+	ac.mutation.SetArchived(b)
+	return ac
+}
+
+// SetNillableArchived sets the archived field if the given value is not nil.
+func (ac *AlertCreate) SetNillableArchived(b *bool) *AlertCreate {
+	if b != nil {
+		ac.SetArchived(*b)
+	}
+	return ac
+}
+
+// SetArchivedAt sets the archivedAt field.
+func (ac *AlertCreate) SetArchivedAt(t time.Time) *AlertCreate {
+	ac.mutation.SetArchivedAt(t)
+	return ac
+}
+
+// SetNillableArchivedAt sets the archivedAt field if the given value is not nil.
+func (ac *AlertCreate) SetNillableArchivedAt(t *time.Time) *AlertCreate {
+	if t != nil {
+		ac.SetArchivedAt(*t)
+	}
+	return ac
+}
+
+// SetID sets the id field.
+func (ac *AlertCreate) SetID(i int) *AlertCreate {
+	ac.mutation.SetID(i)
+	return ac
+}
+
 // SetOwnerID sets the owner edge to Machine by id.
 func (ac *AlertCreate) SetOwnerID(id int) *AlertCreate {
 	ac.mutation.SetOwnerID(id)
@@ -472,6 +576,14 @@ func (ac *AlertCreate) defaults() {
 		v := alert.DefaultSimulated
 		ac.mutation.SetSimulated(v)
 	}
+	if _, ok := ac.mutation.Acknowledged(); !ok {
+		v := alert.DefaultAcknowledged
+		ac.mutation.SetAcknowledged(v)
+	}
+	if _, ok := ac.mutation.Archived(); !ok {
+		v := alert.DefaultArchived
+		ac.mutation.SetArchived(v)
+	}
 }
 
 // check runs all checks and user-defined validators on the builder.
@@ -499,8 +611,10 @@ func (ac *AlertCreate) sqlSave(ctx context.Context) (*Alert, error) {
 		}
 		return nil, err
 	}
-	id := _spec.ID.Value.(int64)
-	_node.ID = int(id)
+	if _node.ID == 0 {
+		id := _spec.ID.Value.(int64)
+		_node.ID = int(id)
+	}
 	return _node, nil
 }
 
@@ -515,6 +629,10 @@ func (ac *AlertCreate) createSpec() (*Alert, *sqlgraph.CreateSpec) {
 			},
 		}
 	)
+	if id, ok := ac.mutation.ID(); ok {
+		_node.ID = id
+		_spec.ID.Value = id
+	}
 	if value, ok := ac.mutation.CreatedAt(); ok {
 		_spec.Fields = append(_spec.Fields, &sqlgraph.FieldSpec{
 			Type:   field.TypeTime,
@@ -691,6 +809,62 @@ func (ac *AlertCreate) createSpec() (*Alert, *sqlgraph.CreateSpec) {
 		})
 		_node.Simulated = value
 	}
+	if value, ok := ac.mutation.Tags(); ok {
+		_spec.Fields = append(_spec.Fields, &sqlgraph.FieldSpec{
+			Type:   field.TypeString,
+			Value:  value,
+			Column: alert.FieldTags,
+		})
+		_node.Tags = value
+	}
+	if value, ok := ac.mutation.BatchId(); ok {
+		_spec.Fields = append(_spec.Fields, &sqlgraph.FieldSpec{
+			Type:   field.TypeString,
+			Value:  value,
+			Column: alert.FieldBatchId,
+		})
+		_node.BatchId = value
+	}
+	if value, ok := ac.mutation.Acknowledged(); ok {
+		_spec.Fields = append(_spec.Fields, &sqlgraph.FieldSpec{
+			Type:   field.TypeBool,
+			Value:  value,
+			Column: alert.FieldAcknowledged,
+		})
+		_node.Acknowledged = value
+	}
+	if value, ok := ac.mutation.AcknowledgedAt(); ok {
+		_spec.Fields = append(_spec.Fields, &sqlgraph.FieldSpec{
+			Type:   field.TypeTime,
+			Value:  value,
+			Column: alert.FieldAcknowledgedAt,
+		})
+		_node.AcknowledgedAt = value
+	}
+	if value, ok := ac.mutation.AcknowledgedBy(); ok {
+		_spec.Fields = append(_spec.Fields, &sqlgraph.FieldSpec{
+			Type:   field.TypeString,
+			Value:  value,
+			Column: alert.FieldAcknowledgedBy,
+		})
+		_node.AcknowledgedBy = value
+	}
+	if value, ok := ac.mutation.Archived(); ok {
+		_spec.Fields = append(_spec.Fields, &sqlgraph.FieldSpec{
+			Type:   field.TypeBool,
+			Value:  value,
+			Column: alert.FieldArchived,
+		})
+		_node.Archived = value
+	}
+	if value, ok := ac.mutation.ArchivedAt(); ok {
+		_spec.Fields = append(_spec.Fields, &sqlgraph.FieldSpec{
+			Type:   field.TypeTime,
+			Value:  value,
+			Column: alert.FieldArchivedAt,
+		})
+		_node.ArchivedAt = value
+	}
 	if nodes := ac.mutation.OwnerIDs(); len(nodes) > 0 {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.M2O,
@@ -810,8 +984,10 @@ func (acb *AlertCreateBulk) Save(ctx context.Context) ([]*Alert, error) {
 				if err != nil {
 					return nil, err
 				}
-				id := specs[i].ID.Value.(int64)
-				nodes[i].ID = int(id)
+				if nodes[i].ID == 0 {
+					id := specs[i].ID.Value.(int64)
+					nodes[i].ID = int(id)
+				}
 				return nodes[i], nil
 			})
 			for i := len(builder.hooks) - 1; i >= 0; i-- {
@@ -468,6 +468,146 @@ func (au *AlertUpdate) SetNillableSimulated(b *bool) *AlertUpdate {
 	return au
 }
 
+// SetTags sets the tags field.
+func (au *AlertUpdate) SetTags(s string) *AlertUpdate {
+	au.mutation.SetTags(s)
+	return au
+}
+
+// SetNillableTags sets the tags field if the given value is not nil.
+func (au *AlertUpdate) SetNillableTags(s *string) *AlertUpdate {
+	if s != nil {
+		au.SetTags(*s)
+	}
+	return au
+}
+
+// ClearTags clears the value of tags.
+func (au *AlertUpdate) ClearTags() *AlertUpdate {
+	au.mutation.ClearTags()
+	return au
+}
+
+// SetBatchId sets the batchId field.
+func (au *AlertUpdate) SetBatchId(s string) *AlertUpdate {
+	au.mutation.SetBatchId(s)
+	return au
+}
+
+// SetNillableBatchId sets the batchId field if the given value is not nil.
+func (au *AlertUpdate) SetNillableBatchId(s *string) *AlertUpdate {
+	if s != nil {
+		au.SetBatchId(*s)
+	}
+	return au
+}
+
+// ClearBatchId clears the value of batchId.
+func (au *AlertUpdate) ClearBatchId() *AlertUpdate {
+	au.mutation.ClearBatchId()
+	return au
+}
+
+// SetAcknowledged sets the acknowledged field.
+func (au *AlertUpdate) SetAcknowledged(b bool) *AlertUpdate {
+	au.mutation.SetAcknowledged(b)
+	return au
+}
+
+// SetNillableAcknowledged sets the acknowledged field if the given value is not nil.
+func (au *AlertUpdate) SetNillableAcknowledged(b *bool) *AlertUpdate {
+	if b != nil {
+		au.SetAcknowledged(*b)
+	}
+	return au
+}
+
+// ClearAcknowledged clears the value of acknowledged.
+func (au *AlertUpdate) ClearAcknowledged() *AlertUpdate {
+	au.mutation.ClearAcknowledged()
+	return au
+}
+
+// SetAcknowledgedAt sets the acknowledgedAt field.
+func (au *AlertUpdate) SetAcknowledgedAt(t time.Time) *AlertUpdate {
+	au.mutation.SetAcknowledgedAt(t)
+	return au
+}
+
+// SetNillableAcknowledgedAt sets the acknowledgedAt field if the given value is not nil.
+func (au *AlertUpdate) SetNillableAcknowledgedAt(t *time.Time) *AlertUpdate {
+	if t != nil {
+		au.SetAcknowledgedAt(*t)
+	}
+	return au
+}
+
+// ClearAcknowledgedAt clears the value of acknowledgedAt.
+func (au *AlertUpdate) ClearAcknowledgedAt() *AlertUpdate {
+	au.mutation.ClearAcknowledgedAt()
+	return au
+}
+
+// SetAcknowledgedBy sets the acknowledgedBy field.
+func (au *AlertUpdate) SetAcknowledgedBy(s string) *AlertUpdate {
+	au.mutation.SetAcknowledgedBy(s)
+	return au
+}
+
+// SetNillableAcknowledgedBy sets the acknowledgedBy field if the given value is not nil.
+func (au *AlertUpdate) SetNillableAcknowledgedBy(s *string) *AlertUpdate {
+	if s != nil {
+		au.SetAcknowledgedBy(*s)
+	}
+	return au
+}
+
+// ClearAcknowledgedBy clears the value of acknowledgedBy.
+func (au *AlertUpdate) ClearAcknowledgedBy() *AlertUpdate {
+	au.mutation.ClearAcknowledgedBy()
+	return au
+}
+
+// SetArchived sets the archived field.
+func (au *AlertUpdate) SetArchived(b bool) *AlertUpdate {
+	au.mutation.SetArchived(b)
+	return au
+}
+
+// SetNillableArchived sets the archived field if the given value is not nil.
+func (au *AlertUpdate) SetNillableArchived(b *bool) *AlertUpdate {
+	if b != nil {
+		au.SetArchived(*b)
+	}
+	return au
+}
+
+// ClearArchived clears the value of archived.
+func (au *AlertUpdate) ClearArchived() *AlertUpdate {
+	au.mutation.ClearArchived()
+	return au
+}
+
+// SetArchivedAt sets the archivedAt field.
+func (au *AlertUpdate) SetArchivedAt(t time.Time) *AlertUpdate {
+	au.mutation.SetArchivedAt(t)
+	return au
+}
+
+// SetNillableArchivedAt sets the archivedAt field if the given value is not nil.
+func (au *AlertUpdate) SetNillableArchivedAt(t *time.Time) *AlertUpdate {
+	if t != nil {
+		au.SetArchivedAt(*t)
+	}
+	return au
+}
+
+// ClearArchivedAt clears the value of archivedAt.
+func (au *AlertUpdate) ClearArchivedAt() *AlertUpdate {
+	au.mutation.ClearArchivedAt()
+	return au
+}
+
 // SetOwnerID sets the owner edge to Machine by id.
 func (au *AlertUpdate) SetOwnerID(id int) *AlertUpdate {
 	au.mutation.SetOwnerID(id)
@@ -965,6 +1105,97 @@ func (au *AlertUpdate) sqlSave(ctx context.Context) (n int, err error) {
 			Column: alert.FieldSimulated,
 		})
 	}
+	if value, ok := au.mutation.Tags(); ok {
+		_spec.Fields.Set = append(_spec.Fields.Set, &sqlgraph.FieldSpec{
+			Type:   field.TypeString,
+			Value:  value,
+			Column: alert.FieldTags,
+		})
+	}
+	if au.mutation.TagsCleared() {
+		_spec.Fields.Clear = append(_spec.Fields.Clear, &sqlgraph.FieldSpec{
+			Type:   field.TypeString,
+			Column: alert.FieldTags,
+		})
+	}
+	if value, ok := au.mutation.BatchId(); ok {
+		_spec.Fields.Set = append(_spec.Fields.Set, &sqlgraph.FieldSpec{
+			Type:   field.TypeString,
+			Value:  value,
+			Column: alert.FieldBatchId,
+		})
+	}
+	if au.mutation.BatchIdCleared() {
+		_spec.Fields.Clear = append(_spec.Fields.Clear, &sqlgraph.FieldSpec{
+			Type:   field.TypeString,
+			Column: alert.FieldBatchId,
+		})
+	}
+	if value, ok := au.mutation.Acknowledged(); ok {
+		_spec.Fields.Set = append(_spec.Fields.Set, &sqlgraph.FieldSpec{
+			Type:   field.TypeBool,
+			Value:  value,
+			Column: alert.FieldAcknowledged,
+		})
+	}
+	if au.mutation.AcknowledgedCleared() {
+		_spec.Fields.Clear = append(_spec.Fields.Clear, &sqlgraph.FieldSpec{
+			Type:   field.TypeBool,
+			Column: alert.FieldAcknowledged,
+		})
+	}
+	if value, ok := au.mutation.AcknowledgedAt(); ok {
+		_spec.Fields.Set = append(_spec.Fields.Set, &sqlgraph.FieldSpec{
+			Type:   field.TypeTime,
+			Value:  value,
+			Column: alert.FieldAcknowledgedAt,
+		})
+	}
+	if au.mutation.AcknowledgedAtCleared() {
+		_spec.Fields.Clear = append(_spec.Fields.Clear, &sqlgraph.FieldSpec{
+			Type:   field.TypeTime,
+			Column: alert.FieldAcknowledgedAt,
+		})
+	}
+	if value, ok := au.mutation.AcknowledgedBy(); ok {
+		_spec.Fields.Set = append(_spec.Fields.Set, &sqlgraph.FieldSpec{
+			Type:   field.TypeString,
+			Value:  value,
+			Column: alert.FieldAcknowledgedBy,
+		})
+	}
+	if au.mutation.AcknowledgedByCleared() {
+		_spec.Fields.Clear = append(_spec.Fields.Clear, &sqlgraph.FieldSpec{
+			Type:   field.TypeString,
+			Column: alert.FieldAcknowledgedBy,
+		})
+	}
+	if value, ok := au.mutation.Archived(); ok {
+		_spec.Fields.Set = append(_spec.Fields.Set, &sqlgraph.FieldSpec{
+			Type:   field.TypeBool,
+			Value:  value,
+			Column: alert.FieldArchived,
+		})
+	}
+	if au.mutation.ArchivedCleared() {
+		_spec.Fields.Clear = append(_spec.Fields.Clear, &sqlgraph.FieldSpec{
+			Type:   field.TypeBool,
+			Column: alert.FieldArchived,
+		})
+	}
+	if value, ok := au.mutation.ArchivedAt(); ok {
+		_spec.Fields.Set = append(_spec.Fields.Set, &sqlgraph.FieldSpec{
+			Type:   field.TypeTime,
+			Value:  value,
+			Column: alert.FieldArchivedAt,
+		})
+	}
+	if au.mutation.ArchivedAtCleared() {
+		_spec.Fields.Clear = append(_spec.Fields.Clear, &sqlgraph.FieldSpec{
+			Type:   field.TypeTime,
+			Column: alert.FieldArchivedAt,
+		})
+	}
 	if au.mutation.OwnerCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.M2O,
@@ -1616,6 +1847,146 @@ func (auo *AlertUpdateOne) SetNillableSimulated(b *bool) *AlertUpdateOne {
 	return auo
 }
 
+// SetTags sets the tags field.
+func (auo *AlertUpdateOne) SetTags(s string) *AlertUpdateOne {
+	auo.mutation.SetTags(s)
+	return auo
+}
+
+// SetNillableTags sets the tags field if the given value is not nil.
+func (auo *AlertUpdateOne) SetNillableTags(s *string) *AlertUpdateOne {
+	if s != nil {
+		auo.SetTags(*s)
+	}
+	return auo
+}
+
+// ClearTags clears the value of tags.
+func (auo *AlertUpdateOne) ClearTags() *AlertUpdateOne {
+	auo.mutation.ClearTags()
+	return auo
+}
+
+// SetBatchId sets the batchId field.
+func (auo *AlertUpdateOne) SetBatchId(s string) *AlertUpdateOne {
+	auo.mutation.SetBatchId(s)
+	return auo
+}
+
+// SetNillableBatchId sets the batchId field if the given value is not nil.
+func (auo *AlertUpdateOne) SetNillableBatchId(s *string) *AlertUpdateOne {
+	if s != nil {
+		auo.SetBatchId(*s)
+	}
+	return auo
+}
+
+// ClearBatchId clears the value of batchId.
+func (auo *AlertUpdateOne) ClearBatchId() *AlertUpdateOne {
+	auo.mutation.ClearBatchId()
+	return auo
+}
+
+// SetAcknowledged sets the acknowledged field.
+func (auo *AlertUpdateOne) SetAcknowledged(b bool) *AlertUpdateOne {
+	auo.mutation.SetAcknowledged(b)
+	return auo
+}
+
+// SetNillableAcknowledged sets the acknowledged field if the given value is not nil.
+func (auo *AlertUpdateOne) SetNillableAcknowledged(b *bool) *AlertUpdateOne {
+	if b != nil {
+		auo.SetAcknowledged(*b)
+	}
+	return auo
+}
+
+// ClearAcknowledged clears the value of acknowledged.
+func (auo *AlertUpdateOne) ClearAcknowledged() *AlertUpdateOne {
+	auo.mutation.ClearAcknowledged()
+	return auo
+}
+
+// SetAcknowledgedAt sets the acknowledgedAt field.
+func (auo *AlertUpdateOne) SetAcknowledgedAt(t time.Time) *AlertUpdateOne {
+	auo.mutation.SetAcknowledgedAt(t)
+	return auo
+}
+
+// SetNillableAcknowledgedAt sets the acknowledgedAt field if the given value is not nil.
+func (auo *AlertUpdateOne) SetNillableAcknowledgedAt(t *time.Time) *AlertUpdateOne {
+	if t != nil {
+		auo.SetAcknowledgedAt(*t)
+	}
+	return auo
+}
+
+// ClearAcknowledgedAt clears the value of acknowledgedAt.
+func (auo *AlertUpdateOne) ClearAcknowledgedAt() *AlertUpdateOne {
+	auo.mutation.ClearAcknowledgedAt()
+	return auo
+}
+
+// SetAcknowledgedBy sets the acknowledgedBy field.
+func (auo *AlertUpdateOne) SetAcknowledgedBy(s string) *AlertUpdateOne {
+	auo.mutation.SetAcknowledgedBy(s)
+	return auo
+}
+
+// SetNillableAcknowledgedBy sets the acknowledgedBy field if the given value is not nil.
+func (auo *AlertUpdateOne) SetNillableAcknowledgedBy(s *string) *AlertUpdateOne {
+	if s != nil {
+		auo.SetAcknowledgedBy(*s)
+	}
+	return auo
+}
+
+// ClearAcknowledgedBy clears the value of acknowledgedBy.
+func (auo *AlertUpdateOne) ClearAcknowledgedBy() *AlertUpdateOne {
+	auo.mutation.ClearAcknowledgedBy()
+	return auo
+}
+
+// SetArchived sets the archived field.
+func (auo *AlertUpdateOne) SetArchived(b bool) *AlertUpdateOne {
+	auo.mutation.SetArchived(b)
+	return auo
+}
+
+// SetNillableArchived sets the archived field if the given value is not nil.
+func (auo *AlertUpdateOne) SetNillableArchived(b *bool) *AlertUpdateOne {
+	if b != nil {
+		auo.SetArchived(*b)
+	}
+	return auo
+}
+
+// ClearArchived clears the value of archived.
+func (auo *AlertUpdateOne) ClearArchived() *AlertUpdateOne {
+	auo.mutation.ClearArchived()
+	return auo
+}
+
+// SetArchivedAt sets the archivedAt field.
+func (auo *AlertUpdateOne) SetArchivedAt(t time.Time) *AlertUpdateOne {
+	auo.mutation.SetArchivedAt(t)
+	return auo
+}
+
+// SetNillableArchivedAt sets the archivedAt field if the given value is not nil.
+func (auo *AlertUpdateOne) SetNillableArchivedAt(t *time.Time) *AlertUpdateOne {
+	if t != nil {
+		auo.SetArchivedAt(*t)
+	}
+	return auo
+}
+
+// ClearArchivedAt clears the value of archivedAt.
+func (auo *AlertUpdateOne) ClearArchivedAt() *AlertUpdateOne {
+	auo.mutation.ClearArchivedAt()
+	return auo
+}
+
 // SetOwnerID sets the owner edge to Machine by id.
 func (auo *AlertUpdateOne) SetOwnerID(id int) *AlertUpdateOne {
 	auo.mutation.SetOwnerID(id)
@@ -2111,6 +2482,97 @@ func (auo *AlertUpdateOne) sqlSave(ctx context.Context) (_node *Alert, err error
 			Column: alert.FieldSimulated,
 		})
 	}
+	if value, ok := auo.mutation.Tags(); ok {
+		_spec.Fields.Set = append(_spec.Fields.Set, &sqlgraph.FieldSpec{
+			Type:   field.TypeString,
+			Value:  value,
+			Column: alert.FieldTags,
+		})
+	}
+	if auo.mutation.TagsCleared() {
+		_spec.Fields.Clear = append(_spec.Fields.Clear, &sqlgraph.FieldSpec{
+			Type:   field.TypeString,
+			Column: alert.FieldTags,
+		})
+	}
+	if value, ok := auo.mutation.BatchId(); ok {
+		_spec.Fields.Set = append(_spec.Fields.Set, &sqlgraph.FieldSpec{
+			Type:   field.TypeString,
+			Value:  value,
+			Column: alert.FieldBatchId,
+		})
+	}
+	if auo.mutation.BatchIdCleared() {
+		_spec.Fields.Clear = append(_spec.Fields.Clear, &sqlgraph.FieldSpec{
+			Type:   field.TypeString,
+			Column: alert.FieldBatchId,
+		})
+	}
+	if value, ok := auo.mutation.Acknowledged(); ok {
+		_spec.Fields.Set = append(_spec.Fields.Set, &sqlgraph.FieldSpec{
+			Type:   field.TypeBool,
+			Value:  value,
+			Column: alert.FieldAcknowledged,
+		})
+	}
+	if auo.mutation.AcknowledgedCleared() {
+		_spec.Fields.Clear = append(_spec.Fields.Clear, &sqlgraph.FieldSpec{
+			Type:   field.TypeBool,
+			Column: alert.FieldAcknowledged,
+		})
+	}
+	if value, ok := auo.mutation.AcknowledgedAt(); ok {
+		_spec.Fields.Set = append(_spec.Fields.Set, &sqlgraph.FieldSpec{
+			Type:   field.TypeTime,
+			Value:  value,
+			Column: alert.FieldAcknowledgedAt,
+		})
+	}
+	if auo.mutation.AcknowledgedAtCleared() {
+		_spec.Fields.Clear = append(_spec.Fields.Clear, &sqlgraph.FieldSpec{
+			Type:   field.TypeTime,
+			Column: alert.FieldAcknowledgedAt,
+		})
+	}
+	if value, ok := auo.mutation.AcknowledgedBy(); ok {
+		_spec.Fields.Set = append(_spec.Fields.Set, &sqlgraph.FieldSpec{
+			Type:   field.TypeString,
+			Value:  value,
+			Column: alert.FieldAcknowledgedBy,
+		})
+	}
+	if auo.mutation.AcknowledgedByCleared() {
+		_spec.Fields.Clear = append(_spec.Fields.Clear, &sqlgraph.FieldSpec{
+			Type:   field.TypeString,
+			Column: alert.FieldAcknowledgedBy,
+		})
+	}
+	if value, ok := auo.mutation.Archived(); ok {
+		_spec.Fields.Set = append(_spec.Fields.Set, &sqlgraph.FieldSpec{
+			Type:   field.TypeBool,
+			Value:  value,
+			Column: alert.FieldArchived,
+		})
+	}
+	if auo.mutation.ArchivedCleared() {
+		_spec.Fields.Clear = append(_spec.Fields.Clear, &sqlgraph.FieldSpec{
+			Type:   field.TypeBool,
+			Column: alert.FieldArchived,
+		})
+	}
+	if value, ok := auo.mutation.ArchivedAt(); ok {
+		_spec.Fields.Set = append(_spec.Fields.Set, &sqlgraph.FieldSpec{
+			Type:   field.TypeTime,
+			Value:  value,
+			Column: alert.FieldArchivedAt,
+		})
+	}
+	if auo.mutation.ArchivedAtCleared() {
+		_spec.Fields.Clear = append(_spec.Fields.Clear, &sqlgraph.FieldSpec{
+			Type:   field.TypeTime,
+			Column: alert.FieldArchivedAt,
+		})
+	}
 	if auo.mutation.OwnerCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.M2O,
@@ -31,6 +31,12 @@ type Decision struct {
 	StartIP int64 `json:"start_ip,omitempty"`
 	// EndIP holds the value of the "end_ip" field.
 	EndIP int64 `json:"end_ip,omitempty"`
+	// IPSize holds the value of the "ip_size" field.
+	IPSize int64 `json:"ip_size,omitempty"`
+	// StartSuffix holds the value of the "start_suffix" field.
+	StartSuffix int64 `json:"start_suffix,omitempty"`
+	// EndSuffix holds the value of the "end_suffix" field.
+	EndSuffix int64 `json:"end_suffix,omitempty"`
 	// Scope holds the value of the "scope" field.
 	Scope string `json:"scope,omitempty"`
 	// Value holds the value of the "value" field.
@@ -79,6 +85,9 @@ func (*Decision) scanValues() []interface{} {
 		&sql.NullString{}, // type
 		&sql.NullInt64{},  // start_ip
 		&sql.NullInt64{},  // end_ip
+		&sql.NullInt64{},  // ip_size
+		&sql.NullInt64{},  // start_suffix
+		&sql.NullInt64{},  // end_suffix
 		&sql.NullString{}, // scope
 		&sql.NullString{}, // value
 		&sql.NullString{}, // origin
@@ -140,27 +149,42 @@ func (d *Decision) assignValues(values ...interface{}) error {
 	} else if value.Valid {
 		d.EndIP = value.Int64
 	}
-	if value, ok := values[7].(*sql.NullString); !ok {
-		return fmt.Errorf("unexpected type %T for field scope", values[7])
+	if value, ok := values[7].(*sql.NullInt64); !ok {
+		return fmt.Errorf("unexpected type %T for field ip_size", values[7])
+	} else if value.Valid {
+		d.IPSize = value.Int64
+	}
+	if value, ok := values[8].(*sql.NullInt64); !ok {
+		return fmt.Errorf("unexpected type %T for field start_suffix", values[8])
+	} else if value.Valid {
+		d.StartSuffix = value.Int64
+	}
+	if value, ok := values[9].(*sql.NullInt64); !ok {
+		return fmt.Errorf("unexpected type %T for field end_suffix", values[9])
+	} else if value.Valid {
+		d.EndSuffix = value.Int64
+	}
+	if value, ok := values[10].(*sql.NullString); !ok {
+		return fmt.Errorf("unexpected type %T for field scope", values[10])
 	} else if value.Valid {
 		d.Scope = value.String
 	}
-	if value, ok := values[8].(*sql.NullString); !ok {
-		return fmt.Errorf("unexpected type %T for field value", values[8])
+	if value, ok := values[11].(*sql.NullString); !ok {
+		return fmt.Errorf("unexpected type %T for field value", values[11])
 	} else if value.Valid {
 		d.Value = value.String
 	}
-	if value, ok := values[9].(*sql.NullString); !ok {
-		return fmt.Errorf("unexpected type %T for field origin", values[9])
+	if value, ok := values[12].(*sql.NullString); !ok {
+		return fmt.Errorf("unexpected type %T for field origin", values[12])
 	} else if value.Valid {
 		d.Origin = value.String
 	}
-	if value, ok := values[10].(*sql.NullBool); !ok {
-		return fmt.Errorf("unexpected type %T for field simulated", values[10])
+	if value, ok := values[13].(*sql.NullBool); !ok {
+		return fmt.Errorf("unexpected type %T for field simulated", values[13])
 	} else if value.Valid {
 		d.Simulated = value.Bool
 	}
-	values = values[11:]
+	values = values[14:]
 	if len(values) == len(decision.ForeignKeys) {
 		if value, ok := values[0].(*sql.NullInt64); !ok {
 			return fmt.Errorf("unexpected type %T for edge-field alert_decisions", value)
@@ -214,6 +238,12 @@ func (d *Decision) String() string {
 	builder.WriteString(fmt.Sprintf("%v", d.StartIP))
 	builder.WriteString(", end_ip=")
 	builder.WriteString(fmt.Sprintf("%v", d.EndIP))
+	builder.WriteString(", ip_size=")
+	builder.WriteString(fmt.Sprintf("%v", d.IPSize))
+	builder.WriteString(", start_suffix=")
+	builder.WriteString(fmt.Sprintf("%v", d.StartSuffix))
+	builder.WriteString(", end_suffix=")
+	builder.WriteString(fmt.Sprintf("%v", d.EndSuffix))
 	builder.WriteString(", scope=")
 	builder.WriteString(d.Scope)
 	builder.WriteString(", value=")
@@ -25,6 +25,12 @@ const (
 	FieldStartIP = "start_ip"
 	// FieldEndIP holds the string denoting the end_ip field in the database.
 	FieldEndIP = "end_ip"
+	// FieldIPSize holds the string denoting the ip_size field in the database.
+	FieldIPSize = "ip_size"
+	// FieldStartSuffix holds the string denoting the start_suffix field in the database.
+	FieldStartSuffix = "start_suffix"
+	// FieldEndSuffix holds the string denoting the end_suffix field in the database.
+	FieldEndSuffix = "end_suffix"
 	// FieldScope holds the string denoting the scope field in the database.
 	FieldScope = "scope"
 	// FieldValue holds the string denoting the value field in the database.
@@ -58,6 +64,9 @@ var Columns = []string{
 	FieldType,
 	FieldStartIP,
 	FieldEndIP,
+	FieldIPSize,
+	FieldStartSuffix,
+	FieldEndSuffix,
 	FieldScope,
 	FieldValue,
 	FieldOrigin,
@@ -89,6 +98,8 @@ var (
 	DefaultCreatedAt func() time.Time
 	// DefaultUpdatedAt holds the default value on creation for the updated_at field.
 	DefaultUpdatedAt func() time.Time
+	// UpdateDefaultUpdatedAt holds the default value on update for the updated_at field.
+	UpdateDefaultUpdatedAt func() time.Time
 	// DefaultSimulated holds the default value on creation for the simulated field.
 	DefaultSimulated bool
 )
@@ -95,6 +95,48 @@ func (dc *DecisionCreate) SetNillableEndIP(i *int64) *DecisionCreate {
 	return dc
 }
 
+// SetIPSize sets the ip_size field.
+func (dc *DecisionCreate) SetIPSize(i int64) *DecisionCreate {
+	dc.mutation.SetIPSize(i)
+	return dc
+}
+
+// SetNillableIPSize sets the ip_size field if the given value is not nil.
+func (dc *DecisionCreate) SetNillableIPSize(i *int64) *DecisionCreate {
+	if i != nil {
+		dc.SetIPSize(*i)
+	}
+	return dc
+}
+
+// SetStartSuffix sets the start_suffix field.
+func (dc *DecisionCreate) SetStartSuffix(i int64) *DecisionCreate {
+	dc.mutation.SetStartSuffix(i)
+	return dc
+}
+
+// SetNillableStartSuffix sets the start_suffix field if the given value is not nil.
+func (dc *DecisionCreate) SetNillableStartSuffix(i *int64) *DecisionCreate {
+	if i != nil {
+		dc.SetStartSuffix(*i)
+	}
+	return dc
+}
+
+// SetEndSuffix sets the end_suffix field.
+func (dc *DecisionCreate) SetEndSuffix(i int64) *DecisionCreate {
+	dc.mutation.SetEndSuffix(i)
+	return dc
+}
+
+// SetNillableEndSuffix sets the end_suffix field if the given value is not nil.
+func (dc *DecisionCreate) SetNillableEndSuffix(i *int64) *DecisionCreate {
+	if i != nil {
+		dc.SetEndSuffix(*i)
+	}
+	return dc
+}
+
 // SetScope sets the scope field.
 func (dc *DecisionCreate) SetScope(s string) *DecisionCreate {
 	dc.mutation.SetScope(s)
@@ -324,6 +366,30 @@ func (dc *DecisionCreate) createSpec() (*Decision, *sqlgraph.CreateSpec) {
 		})
 		_node.EndIP = value
 	}
+	if value, ok := dc.mutation.IPSize(); ok {
+		_spec.Fields = append(_spec.Fields, &sqlgraph.FieldSpec{
+			Type:   field.TypeInt64,
+			Value:  value,
+			Column: decision.FieldIPSize,
+		})
+		_node.IPSize = value
+	}
+	if value, ok := dc.mutation.StartSuffix(); ok {
+		_spec.Fields = append(_spec.Fields, &sqlgraph.FieldSpec{
+			Type:   field.TypeInt64,
+			Value:  value,
+			Column: decision.FieldStartSuffix,
+		})
+		_node.StartSuffix = value
+	}
+	if value, ok := dc.mutation.EndSuffix(); ok {
+		_spec.Fields = append(_spec.Fields, &sqlgraph.FieldSpec{
+			Type:   field.TypeInt64,
+			Value:  value,
+			Column: decision.FieldEndSuffix,
+		})
+		_node.EndSuffix = value
+	}
 	if value, ok := dc.mutation.Scope(); ok {
 		_spec.Fields = append(_spec.Fields, &sqlgraph.FieldSpec{
 			Type:   field.TypeString,
@@ -49,14 +49,6 @@ func (du *DecisionUpdate) SetUpdatedAt(t time.Time) *DecisionUpdate {
 	return du
 }
 
-// SetNillableUpdatedAt sets the updated_at field if the given value is not nil.
-func (du *DecisionUpdate) SetNillableUpdatedAt(t *time.Time) *DecisionUpdate {
-	if t != nil {
-		du.SetUpdatedAt(*t)
-	}
-	return du
-}
-
 // SetUntil sets the until field.
 func (du *DecisionUpdate) SetUntil(t time.Time) *DecisionUpdate {
 	du.mutation.SetUntil(t)
@@ -129,6 +121,87 @@ func (du *DecisionUpdate) ClearEndIP() *DecisionUpdate {
 	return du
 }
 
+// SetIPSize sets the ip_size field.
+func (du *DecisionUpdate) SetIPSize(i int64) *DecisionUpdate {
+	du.mutation.ResetIPSize()
+	du.mutation.SetIPSize(i)
+	return du
+}
+
+// SetNillableIPSize sets the ip_size field if the given value is not nil.
+func (du *DecisionUpdate) SetNillableIPSize(i *int64) *DecisionUpdate {
+	if i != nil {
+		du.SetIPSize(*i)
+	}
+	return du
+}
+
+// AddIPSize adds i to ip_size.
+func (du *DecisionUpdate) AddIPSize(i int64) *DecisionUpdate {
+	du.mutation.AddIPSize(i)
+	return du
+}
+
+// ClearIPSize clears the value of ip_size.
+func (du *DecisionUpdate) ClearIPSize() *DecisionUpdate {
+	du.mutation.ClearIPSize()
+	return du
+}
+
+// SetStartSuffix sets the start_suffix field.
+func (du *DecisionUpdate) SetStartSuffix(i int64) *DecisionUpdate {
+	du.mutation.ResetStartSuffix()
+	du.mutation.SetStartSuffix(i)
+	return du
+}
+
+// SetNillableStartSuffix sets the start_suffix field if the given value is not nil.
+func (du *DecisionUpdate) SetNillableStartSuffix(i *int64) *DecisionUpdate {
+	if i != nil {
+		du.SetStartSuffix(*i)
+	}
+	return du
+}
+
+// AddStartSuffix adds i to start_suffix.
+func (du *DecisionUpdate) AddStartSuffix(i int64) *DecisionUpdate {
+	du.mutation.AddStartSuffix(i)
+	return du
+}
+
+// ClearStartSuffix clears the value of start_suffix.
+func (du *DecisionUpdate) ClearStartSuffix() *DecisionUpdate {
+	du.mutation.ClearStartSuffix()
+	return du
+}
+
+// SetEndSuffix sets the end_suffix field.
+func (du *DecisionUpdate) SetEndSuffix(i int64) *DecisionUpdate {
+	du.mutation.ResetEndSuffix()
+	du.mutation.SetEndSuffix(i)
+	return du
+}
+
+// SetNillableEndSuffix sets the end_suffix field if the given value is not nil.
+func (du *DecisionUpdate) SetNillableEndSuffix(i *int64) *DecisionUpdate {
+	if i != nil {
+		du.SetEndSuffix(*i)
+	}
+	return du
+}
+
+// AddEndSuffix adds i to end_suffix.
+func (du *DecisionUpdate) AddEndSuffix(i int64) *DecisionUpdate {
+	du.mutation.AddEndSuffix(i)
+	return du
+}
+
+// ClearEndSuffix clears the value of end_suffix.
+func (du *DecisionUpdate) ClearEndSuffix() *DecisionUpdate {
+	du.mutation.ClearEndSuffix()
+	return du
+}
+
 // SetScope sets the scope field.
 func (du *DecisionUpdate) SetScope(s string) *DecisionUpdate {
 	du.mutation.SetScope(s)
@@ -197,6 +270,7 @@ func (du *DecisionUpdate) Save(ctx context.Context) (int, error) {
 		err      error
 		affected int
 	)
+	du.defaults()
 	if len(du.hooks) == 0 {
 		affected, err = du.sqlSave(ctx)
 	} else {
@@ -242,6 +316,14 @@ func (du *DecisionUpdate) ExecX(ctx context.Context) {
 	}
 }
 
+// defaults sets the default values of the builder before save.
+func (du *DecisionUpdate) defaults() {
+	if _, ok := du.mutation.UpdatedAt(); !ok {
+		v := decision.UpdateDefaultUpdatedAt()
+		du.mutation.SetUpdatedAt(v)
+	}
+}
+
 func (du *DecisionUpdate) sqlSave(ctx context.Context) (n int, err error) {
 	_spec := &sqlgraph.UpdateSpec{
 		Node: &sqlgraph.NodeSpec{
@@ -335,6 +417,66 @@ func (du *DecisionUpdate) sqlSave(ctx context.Context) (n int, err error) {
 			Column: decision.FieldEndIP,
 		})
 	}
+	if value, ok := du.mutation.IPSize(); ok {
+		_spec.Fields.Set = append(_spec.Fields.Set, &sqlgraph.FieldSpec{
+			Type:   field.TypeInt64,
+			Value:  value,
+			Column: decision.FieldIPSize,
+		})
+	}
+	if value, ok := du.mutation.AddedIPSize(); ok {
+		_spec.Fields.Add = append(_spec.Fields.Add, &sqlgraph.FieldSpec{
+			Type:   field.TypeInt64,
+			Value:  value,
+			Column: decision.FieldIPSize,
+		})
+	}
+	if du.mutation.IPSizeCleared() {
+		_spec.Fields.Clear = append(_spec.Fields.Clear, &sqlgraph.FieldSpec{
+			Type:   field.TypeInt64,
+			Column: decision.FieldIPSize,
+		})
+	}
+	if value, ok := du.mutation.StartSuffix(); ok {
+		_spec.Fields.Set = append(_spec.Fields.Set, &sqlgraph.FieldSpec{
+			Type:   field.TypeInt64,
+			Value:  value,
+			Column: decision.FieldStartSuffix,
+		})
+	}
+	if value, ok := du.mutation.AddedStartSuffix(); ok {
+		_spec.Fields.Add = append(_spec.Fields.Add, &sqlgraph.FieldSpec{
+			Type:   field.TypeInt64,
+			Value:  value,
+			Column: decision.FieldStartSuffix,
+		})
+	}
+	if du.mutation.StartSuffixCleared() {
+		_spec.Fields.Clear = append(_spec.Fields.Clear, &sqlgraph.FieldSpec{
+			Type:   field.TypeInt64,
+			Column: decision.FieldStartSuffix,
+		})
+	}
+	if value, ok := du.mutation.EndSuffix(); ok {
+		_spec.Fields.Set = append(_spec.Fields.Set, &sqlgraph.FieldSpec{
+			Type:   field.TypeInt64,
+			Value:  value,
+			Column: decision.FieldEndSuffix,
+		})
+	}
+	if value, ok := du.mutation.AddedEndSuffix(); ok {
+		_spec.Fields.Add = append(_spec.Fields.Add, &sqlgraph.FieldSpec{
+			Type:   field.TypeInt64,
+			Value:  value,
+			Column: decision.FieldEndSuffix,
+		})
+	}
+	if du.mutation.EndSuffixCleared() {
+		_spec.Fields.Clear = append(_spec.Fields.Clear, &sqlgraph.FieldSpec{
+			Type:   field.TypeInt64,
+			Column: decision.FieldEndSuffix,
+		})
+	}
 	if value, ok := du.mutation.Scope(); ok {
 		_spec.Fields.Set = append(_spec.Fields.Set, &sqlgraph.FieldSpec{
 			Type:   field.TypeString,
@@ -436,14 +578,6 @@ func (duo *DecisionUpdateOne) SetUpdatedAt(t time.Time) *DecisionUpdateOne {
 	return duo
 }
 
-// SetNillableUpdatedAt sets the updated_at field if the given value is not nil.
-func (duo *DecisionUpdateOne) SetNillableUpdatedAt(t *time.Time) *DecisionUpdateOne {
-	if t != nil {
-		duo.SetUpdatedAt(*t)
-	}
-	return duo
-}
-
 // SetUntil sets the until field.
 func (duo *DecisionUpdateOne) SetUntil(t time.Time) *DecisionUpdateOne {
 	duo.mutation.SetUntil(t)
@@ -516,6 +650,87 @@ func (duo *DecisionUpdateOne) ClearEndIP() *DecisionUpdateOne {
 	return duo
 }
 
+// SetIPSize sets the ip_size field.
+func (duo *DecisionUpdateOne) SetIPSize(i int64) *DecisionUpdateOne {
+	duo.mutation.ResetIPSize()
+	duo.mutation.SetIPSize(i)
+	return duo
+}
+
+// SetNillableIPSize sets the ip_size field if the given value is not nil.
+func (duo *DecisionUpdateOne) SetNillableIPSize(i *int64) *DecisionUpdateOne {
+	if i != nil {
+		duo.SetIPSize(*i)
+	}
+	return duo
+}
+
+// AddIPSize adds i to ip_size.
+func (duo *DecisionUpdateOne) AddIPSize(i int64) *DecisionUpdateOne {
+	duo.mutation.AddIPSize(i)
+	return duo
+}
+
+// ClearIPSize clears the value of ip_size.
+func (duo *DecisionUpdateOne) ClearIPSize() *DecisionUpdateOne {
+	duo.mutation.ClearIPSize()
+	return duo
+}
+
+// SetStartSuffix sets the start_suffix field.
+func (duo *DecisionUpdateOne) SetStartSuffix(i int64) *DecisionUpdateOne {
+	duo.mutation.ResetStartSuffix()
+	duo.mutation.SetStartSuffix(i)
+	return duo
+}
+
+// SetNillableStartSuffix sets the start_suffix field if the given value is not nil.
+func (duo *DecisionUpdateOne) SetNillableStartSuffix(i *int64) *DecisionUpdateOne {
+	if i != nil {
+		duo.SetStartSuffix(*i)
+	}
+	return duo
+}
+
+// AddStartSuffix adds i to start_suffix.
+func (duo *DecisionUpdateOne) AddStartSuffix(i int64) *DecisionUpdateOne {
+	duo.mutation.AddStartSuffix(i)
+	return duo
+}
+
+// ClearStartSuffix clears the value of start_suffix.
+func (duo *DecisionUpdateOne) ClearStartSuffix() *DecisionUpdateOne {
+	duo.mutation.ClearStartSuffix()
+	return duo
+}
+
+// SetEndSuffix sets the end_suffix field.
+func (duo *DecisionUpdateOne) SetEndSuffix(i int64) *DecisionUpdateOne {
+	duo.mutation.ResetEndSuffix()
+	duo.mutation.SetEndSuffix(i)
+	return duo
+}
+
+// SetNillableEndSuffix sets the end_suffix field if the given value is not nil.
+func (duo *DecisionUpdateOne) SetNillableEndSuffix(i *int64) *DecisionUpdateOne {
+	if i != nil {
+		duo.SetEndSuffix(*i)
+	}
+	return duo
+}
+
+// AddEndSuffix adds i to end_suffix.
+func (duo *DecisionUpdateOne) AddEndSuffix(i int64) *DecisionUpdateOne {
+	duo.mutation.AddEndSuffix(i)
+	return duo
+}
+
+// ClearEndSuffix clears the value of end_suffix.
+func (duo *DecisionUpdateOne) ClearEndSuffix() *DecisionUpdateOne {
+	duo.mutation.ClearEndSuffix()
+	return duo
+}
+
 // SetScope sets the scope field.
 func (duo *DecisionUpdateOne) SetScope(s string) *DecisionUpdateOne {
 	duo.mutation.SetScope(s)
@@ -584,6 +799,7 @@ func (duo *DecisionUpdateOne) Save(ctx context.Context) (*Decision, error) {
 		err  error
 		node *Decision
 	)
+	duo.defaults()
 	if len(duo.hooks) == 0 {
 		node, err = duo.sqlSave(ctx)
 	} else {
@@ -629,6 +845,14 @@ func (duo *DecisionUpdateOne) ExecX(ctx context.Context) {
 	}
 }
 
+// defaults sets the default values of the builder before save.
+func (duo *DecisionUpdateOne) defaults() {
+	if _, ok := duo.mutation.UpdatedAt(); !ok {
+		v := decision.UpdateDefaultUpdatedAt()
+		duo.mutation.SetUpdatedAt(v)
+	}
+}
+
 func (duo *DecisionUpdateOne) sqlSave(ctx context.Context) (_node *Decision, err error) {
 	_spec := &sqlgraph.UpdateSpec{
 		Node: &sqlgraph.NodeSpec{
@@ -720,6 +944,66 @@ func (duo *DecisionUpdateOne) sqlSave(ctx context.Context) (_node *Decision, err
 			Column: decision.FieldEndIP,
 		})
 	}
+	if value, ok := duo.mutation.IPSize(); ok {
+		_spec.Fields.Set = append(_spec.Fields.Set, &sqlgraph.FieldSpec{
+			Type:   field.TypeInt64,
+			Value:  value,
+			Column: decision.FieldIPSize,
+		})
+	}
+	if value, ok := duo.mutation.AddedIPSize(); ok {
+		_spec.Fields.Add = append(_spec.Fields.Add, &sqlgraph.FieldSpec{
+			Type:   field.TypeInt64,
+			Value:  value,
+			Column: decision.FieldIPSize,
+		})
+	}
+	if duo.mutation.IPSizeCleared() {
+		_spec.Fields.Clear = append(_spec.Fields.Clear, &sqlgraph.FieldSpec{
+			Type:   field.TypeInt64,
+			Column: decision.FieldIPSize,
+		})
+	}
+	if value, ok := duo.mutation.StartSuffix(); ok {
+		_spec.Fields.Set = append(_spec.Fields.Set, &sqlgraph.FieldSpec{
+			Type:   field.TypeInt64,
+			Value:  value,
+			Column: decision.FieldStartSuffix,
+		})
+	}
+	if value, ok := duo.mutation.AddedStartSuffix(); ok {
+		_spec.Fields.Add = append(_spec.Fields.Add, &sqlgraph.FieldSpec{
+			Type:   field.TypeInt64,
+			Value:  value,
+			Column: decision.FieldStartSuffix,
+		})
+	}
+	if duo.mutation.StartSuffixCleared() {
+		_spec.Fields.Clear = append(_spec.Fields.Clear, &sqlgraph.FieldSpec{
+			Type:   field.TypeInt64,
+			Column: decision.FieldStartSuffix,
+		})
+	}
+	if value, ok := duo.mutation.EndSuffix(); ok {
+		_spec.Fields.Set = append(_spec.Fields.Set, &sqlgraph.FieldSpec{
+			Type:   field.TypeInt64,
+			Value:  value,
+			Column: decision.FieldEndSuffix,
+		})
+	}
+	if value, ok := duo.mutation.AddedEndSuffix(); ok {
+		_spec.Fields.Add = append(_spec.Fields.Add, &sqlgraph.FieldSpec{
+			Type:   field.TypeInt64,
+			Value:  value,
+			Column: decision.FieldEndSuffix,
+		})
+	}
+	if duo.mutation.EndSuffixCleared() {
+		_spec.Fields.Clear = append(_spec.Fields.Clear, &sqlgraph.FieldSpec{
+			Type:   field.TypeInt64,
+			Column: decision.FieldEndSuffix,
+		})
+	}
 	if value, ok := duo.mutation.Scope(); ok {
 		_spec.Fields.Set = append(_spec.Fields.Set, &sqlgraph.FieldSpec{
 			Type:   field.TypeString,
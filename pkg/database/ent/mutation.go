@@ -68,6 +68,13 @@ type AlertMutation struct {
 	scenarioVersion    *string
 	scenarioHash       *string
 	simulated          *bool
+	tags               *string
+	batchId            *string
+	acknowledged       *bool
+	acknowledgedAt     *time.Time
+	acknowledgedBy     *string
+	archived           *bool
+	archivedAt         *time.Time
 	clearedFields      map[string]struct{}
 	owner              *int
 	clearedowner       bool
@@ -154,6 +161,12 @@ func (m AlertMutation) Tx() (*Tx, error) {
 	return tx, nil
 }
 
+// SetID sets the value of the id field. Note that, this
+// operation is accepted only on Alert creation.
+func (m *AlertMutation) SetID(id int) {
+	m.id = &id
+}
+
 // ID returns the id value in the mutation. Note that, the id
 // is available only if it was provided to the builder.
 func (m *AlertMutation) ID() (id int, exists bool) {
@@ -1295,6 +1308,356 @@ func (m *AlertMutation) ResetSimulated() {
 	m.simulated = nil
 }
 
+// SetTags sets the tags field.
+func (m *AlertMutation) SetTags(s string) {
+	m.tags = &s
+}
+
+// Tags returns the tags value in the mutation.
+func (m *AlertMutation) Tags() (r string, exists bool) {
+	v := m.tags
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldTags returns the old tags value of the Alert.
+// If the Alert object wasn't provided to the builder, the object is fetched
+// from the database.
+// An error is returned if the mutation operation is not UpdateOne, or database query fails.
+func (m *AlertMutation) OldTags(ctx context.Context) (v string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, fmt.Errorf("OldTags is allowed only on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, fmt.Errorf("OldTags requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldTags: %w", err)
+	}
+	return oldValue.Tags, nil
+}
+
+// ClearTags clears the value of tags.
+func (m *AlertMutation) ClearTags() {
+	m.tags = nil
+	m.clearedFields[alert.FieldTags] = struct{}{}
+}
+
+// TagsCleared returns if the field tags was cleared in this mutation.
+func (m *AlertMutation) TagsCleared() bool {
+	_, ok := m.clearedFields[alert.FieldTags]
+	return ok
+}
+
+// ResetTags reset all changes of the "tags" field.
+func (m *AlertMutation) ResetTags() {
+	m.tags = nil
+	delete(m.clearedFields, alert.FieldTags)
+}
+
+// SetBatchId sets the batchId field.
+func (m *AlertMutation) SetBatchId(s string) {
+	m.batchId = &s
+}
+
+// BatchId returns the batchId value in the mutation.
+func (m *AlertMutation) BatchId() (r string, exists bool) {
+	v := m.batchId
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldBatchId returns the old batchId value of the Alert.
+// If the Alert object wasn't provided to the builder, the object is fetched
+// from the database.
+// An error is returned if the mutation operation is not UpdateOne, or database query fails.
+func (m *AlertMutation) OldBatchId(ctx context.Context) (v string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, fmt.Errorf("OldBatchId is allowed only on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, fmt.Errorf("OldBatchId requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldBatchId: %w", err)
+	}
+	return oldValue.BatchId, nil
+}
+
+// ClearBatchId clears the value of batchId.
+func (m *AlertMutation) ClearBatchId() {
+	m.batchId = nil
+	m.clearedFields[alert.FieldBatchId] = struct{}{}
+}
+
+// BatchIdCleared returns if the field batchId was cleared in this mutation.
+func (m *AlertMutation) BatchIdCleared() bool {
+	_, ok := m.clearedFields[alert.FieldBatchId]
+	return ok
+}
+
+// ResetBatchId reset all changes of the "batchId" field.
+func (m *AlertMutation) ResetBatchId() {
+	m.batchId = nil
+	delete(m.clearedFields, alert.FieldBatchId)
+}
+
+// SetAcknowledged sets the acknowledged field.
+func (m *AlertMutation) SetAcknowledged(b bool) {
+	m.acknowledged = &b
+}
+
+// Acknowledged returns the acknowledged value in the mutation.
+func (m *AlertMutation) Acknowledged() (r bool, exists bool) {
+	v := m.acknowledged
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldAcknowledged returns the old acknowledged value of the Alert.
+// If the Alert object wasn't provided to the builder, the object is fetched
+// from the database.
+// An error is returned if the mutation operation is not UpdateOne, or database query fails.
+func (m *AlertMutation) OldAcknowledged(ctx context.Context) (v bool, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, fmt.Errorf("OldAcknowledged is allowed only on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, fmt.Errorf("OldAcknowledged requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldAcknowledged: %w", err)
+	}
+	return oldValue.Acknowledged, nil
+}
+
+// ClearAcknowledged clears the value of acknowledged.
+func (m *AlertMutation) ClearAcknowledged() {
+	m.acknowledged = nil
+	m.clearedFields[alert.FieldAcknowledged] = struct{}{}
+}
+
+// AcknowledgedCleared returns if the field acknowledged was cleared in this mutation.
+func (m *AlertMutation) AcknowledgedCleared() bool {
+	_, ok := m.clearedFields[alert.FieldAcknowledged]
+	return ok
+}
+
+// ResetAcknowledged reset all changes of the "acknowledged" field.
+func (m *AlertMutation) ResetAcknowledged() {
+	m.acknowledged = nil
+	delete(m.clearedFields, alert.FieldAcknowledged)
+}
+
+// SetAcknowledgedAt sets the acknowledgedAt field.
+func (m *AlertMutation) SetAcknowledgedAt(t time.Time) {
+	m.acknowledgedAt = &t
+}
+
+// AcknowledgedAt returns the acknowledgedAt value in the mutation.
+func (m *AlertMutation) AcknowledgedAt() (r time.Time, exists bool) {
+	v := m.acknowledgedAt
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldAcknowledgedAt returns the old acknowledgedAt value of the Alert.
+// If the Alert object wasn't provided to the builder, the object is fetched
+// from the database.
+// An error is returned if the mutation operation is not UpdateOne, or database query fails.
+func (m *AlertMutation) OldAcknowledgedAt(ctx context.Context) (v time.Time, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, fmt.Errorf("OldAcknowledgedAt is allowed only on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, fmt.Errorf("OldAcknowledgedAt requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldAcknowledgedAt: %w", err)
+	}
+	return oldValue.AcknowledgedAt, nil
+}
+
+// ClearAcknowledgedAt clears the value of acknowledgedAt.
+func (m *AlertMutation) ClearAcknowledgedAt() {
+	m.acknowledgedAt = nil
+	m.clearedFields[alert.FieldAcknowledgedAt] = struct{}{}
+}
+
+// AcknowledgedAtCleared returns if the field acknowledgedAt was cleared in this mutation.
+func (m *AlertMutation) AcknowledgedAtCleared() bool {
+	_, ok := m.clearedFields[alert.FieldAcknowledgedAt]
+	return ok
+}
+
+// ResetAcknowledgedAt reset all changes of the "acknowledgedAt" field.
+func (m *AlertMutation) ResetAcknowledgedAt() {
+	m.acknowledgedAt = nil
+	delete(m.clearedFields, alert.FieldAcknowledgedAt)
+}
+
+// SetAcknowledgedBy sets the acknowledgedBy field.
+func (m *AlertMutation) SetAcknowledgedBy(s string) {
+	m.acknowledgedBy = &s
+}
+
+// AcknowledgedBy returns the acknowledgedBy value in the mutation.
+func (m *AlertMutation) AcknowledgedBy() (r string, exists bool) {
+	v := m.acknowledgedBy
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldAcknowledgedBy returns the old acknowledgedBy value of the Alert.
+// If the Alert object wasn't provided to the builder, the object is fetched
+// from the database.
+// An error is returned if the mutation operation is not UpdateOne, or database query fails.
+func (m *AlertMutation) OldAcknowledgedBy(ctx context.Context) (v string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, fmt.Errorf("OldAcknowledgedBy is allowed only on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, fmt.Errorf("OldAcknowledgedBy requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldAcknowledgedBy: %w", err)
+	}
+	return oldValue.AcknowledgedBy, nil
+}
+
+// ClearAcknowledgedBy clears the value of acknowledgedBy.
+func (m *AlertMutation) ClearAcknowledgedBy() {
+	m.acknowledgedBy = nil
+	m.clearedFields[alert.FieldAcknowledgedBy] = struct{}{}
+}
+
+// AcknowledgedByCleared returns if the field acknowledgedBy was cleared in this mutation.
+func (m *AlertMutation) AcknowledgedByCleared() bool {
+	_, ok := m.clearedFields[alert.FieldAcknowledgedBy]
+	return ok
+}
+
+// ResetAcknowledgedBy reset all changes of the "acknowledgedBy" field.
+func (m *AlertMutation) ResetAcknowledgedBy() {
+	m.acknowledgedBy = nil
+	delete(m.clearedFields, alert.FieldAcknowledgedBy)
+}
+
+// SetArchived sets the archived field.
+func (m *AlertMutation) SetArchived(b bool) {
+	m.archived = &b
+}
+
+// Archived returns the archived value in the mutation.
+func (m *AlertMutation) Archived() (r bool, exists bool) {
+	v := m.archived
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldArchived returns the old archived value of the Alert.
+// If the Alert object wasn't provided to the builder, the object is fetched
+// from the database.
+// An error is returned if the mutation operation is not UpdateOne, or database query fails.
+func (m *AlertMutation) OldArchived(ctx context.Context) (v bool, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, fmt.Errorf("OldArchived is allowed only on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, fmt.Errorf("OldArchived requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldArchived: %w", err)
+	}
+	return oldValue.Archived, nil
+}
+
+// ClearArchived clears the value of archived.
+func (m *AlertMutation) ClearArchived() {
+	m.archived = nil
+	m.clearedFields[alert.FieldArchived] = struct{}{}
+}
+
+// ArchivedCleared returns if the field archived was cleared in this mutation.
+func (m *AlertMutation) ArchivedCleared() bool {
+	_, ok := m.clearedFields[alert.FieldArchived]
+	return ok
+}
+
+// ResetArchived reset all changes of the "archived" field.
+func (m *AlertMutation) ResetArchived() {
+	m.archived = nil
+	delete(m.clearedFields, alert.FieldArchived)
+}
+
+// SetArchivedAt sets the archivedAt field.
+func (m *AlertMutation) SetArchivedAt(t time.Time) {
+	m.archivedAt = &t
+}
+
+// ArchivedAt returns the archivedAt value in the mutation.
+func (m *AlertMutation) ArchivedAt() (r time.Time, exists bool) {
+	v := m.archivedAt
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldArchivedAt returns the old archivedAt value of the Alert.
+// If the Alert object wasn't provided to the builder, the object is fetched
+// from the database.
+// An error is returned if the mutation operation is not UpdateOne, or database query fails.
+func (m *AlertMutation) OldArchivedAt(ctx context.Context) (v time.Time, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, fmt.Errorf("OldArchivedAt is allowed only on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, fmt.Errorf("OldArchivedAt requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldArchivedAt: %w", err)
+	}
+	return oldValue.ArchivedAt, nil
+}
+
+// ClearArchivedAt clears the value of archivedAt.
+func (m *AlertMutation) ClearArchivedAt() {
+	m.archivedAt = nil
+	m.clearedFields[alert.FieldArchivedAt] = struct{}{}
+}
+
+// ArchivedAtCleared returns if the field archivedAt was cleared in this mutation.
+func (m *AlertMutation) ArchivedAtCleared() bool {
+	_, ok := m.clearedFields[alert.FieldArchivedAt]
+	return ok
+}
+
+// ResetArchivedAt reset all changes of the "archivedAt" field.
+func (m *AlertMutation) ResetArchivedAt() {
+	m.archivedAt = nil
+	delete(m.clearedFields, alert.FieldArchivedAt)
+}
+
 // SetOwnerID sets the owner edge to Machine by id.
 func (m *AlertMutation) SetOwnerID(id int) {
 	m.owner = &id
@@ -1507,7 +1870,7 @@ func (m *AlertMutation) Type() string {
 // this mutation. Note that, in order to get all numeric
 // fields that were in/decremented, call AddedFields().
 func (m *AlertMutation) Fields() []string {
-	fields := make([]string, 0, 22)
+	fields := make([]string, 0, 29)
 	if m.created_at != nil {
 		fields = append(fields, alert.FieldCreatedAt)
 	}
@@ -1574,6 +1937,27 @@ func (m *AlertMutation) Fields() []string {
 	if m.simulated != nil {
 		fields = append(fields, alert.FieldSimulated)
 	}
+	if m.tags != nil {
+		fields = append(fields, alert.FieldTags)
+	}
+	if m.batchId != nil {
+		fields = append(fields, alert.FieldBatchId)
+	}
+	if m.acknowledged != nil {
+		fields = append(fields, alert.FieldAcknowledged)
+	}
+	if m.acknowledgedAt != nil {
+		fields = append(fields, alert.FieldAcknowledgedAt)
+	}
+	if m.acknowledgedBy != nil {
+		fields = append(fields, alert.FieldAcknowledgedBy)
+	}
+	if m.archived != nil {
+		fields = append(fields, alert.FieldArchived)
+	}
+	if m.archivedAt != nil {
+		fields = append(fields, alert.FieldArchivedAt)
+	}
 	return fields
 }
 
@@ -1626,6 +2010,20 @@ func (m *AlertMutation) Field(name string) (ent.Value, bool) {
 		return m.ScenarioHash()
 	case alert.FieldSimulated:
 		return m.Simulated()
+	case alert.FieldTags:
+		return m.Tags()
+	case alert.FieldBatchId:
+		return m.BatchId()
+	case alert.FieldAcknowledged:
+		return m.Acknowledged()
+	case alert.FieldAcknowledgedAt:
+		return m.AcknowledgedAt()
+	case alert.FieldAcknowledgedBy:
+		return m.AcknowledgedBy()
+	case alert.FieldArchived:
+		return m.Archived()
+	case alert.FieldArchivedAt:
+		return m.ArchivedAt()
 	}
 	return nil, false
 }
@@ -1679,6 +2077,20 @@ func (m *AlertMutation) OldField(ctx context.Context, name string) (ent.Value, e
 		return m.OldScenarioHash(ctx)
 	case alert.FieldSimulated:
 		return m.OldSimulated(ctx)
+	case alert.FieldTags:
+		return m.OldTags(ctx)
+	case alert.FieldBatchId:
+		return m.OldBatchId(ctx)
+	case alert.FieldAcknowledged:
+		return m.OldAcknowledged(ctx)
+	case alert.FieldAcknowledgedAt:
+		return m.OldAcknowledgedAt(ctx)
+	case alert.FieldAcknowledgedBy:
+		return m.OldAcknowledgedBy(ctx)
+	case alert.FieldArchived:
+		return m.OldArchived(ctx)
+	case alert.FieldArchivedAt:
+		return m.OldArchivedAt(ctx)
 	}
 	return nil, fmt.Errorf("unknown Alert field %s", name)
 }
@@ -1842,6 +2254,55 @@ func (m *AlertMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetSimulated(v)
 		return nil
+	case alert.FieldTags:
+		v, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetTags(v)
+		return nil
+	case alert.FieldBatchId:
+		v, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetBatchId(v)
+		return nil
+	case alert.FieldAcknowledged:
+		v, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetAcknowledged(v)
+		return nil
+	case alert.FieldAcknowledgedAt:
+		v, ok := value.(time.Time)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetAcknowledgedAt(v)
+		return nil
+	case alert.FieldAcknowledgedBy:
+		v, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetAcknowledgedBy(v)
+		return nil
+	case alert.FieldArchived:
+		v, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetArchived(v)
+		return nil
+	case alert.FieldArchivedAt:
+		v, ok := value.(time.Time)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetArchivedAt(v)
+		return nil
 	}
 	return fmt.Errorf("unknown Alert field %s", name)
 }
@@ -1977,6 +2438,27 @@ func (m *AlertMutation) ClearedFields() []string {
 	if m.FieldCleared(alert.FieldScenarioHash) {
 		fields = append(fields, alert.FieldScenarioHash)
 	}
+	if m.FieldCleared(alert.FieldTags) {
+		fields = append(fields, alert.FieldTags)
+	}
+	if m.FieldCleared(alert.FieldBatchId) {
+		fields = append(fields, alert.FieldBatchId)
+	}
+	if m.FieldCleared(alert.FieldAcknowledged) {
+		fields = append(fields, alert.FieldAcknowledged)
+	}
+	if m.FieldCleared(alert.FieldAcknowledgedAt) {
+		fields = append(fields, alert.FieldAcknowledgedAt)
+	}
+	if m.FieldCleared(alert.FieldAcknowledgedBy) {
+		fields = append(fields, alert.FieldAcknowledgedBy)
+	}
+	if m.FieldCleared(alert.FieldArchived) {
+		fields = append(fields, alert.FieldArchived)
+	}
+	if m.FieldCleared(alert.FieldArchivedAt) {
+		fields = append(fields, alert.FieldArchivedAt)
+	}
 	return fields
 }
 
@@ -2045,6 +2527,27 @@ func (m *AlertMutation) ClearField(name string) error {
 	case alert.FieldScenarioHash:
 		m.ClearScenarioHash()
 		return nil
+	case alert.FieldTags:
+		m.ClearTags()
+		return nil
+	case alert.FieldBatchId:
+		m.ClearBatchId()
+		return nil
+	case alert.FieldAcknowledged:
+		m.ClearAcknowledged()
+		return nil
+	case alert.FieldAcknowledgedAt:
+		m.ClearAcknowledgedAt()
+		return nil
+	case alert.FieldAcknowledgedBy:
+		m.ClearAcknowledgedBy()
+		return nil
+	case alert.FieldArchived:
+		m.ClearArchived()
+		return nil
+	case alert.FieldArchivedAt:
+		m.ClearArchivedAt()
+		return nil
 	}
 	return fmt.Errorf("unknown Alert nullable field %s", name)
 }
@@ -2120,6 +2623,27 @@ func (m *AlertMutation) ResetField(name string) error {
 	case alert.FieldSimulated:
 		m.ResetSimulated()
 		return nil
+	case alert.FieldTags:
+		m.ResetTags()
+		return nil
+	case alert.FieldBatchId:
+		m.ResetBatchId()
+		return nil
+	case alert.FieldAcknowledged:
+		m.ResetAcknowledged()
+		return nil
+	case alert.FieldAcknowledgedAt:
+		m.ResetAcknowledgedAt()
+		return nil
+	case alert.FieldAcknowledgedBy:
+		m.ResetAcknowledgedBy()
+		return nil
+	case alert.FieldArchived:
+		m.ResetArchived()
+		return nil
+	case alert.FieldArchivedAt:
+		m.ResetArchivedAt()
+		return nil
 	}
 	return fmt.Errorf("unknown Alert field %s", name)
 }
@@ -3153,27 +3677,33 @@ func (m *BouncerMutation) ResetEdge(name string) error {
 // nodes in the graph.
 type DecisionMutation struct {
 	config
-	op            Op
-	typ           string
-	id            *int
-	created_at    *time.Time
-	updated_at    *time.Time
-	until         *time.Time
-	scenario      *string
-	_type         *string
-	start_ip      *int64
-	addstart_ip   *int64
-	end_ip        *int64
-	addend_ip     *int64
-	scope         *string
-	value         *string
-	origin        *string
-	simulated     *bool
-	clearedFields map[string]struct{}
-	owner         *int
-	clearedowner  bool
-	done          bool
-	oldValue      func(context.Context) (*Decision, error)
+	op              Op
+	typ             string
+	id              *int
+	created_at      *time.Time
+	updated_at      *time.Time
+	until           *time.Time
+	scenario        *string
+	_type           *string
+	start_ip        *int64
+	addstart_ip     *int64
+	end_ip          *int64
+	addend_ip       *int64
+	ip_size         *int64
+	addip_size      *int64
+	start_suffix    *int64
+	addstart_suffix *int64
+	end_suffix      *int64
+	addend_suffix   *int64
+	scope           *string
+	value           *string
+	origin          *string
+	simulated       *bool
+	clearedFields   map[string]struct{}
+	owner           *int
+	clearedowner    bool
+	done            bool
+	oldValue        func(context.Context) (*Decision, error)
 }
 
 var _ ent.Mutation = (*DecisionMutation)(nil)
@@ -3582,6 +4112,219 @@ func (m *DecisionMutation) ResetEndIP() {
 	delete(m.clearedFields, decision.FieldEndIP)
 }
 
+// SetIPSize sets the ip_size field.
+func (m *DecisionMutation) SetIPSize(i int64) {
+	m.ip_size = &i
+	m.addip_size = nil
+}
+
+// IPSize returns the ip_size value in the mutation.
+func (m *DecisionMutation) IPSize() (r int64, exists bool) {
+	v := m.ip_size
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldIPSize returns the old ip_size value of the Decision.
+// If the Decision object wasn't provided to the builder, the object is fetched
+// from the database.
+// An error is returned if the mutation operation is not UpdateOne, or database query fails.
+func (m *DecisionMutation) OldIPSize(ctx context.Context) (v int64, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, fmt.Errorf("OldIPSize is allowed only on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, fmt.Errorf("OldIPSize requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldIPSize: %w", err)
+	}
+	return oldValue.IPSize, nil
+}
+
+// AddIPSize adds i to ip_size.
+func (m *DecisionMutation) AddIPSize(i int64) {
+	if m.addip_size != nil {
+		*m.addip_size += i
+	} else {
+		m.addip_size = &i
+	}
+}
+
+// AddedIPSize returns the value that was added to the ip_size field in this mutation.
+func (m *DecisionMutation) AddedIPSize() (r int64, exists bool) {
+	v := m.addip_size
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ClearIPSize clears the value of ip_size.
+func (m *DecisionMutation) ClearIPSize() {
+	m.ip_size = nil
+	m.addip_size = nil
+	m.clearedFields[decision.FieldIPSize] = struct{}{}
+}
+
+// IPSizeCleared returns if the field ip_size was cleared in this mutation.
+func (m *DecisionMutation) IPSizeCleared() bool {
+	_, ok := m.clearedFields[decision.FieldIPSize]
+	return ok
+}
+
+// ResetIPSize reset all changes of the "ip_size" field.
+func (m *DecisionMutation) ResetIPSize() {
+	m.ip_size = nil
+	m.addip_size = nil
+	delete(m.clearedFields, decision.FieldIPSize)
+}
+
+// SetStartSuffix sets the start_suffix field.
+func (m *DecisionMutation) SetStartSuffix(i int64) {
+	m.start_suffix = &i
+	m.addstart_suffix = nil
+}
+
+// StartSuffix returns the start_suffix value in the mutation.
+func (m *DecisionMutation) StartSuffix() (r int64, exists bool) {
+	v := m.start_suffix
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldStartSuffix returns the old start_suffix value of the Decision.
+// If the Decision object wasn't provided to the builder, the object is fetched
+// from the database.
+// An error is returned if the mutation operation is not UpdateOne, or database query fails.
+func (m *DecisionMutation) OldStartSuffix(ctx context.Context) (v int64, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, fmt.Errorf("OldStartSuffix is allowed only on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, fmt.Errorf("OldStartSuffix requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldStartSuffix: %w", err)
+	}
+	return oldValue.StartSuffix, nil
+}
+
+// AddStartSuffix adds i to start_suffix.
+func (m *DecisionMutation) AddStartSuffix(i int64) {
+	if m.addstart_suffix != nil {
+		*m.addstart_suffix += i
+	} else {
+		m.addstart_suffix = &i
+	}
+}
+
+// AddedStartSuffix returns the value that was added to the start_suffix field in this mutation.
+func (m *DecisionMutation) AddedStartSuffix() (r int64, exists bool) {
+	v := m.addstart_suffix
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ClearStartSuffix clears the value of start_suffix.
+func (m *DecisionMutation) ClearStartSuffix() {
+	m.start_suffix = nil
+	m.addstart_suffix = nil
+	m.clearedFields[decision.FieldStartSuffix] = struct{}{}
+}
+
+// StartSuffixCleared returns if the field start_suffix was cleared in this mutation.
+func (m *DecisionMutation) StartSuffixCleared() bool {
+	_, ok := m.clearedFields[decision.FieldStartSuffix]
+	return ok
+}
+
+// ResetStartSuffix reset all changes of the "start_suffix" field.
+func (m *DecisionMutation) ResetStartSuffix() {
+	m.start_suffix = nil
+	m.addstart_suffix = nil
+	delete(m.clearedFields, decision.FieldStartSuffix)
+}
+
+// SetEndSuffix sets the end_suffix field.
+func (m *DecisionMutation) SetEndSuffix(i int64) {
+	m.end_suffix = &i
+	m.addend_suffix = nil
+}
+
+// EndSuffix returns the end_suffix value in the mutation.
+func (m *DecisionMutation) EndSuffix() (r int64, exists bool) {
+	v := m.end_suffix
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldEndSuffix returns the old end_suffix value of the Decision.
+// If the Decision object wasn't provided to the builder, the object is fetched
+// from the database.
+// An error is returned if the mutation operation is not UpdateOne, or database query fails.
+func (m *DecisionMutation) OldEndSuffix(ctx context.Context) (v int64, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, fmt.Errorf("OldEndSuffix is allowed only on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, fmt.Errorf("OldEndSuffix requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldEndSuffix: %w", err)
+	}
+	return oldValue.EndSuffix, nil
+}
+
+// AddEndSuffix adds i to end_suffix.
+func (m *DecisionMutation) AddEndSuffix(i int64) {
+	if m.addend_suffix != nil {
+		*m.addend_suffix += i
+	} else {
+		m.addend_suffix = &i
+	}
+}
+
+// AddedEndSuffix returns the value that was added to the end_suffix field in this mutation.
+func (m *DecisionMutation) AddedEndSuffix() (r int64, exists bool) {
+	v := m.addend_suffix
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ClearEndSuffix clears the value of end_suffix.
+func (m *DecisionMutation) ClearEndSuffix() {
+	m.end_suffix = nil
+	m.addend_suffix = nil
+	m.clearedFields[decision.FieldEndSuffix] = struct{}{}
+}
+
+// EndSuffixCleared returns if the field end_suffix was cleared in this mutation.
+func (m *DecisionMutation) EndSuffixCleared() bool {
+	_, ok := m.clearedFields[decision.FieldEndSuffix]
+	return ok
+}
+
+// ResetEndSuffix reset all changes of the "end_suffix" field.
+func (m *DecisionMutation) ResetEndSuffix() {
+	m.end_suffix = nil
+	m.addend_suffix = nil
+	delete(m.clearedFields, decision.FieldEndSuffix)
+}
+
 // SetScope sets the scope field.
 func (m *DecisionMutation) SetScope(s string) {
 	m.scope = &s
@@ -3783,7 +4526,7 @@ func (m *DecisionMutation) Type() string {
 // this mutation. Note that, in order to get all numeric
 // fields that were in/decremented, call AddedFields().
 func (m *DecisionMutation) Fields() []string {
-	fields := make([]string, 0, 11)
+	fields := make([]string, 0, 14)
 	if m.created_at != nil {
 		fields = append(fields, decision.FieldCreatedAt)
 	}
@@ -3805,6 +4548,15 @@ func (m *DecisionMutation) Fields() []string {
 	if m.end_ip != nil {
 		fields = append(fields, decision.FieldEndIP)
 	}
+	if m.ip_size != nil {
+		fields = append(fields, decision.FieldIPSize)
+	}
+	if m.start_suffix != nil {
+		fields = append(fields, decision.FieldStartSuffix)
+	}
+	if m.end_suffix != nil {
+		fields = append(fields, decision.FieldEndSuffix)
+	}
 	if m.scope != nil {
 		fields = append(fields, decision.FieldScope)
 	}
@@ -3839,6 +4591,12 @@ func (m *DecisionMutation) Field(name string) (ent.Value, bool) {
 		return m.StartIP()
 	case decision.FieldEndIP:
 		return m.EndIP()
+	case decision.FieldIPSize:
+		return m.IPSize()
+	case decision.FieldStartSuffix:
+		return m.StartSuffix()
+	case decision.FieldEndSuffix:
+		return m.EndSuffix()
 	case decision.FieldScope:
 		return m.Scope()
 	case decision.FieldValue:
@@ -3870,6 +4628,12 @@ func (m *DecisionMutation) OldField(ctx context.Context, name string) (ent.Value
 		return m.OldStartIP(ctx)
 	case decision.FieldEndIP:
 		return m.OldEndIP(ctx)
+	case decision.FieldIPSize:
+		return m.OldIPSize(ctx)
+	case decision.FieldStartSuffix:
+		return m.OldStartSuffix(ctx)
+	case decision.FieldEndSuffix:
+		return m.OldEndSuffix(ctx)
 	case decision.FieldScope:
 		return m.OldScope(ctx)
 	case decision.FieldValue:
@@ -3936,6 +4700,27 @@ func (m *DecisionMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetEndIP(v)
 		return nil
+	case decision.FieldIPSize:
+		v, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetIPSize(v)
+		return nil
+	case decision.FieldStartSuffix:
+		v, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetStartSuffix(v)
+		return nil
+	case decision.FieldEndSuffix:
+		v, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetEndSuffix(v)
+		return nil
 	case decision.FieldScope:
 		v, ok := value.(string)
 		if !ok {
@@ -3978,6 +4763,15 @@ func (m *DecisionMutation) AddedFields() []string {
 	if m.addend_ip != nil {
 		fields = append(fields, decision.FieldEndIP)
 	}
+	if m.addip_size != nil {
+		fields = append(fields, decision.FieldIPSize)
+	}
+	if m.addstart_suffix != nil {
+		fields = append(fields, decision.FieldStartSuffix)
+	}
+	if m.addend_suffix != nil {
+		fields = append(fields, decision.FieldEndSuffix)
+	}
 	return fields
 }
 
@@ -3990,6 +4784,12 @@ func (m *DecisionMutation) AddedField(name string) (ent.Value, bool) {
 		return m.AddedStartIP()
 	case decision.FieldEndIP:
 		return m.AddedEndIP()
+	case decision.FieldIPSize:
+		return m.AddedIPSize()
+	case decision.FieldStartSuffix:
+		return m.AddedStartSuffix()
+	case decision.FieldEndSuffix:
+		return m.AddedEndSuffix()
 	}
 	return nil, false
 }
@@ -4013,6 +4813,27 @@ func (m *DecisionMutation) AddField(name string, value ent.Value) error {
 		}
 		m.AddEndIP(v)
 		return nil
+	case decision.FieldIPSize:
+		v, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddIPSize(v)
+		return nil
+	case decision.FieldStartSuffix:
+		v, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddStartSuffix(v)
+		return nil
+	case decision.FieldEndSuffix:
+		v, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddEndSuffix(v)
+		return nil
 	}
 	return fmt.Errorf("unknown Decision numeric field %s", name)
 }
@@ -4027,6 +4848,15 @@ func (m *DecisionMutation) ClearedFields() []string {
 	if m.FieldCleared(decision.FieldEndIP) {
 		fields = append(fields, decision.FieldEndIP)
 	}
+	if m.FieldCleared(decision.FieldIPSize) {
+		fields = append(fields, decision.FieldIPSize)
+	}
+	if m.FieldCleared(decision.FieldStartSuffix) {
+		fields = append(fields, decision.FieldStartSuffix)
+	}
+	if m.FieldCleared(decision.FieldEndSuffix) {
+		fields = append(fields, decision.FieldEndSuffix)
+	}
 	return fields
 }
 
@@ -4047,6 +4877,15 @@ func (m *DecisionMutation) ClearField(name string) error {
 	case decision.FieldEndIP:
 		m.ClearEndIP()
 		return nil
+	case decision.FieldIPSize:
+		m.ClearIPSize()
+		return nil
+	case decision.FieldStartSuffix:
+		m.ClearStartSuffix()
+		return nil
+	case decision.FieldEndSuffix:
+		m.ClearEndSuffix()
+		return nil
 	}
 	return fmt.Errorf("unknown Decision nullable field %s", name)
 }
@@ -4077,6 +4916,15 @@ func (m *DecisionMutation) ResetField(name string) error {
 	case decision.FieldEndIP:
 		m.ResetEndIP()
 		return nil
+	case decision.FieldIPSize:
+		m.ResetIPSize()
+		return nil
+	case decision.FieldStartSuffix:
+		m.ResetStartSuffix()
+		return nil
+	case decision.FieldEndSuffix:
+		m.ResetEndSuffix()
+		return nil
 	case decision.FieldScope:
 		m.ResetScope()
 		return nil
@@ -21,37 +21,45 @@ func init() {
 	alertFields := schema.Alert{}.Fields()
 	_ = alertFields
 	// alertDescCreatedAt is the schema descriptor for created_at field.
-	alertDescCreatedAt := alertFields[0].Descriptor()
+	alertDescCreatedAt := alertFields[1].Descriptor()
 	// alert.DefaultCreatedAt holds the default value on creation for the created_at field.
 	alert.DefaultCreatedAt = alertDescCreatedAt.Default.(func() time.Time)
 	// alertDescUpdatedAt is the schema descriptor for updated_at field.
-	alertDescUpdatedAt := alertFields[1].Descriptor()
+	alertDescUpdatedAt := alertFields[2].Descriptor()
 	// alert.DefaultUpdatedAt holds the default value on creation for the updated_at field.
 	alert.DefaultUpdatedAt = alertDescUpdatedAt.Default.(func() time.Time)
 	// alertDescBucketId is the schema descriptor for bucketId field.
-	alertDescBucketId := alertFields[3].Descriptor()
+	alertDescBucketId := alertFields[4].Descriptor()
 	// alert.DefaultBucketId holds the default value on creation for the bucketId field.
 	alert.DefaultBucketId = alertDescBucketId.Default.(string)
 	// alertDescMessage is the schema descriptor for message field.
-	alertDescMessage := alertFields[4].Descriptor()
+	alertDescMessage := alertFields[5].Descriptor()
 	// alert.DefaultMessage holds the default value on creation for the message field.
 	alert.DefaultMessage = alertDescMessage.Default.(string)
 	// alertDescEventsCount is the schema descriptor for eventsCount field.
-	alertDescEventsCount := alertFields[5].Descriptor()
+	alertDescEventsCount := alertFields[6].Descriptor()
 	// alert.DefaultEventsCount holds the default value on creation for the eventsCount field.
 	alert.DefaultEventsCount = alertDescEventsCount.Default.(int32)
 	// alertDescStartedAt is the schema descriptor for startedAt field.
-	alertDescStartedAt := alertFields[6].Descriptor()
+	alertDescStartedAt := alertFields[7].Descriptor()
 	// alert.DefaultStartedAt holds the default value on creation for the startedAt field.
 	alert.DefaultStartedAt = alertDescStartedAt.Default.(func() time.Time)
 	// alertDescStoppedAt is the schema descriptor for stoppedAt field.
-	alertDescStoppedAt := alertFields[7].Descriptor()
+	alertDescStoppedAt := alertFields[8].Descriptor()
 	// alert.DefaultStoppedAt holds the default value on creation for the stoppedAt field.
 	alert.DefaultStoppedAt = alertDescStoppedAt.Default.(func() time.Time)
 	// alertDescSimulated is the schema descriptor for simulated field.
-	alertDescSimulated := alertFields[21].Descriptor()
+	alertDescSimulated := alertFields[22].Descriptor()
 	// alert.DefaultSimulated holds the default value on creation for the simulated field.
 	alert.DefaultSimulated = alertDescSimulated.Default.(bool)
+	// alertDescAcknowledged is the schema descriptor for acknowledged field.
+	alertDescAcknowledged := alertFields[25].Descriptor()
+	// alert.DefaultAcknowledged holds the default value on creation for the acknowledged field.
+	alert.DefaultAcknowledged = alertDescAcknowledged.Default.(bool)
+	// alertDescArchived is the schema descriptor for archived field.
+	alertDescArchived := alertFields[28].Descriptor()
+	// alert.DefaultArchived holds the default value on creation for the archived field.
+	alert.DefaultArchived = alertDescArchived.Default.(bool)
 	bouncerFields := schema.Bouncer{}.Fields()
 	_ = bouncerFields
 	// bouncerDescCreatedAt is the schema descriptor for created_at field.
@@ -84,8 +92,10 @@ func init() {
 	decisionDescUpdatedAt := decisionFields[1].Descriptor()
 	// decision.DefaultUpdatedAt holds the default value on creation for the updated_at field.
 	decision.DefaultUpdatedAt = decisionDescUpdatedAt.Default.(func() time.Time)
+	// decision.UpdateDefaultUpdatedAt holds the default value on update for the updated_at field.
+	decision.UpdateDefaultUpdatedAt = decisionDescUpdatedAt.UpdateDefault.(func() time.Time)
 	// decisionDescSimulated is the schema descriptor for simulated field.
-	decisionDescSimulated := decisionFields[10].Descriptor()
+	decisionDescSimulated := decisionFields[13].Descriptor()
 	// decision.DefaultSimulated holds the default value on creation for the simulated field.
 	decision.DefaultSimulated = decisionDescSimulated.Default.(bool)
 	eventFields := schema.Event{}.Fields()
@@ -6,6 +6,7 @@ import (
 	"github.com/facebook/ent"
 	"github.com/facebook/ent/schema/edge"
 	"github.com/facebook/ent/schema/field"
+	"github.com/facebook/ent/schema/index"
 )
 
 // Alert holds the schema definition for the Alert entity.
@@ -16,6 +17,8 @@ type Alert struct {
 // Fields of the Alert.
 func (Alert) Fields() []ent.Field {
 	return []ent.Field{
+		/*declared to allow importing alerts with their original id, it is still auto incremented otherwise*/
+		field.Int("id"),
 		field.Time("created_at").
 			Default(time.Now),
 		field.Time("updated_at").
@@ -47,6 +50,19 @@ func (Alert) Fields() []ent.Field {
 		field.String("scenarioVersion").Optional(),
 		field.String("scenarioHash").Optional(),
 		field.Bool("simulated").Default(false),
+		/*JSON encoded list of tags, from the alert labels*/
+		field.Text("tags").Optional(),
+		/*identifies the alerts inserted by the same CreateAlertBulk call*/
+		field.String("batchId").Optional(),
+		/*set when the alert has been reviewed, so that it drops out of the triage queue.
+		Optional as the alerts stored before the column was added have it NULL, which means not acknowledged*/
+		field.Bool("acknowledged").Default(false).Optional(),
+		field.Time("acknowledgedAt").Optional(),
+		field.String("acknowledgedBy").Optional(),
+		/*archived alerts are hidden from the queries but kept for audit, until FlushAlerts deletes them.
+		Optional as the alerts stored before the column was added have it NULL, which means not archived*/
+		field.Bool("archived").Default(false).Optional(),
+		field.Time("archivedAt").Optional(),
 	}
 }
 
@@ -61,3 +77,11 @@ func (Alert) Edges() []ent.Edge {
 		edge.To("metas", Meta.Type),
 	}
 }
+
+// Indexes of the Alert.
+func (Alert) Indexes() []ent.Index {
+	return []ent.Index{
+		/*RecentCounts, SumEventsCount and the created_after/created_before filters all bound the alerts on their creation time*/
+		index.Fields("created_at"),
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/facebook/ent"
 	"github.com/facebook/ent/schema/edge"
 	"github.com/facebook/ent/schema/field"
+	"github.com/facebook/ent/schema/index"
 )
 
 // Decision holds the schema definition for the Decision entity.
@@ -19,12 +20,17 @@ func (Decision) Fields() []ent.Field {
 		field.Time("created_at").
 			Default(time.Now),
 		field.Time("updated_at").
-			Default(time.Now),
+			Default(time.Now).
+			UpdateDefault(time.Now),
 		field.Time("until"),
 		field.String("scenario"),
 		field.String("type"),
 		field.Int64("start_ip").Optional(),
 		field.Int64("end_ip").Optional(),
+		/*size of the addresses (4 or 16 bytes) : IPv6 ones don't fit in start_ip/end_ip, which then hold the network half of the addresses, and the suffixes the other half*/
+		field.Int64("ip_size").Optional(),
+		field.Int64("start_suffix").Optional(),
+		field.Int64("end_suffix").Optional(),
 		field.String("scope"),
 		field.String("value"),
 		field.String("origin"),
@@ -40,3 +46,11 @@ func (Decision) Edges() []ent.Edge {
 			Unique(),
 	}
 }
+
+// Indexes of the Decision.
+func (Decision) Indexes() []ent.Index {
+	return []ent.Index{
+		/*the decisions half of RecentCounts, polled by the monitoring every few seconds*/
+		index.Fields("created_at"),
+	}
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent"
+)
+
+// machineCache is a bounded LRU of the machines resolved by CreateAlertBulk, so that a busy LAPI doesn't read the same machine for each batch of alerts.
+// The entries expire after Client.MachineCacheTTL, and are dropped as soon as the machine is updated or deleted through the Client
+type machineCache struct {
+	lock    sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // the most recently used entry first
+}
+
+type machineCacheEntry struct {
+	machineID string
+	machine   *ent.Machine
+	expires   time.Time
+}
+
+func newMachineCache() *machineCache {
+	return &machineCache{
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (mc *machineCache) get(machineID string, now time.Time) (*ent.Machine, bool) {
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+	elem, ok := mc.entries[machineID]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*machineCacheEntry)
+	if now.After(entry.expires) {
+		mc.order.Remove(elem)
+		delete(mc.entries, machineID)
+		return nil, false
+	}
+	mc.order.MoveToFront(elem)
+	return entry.machine, true
+}
+
+// add caches the machine until expires, evicting the least recently used entries beyond size
+func (mc *machineCache) add(machineID string, machine *ent.Machine, expires time.Time, size int) {
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+	if elem, ok := mc.entries[machineID]; ok {
+		elem.Value = &machineCacheEntry{machineID: machineID, machine: machine, expires: expires}
+		mc.order.MoveToFront(elem)
+		return
+	}
+	mc.entries[machineID] = mc.order.PushFront(&machineCacheEntry{machineID: machineID, machine: machine, expires: expires})
+	for mc.order.Len() > size {
+		oldest := mc.order.Back()
+		mc.order.Remove(oldest)
+		delete(mc.entries, oldest.Value.(*machineCacheEntry).machineID)
+	}
+}
+
+func (mc *machineCache) remove(machineID string) {
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+	if elem, ok := mc.entries[machineID]; ok {
+		mc.order.Remove(elem)
+		delete(mc.entries, machineID)
+	}
+}
+
+// removeID drops the machine with the given database id, for the updates that don't know its machine id
+func (mc *machineCache) removeID(id int) {
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+	for machineID, elem := range mc.entries {
+		if elem.Value.(*machineCacheEntry).machine.ID == id {
+			mc.order.Remove(elem)
+			delete(mc.entries, machineID)
+		}
+	}
+}
@@ -2,13 +2,16 @@ package database
 
 import (
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/go-openapi/strfmt"
-	log "github.com/sirupsen/logrus"
 
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/alert"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/decision"
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent/machine"
+	"github.com/facebook/ent/dialect/sql"
 	"github.com/pkg/errors"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -16,7 +19,7 @@ import (
 func (c *Client) CreateMachine(machineID *string, password *strfmt.Password, ipAddress string, isValidated bool, force bool) (int, error) {
 	hashPassword, err := bcrypt.GenerateFromPassword([]byte(*password), bcrypt.DefaultCost)
 	if err != nil {
-		log.Warningf("CreateMachine : %s", err)
+		c.logger().Warningf("CreateMachine : %s", err)
 		return 0, errors.Wrap(HashError, "")
 	}
 
@@ -30,8 +33,9 @@ func (c *Client) CreateMachine(machineID *string, password *strfmt.Password, ipA
 	if len(machineExist) > 0 {
 		if force {
 			_, err := c.Ent.Machine.Update().Where(machine.MachineIdEQ(*machineID)).SetPassword(string(hashPassword)).Save(c.CTX)
+			c.forgetMachine(*machineID)
 			if err != nil {
-				log.Warningf("CreateMachine : %s", err)
+				c.logger().Warningf("CreateMachine : %s", err)
 				return 0, errors.Wrapf(UpdateFail, "machine '%s'", *machineID)
 			}
 			return 1, nil
@@ -48,7 +52,7 @@ func (c *Client) CreateMachine(machineID *string, password *strfmt.Password, ipA
 		Save(c.CTX)
 
 	if err != nil {
-		log.Warningf("CreateMachine : %s", err)
+		c.logger().Warningf("CreateMachine : %s", err)
 		return 0, errors.Wrapf(InsertFail, "creating machine '%s'", *machineID)
 	}
 
@@ -61,16 +65,54 @@ func (c *Client) QueryMachineByID(machineID string) (*ent.Machine, error) {
 		Where(machine.MachineIdEQ(machineID)).
 		Only(c.CTX)
 	if err != nil {
-		log.Warningf("QueryMachineByID : %s", err)
+		c.logger().Warningf("QueryMachineByID : %s", err)
 		return &ent.Machine{}, errors.Wrapf(UserNotExists, "user '%s'", machineID)
 	}
 	return machine, nil
 }
 
+// cachedMachineByID is QueryMachineByID through the machines cache (see Client.MachineCacheTTL), the unknown machines aren't cached
+func (c *Client) cachedMachineByID(machineID string) (*ent.Machine, error) {
+	ttl := c.MachineCacheTTL
+	if ttl == 0 {
+		ttl = defaultMachineCacheTTL
+	}
+	if ttl < 0 || c.machines == nil {
+		return c.QueryMachineByID(machineID)
+	}
+	if cached, ok := c.machines.get(machineID, time.Now()); ok {
+		return cached, nil
+	}
+	machine, err := c.QueryMachineByID(machineID)
+	if err != nil {
+		return machine, err
+	}
+	size := c.MachineCacheSize
+	if size <= 0 {
+		size = defaultMachineCacheSize
+	}
+	c.machines.add(machineID, machine, time.Now().Add(ttl), size)
+	return machine, nil
+}
+
+// forgetMachine drops the machine from the cache once it has been updated or deleted
+func (c *Client) forgetMachine(machineID string) {
+	if c.machines != nil {
+		c.machines.remove(machineID)
+	}
+}
+
+// forgetMachineID is forgetMachine for the updates by database id
+func (c *Client) forgetMachineID(ID int) {
+	if c.machines != nil {
+		c.machines.removeID(ID)
+	}
+}
+
 func (c *Client) ListMachines() ([]*ent.Machine, error) {
 	machines, err := c.Ent.Machine.Query().All(c.CTX)
 	if err != nil {
-		log.Warningf("ListMachines : %s", err)
+		c.logger().Warningf("ListMachines : %s", err)
 		return []*ent.Machine{}, errors.Wrap(UpdateFail, "setting machine status")
 	}
 	return machines, nil
@@ -78,8 +120,9 @@ func (c *Client) ListMachines() ([]*ent.Machine, error) {
 
 func (c *Client) ValidateMachine(machineID string) error {
 	_, err := c.Ent.Machine.Update().Where(machine.MachineIdEQ(machineID)).SetIsValidated(true).Save(c.CTX)
+	c.forgetMachine(machineID)
 	if err != nil {
-		log.Warningf("ValidateMachine : %s", err)
+		c.logger().Warningf("ValidateMachine : %s", err)
 		return errors.Wrap(UpdateFail, "setting machine status")
 	}
 	return nil
@@ -91,7 +134,7 @@ func (c *Client) QueryPendingMachine() ([]*ent.Machine, error) {
 
 	machines, err = c.Ent.Machine.Query().Where(machine.IsValidatedEQ(false)).All(c.CTX)
 	if err != nil {
-		log.Warningf("QueryPendingMachine : %s", err)
+		c.logger().Warningf("QueryPendingMachine : %s", err)
 		return []*ent.Machine{}, errors.Wrap(UpdateFail, "setting machine status")
 	}
 	return machines, nil
@@ -102,6 +145,7 @@ func (c *Client) DeleteWatcher(name string) error {
 		Delete().
 		Where(machine.MachineIdEQ(name)).
 		Exec(c.CTX)
+	c.forgetMachine(name)
 	if err != nil {
 		return fmt.Errorf("unable to save api key in database: %s", err)
 	}
@@ -113,6 +157,7 @@ func (c *Client) UpdateMachineScenarios(scenarios string, ID int) error {
 		SetUpdatedAt(time.Now()).
 		SetScenarios(scenarios).
 		Save(c.CTX)
+	c.forgetMachineID(ID)
 	if err != nil {
 		return fmt.Errorf("unable to update machine in database: %s", err)
 	}
@@ -123,6 +168,7 @@ func (c *Client) UpdateMachineIP(ipAddr string, ID int) error {
 	_, err := c.Ent.Machine.UpdateOneID(ID).
 		SetIpAddress(ipAddr).
 		Save(c.CTX)
+	c.forgetMachineID(ID)
 	if err != nil {
 		return fmt.Errorf("unable to update machine in database: %s", err)
 	}
@@ -133,6 +179,7 @@ func (c *Client) UpdateMachineVersion(ipAddr string, ID int) error {
 	_, err := c.Ent.Machine.UpdateOneID(ID).
 		SetVersion(ipAddr).
 		Save(c.CTX)
+	c.forgetMachineID(ID)
 	if err != nil {
 		return fmt.Errorf("unable to update machine in database: %s", err)
 	}
@@ -154,3 +201,74 @@ func (c *Client) IsMachineRegistered(machineID string) (bool, error) {
 	return false, nil
 
 }
+
+// MachineStat is a machine along with the number of alerts it reported and of active decisions resulting from them
+type MachineStat struct {
+	MachineID       string
+	Alerts          int
+	ActiveDecisions int
+}
+
+// MachineStats returns every machine with its number of alerts and active decisions, the most active ones first
+func (c *Client) MachineStats() ([]MachineStat, error) {
+	var alertGroups []struct {
+		MachineAlerts int `json:"machine_alerts"`
+		Count         int `json:"count"`
+	}
+	var decisionGroups []struct {
+		MachineAlerts int `json:"machine_alerts"`
+		Count         int `json:"count"`
+	}
+
+	machines, err := c.Ent.Machine.Query().All(c.CTX)
+	if err != nil {
+		c.logger().Warningf("MachineStats : %s", err)
+		return []MachineStat{}, errors.Wrap(QueryFail, "list machines")
+	}
+
+	err = c.Ent.Alert.Query().
+		Where(alert.HasOwner()).
+		GroupBy(alert.OwnerColumn).
+		Aggregate(ent.Count()).
+		Scan(c.CTX, &alertGroups)
+	if err != nil {
+		c.logger().Warningf("MachineStats : %s", err)
+		return []MachineStat{}, errors.Wrap(QueryFail, "count alerts by machine")
+	}
+
+	/*join the alerts with their active decisions, so that counting the rows by machine counts the decisions*/
+	decisions := sql.Table(decision.Table)
+	err = c.Ent.Alert.Query().
+		Where(alert.HasOwner()).
+		Where(func(s *sql.Selector) {
+			s.Join(decisions).On(s.C(alert.FieldID), decisions.C(decision.OwnerColumn))
+			s.Where(sql.GTE(decisions.C(decision.FieldUntil), time.Now()))
+		}).
+		GroupBy(alert.OwnerColumn).
+		Aggregate(ent.Count()).
+		Scan(c.CTX, &decisionGroups)
+	if err != nil {
+		c.logger().Warningf("MachineStats : %s", err)
+		return []MachineStat{}, errors.Wrap(QueryFail, "count active decisions by machine")
+	}
+
+	alertCount := make(map[int]int, len(alertGroups))
+	for _, group := range alertGroups {
+		alertCount[group.MachineAlerts] = group.Count
+	}
+	decisionCount := make(map[int]int, len(decisionGroups))
+	for _, group := range decisionGroups {
+		decisionCount[group.MachineAlerts] = group.Count
+	}
+
+	ret := make([]MachineStat, 0, len(machines))
+	for _, machineItem := range machines {
+		ret = append(ret, MachineStat{
+			MachineID:       machineItem.MachineId,
+			Alerts:          alertCount[machineItem.ID],
+			ActiveDecisions: decisionCount[machineItem.ID],
+		})
+	}
+	sort.SliceStable(ret, func(i, j int) bool { return ret[i].Alerts > ret[j].Alerts })
+	return ret, nil
+}
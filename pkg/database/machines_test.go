@@ -0,0 +1,162 @@
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent"
+	"github.com/crowdsecurity/crowdsec/pkg/models"
+	"github.com/facebook/ent/dialect"
+	"github.com/go-openapi/strfmt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMachineStats(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	password := strfmt.Password("password")
+	for _, machineID := range []string{"idle", "busy", "quiet"} {
+		machineID := machineID
+		_, err := dbClient.CreateMachine(&machineID, &password, "127.0.0.1", true, false)
+		assert.NoError(t, err)
+	}
+
+	_, err := dbClient.CreateAlertBulk("busy", newTestAlerts(3))
+	assert.NoError(t, err)
+	expired := newTestAlert("crowdsecurity/test", "4.3.2.1")
+	expired.Decisions[0].Until = time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	_, err = dbClient.CreateAlertBulk("quiet", []*models.Alert{newTestAlert("crowdsecurity/test", "4.3.2.2"), expired})
+	assert.NoError(t, err)
+	/*alerts from unknown machines have no owner and aren't counted*/
+	_, err = dbClient.CreateAlertBulk("unknown", newTestAlerts(1))
+	assert.NoError(t, err)
+
+	stats, err := dbClient.MachineStats()
+	assert.NoError(t, err)
+	assert.Equal(t, []MachineStat{
+		{MachineID: "busy", Alerts: 3, ActiveDecisions: 3},
+		{MachineID: "quiet", Alerts: 2, ActiveDecisions: 1},
+		{MachineID: "idle", Alerts: 0, ActiveDecisions: 0},
+	}, stats)
+}
+
+func TestMachineRecentAlerts(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	password := strfmt.Password("password")
+	for _, machineID := range []string{"agent1", "agent2"} {
+		machineID := machineID
+		_, err := dbClient.CreateMachine(&machineID, &password, "127.0.0.1", true, false)
+		assert.NoError(t, err)
+	}
+	/*more than paginationSize alerts, to get several pages*/
+	ids, err := dbClient.CreateAlertBulk("agent1", newTestAlerts(paginationSize+2))
+	assert.NoError(t, err)
+	_, err = dbClient.CreateAlertBulk("agent2", newTestAlerts(2))
+	assert.NoError(t, err)
+
+	/*one of agent1's alerts is older than the window*/
+	oldID, err := strconv.Atoi(ids[0])
+	assert.NoError(t, err)
+	_, err = dbClient.Ent.Alert.UpdateOneID(oldID).SetCreatedAt(time.Now().Add(-2 * time.Hour)).Save(dbClient.CTX)
+	assert.NoError(t, err)
+
+	alerts, err := dbClient.MachineRecentAlerts("agent1", time.Hour)
+	assert.NoError(t, err)
+	assert.Len(t, alerts, paginationSize+1)
+	for _, alertItem := range alerts {
+		assert.NotEqual(t, oldID, alertItem.ID)
+		assert.Len(t, alertItem.Edges.Decisions, 1)
+		assert.Nil(t, alertItem.Edges.Events)
+	}
+	assert.True(t, alerts[0].ID > alerts[1].ID)
+
+	alerts, err = dbClient.MachineRecentAlerts("agent1", 3*time.Hour)
+	assert.NoError(t, err)
+	assert.Len(t, alerts, paginationSize+2)
+
+	alerts, err = dbClient.MachineRecentAlerts("unknown", time.Hour)
+	assert.NoError(t, err)
+	assert.Len(t, alerts, 0)
+}
+
+func TestMachineCache(t *testing.T) {
+	dbClient, cleanup := getDBClient(t)
+	defer cleanup()
+
+	machineID := "agent"
+	password := strfmt.Password("password")
+	_, err := dbClient.CreateMachine(&machineID, &password, "127.0.0.1", true, false)
+	assert.NoError(t, err)
+
+	/*count the queries on the machines table*/
+	nbMachineQueries := 0
+	dbClient.Ent = ent.NewClient(ent.Driver(dialect.Debug(dbClient.drv, func(args ...interface{}) {
+		if strings.Contains(fmt.Sprint(args...), "FROM `machines`") {
+			nbMachineQueries++
+		}
+	})))
+	createAlert := func() int {
+		nbMachineQueries = 0
+		_, err := dbClient.CreateAlertBulk(machineID, newTestAlerts(1))
+		assert.NoError(t, err)
+		return nbMachineQueries
+	}
+
+	assert.Equal(t, 1, createAlert())
+	/*within the ttl, the machine is not read again*/
+	assert.Equal(t, 0, createAlert())
+
+	/*an update drops the machine from the cache*/
+	owner, err := dbClient.QueryMachineByID(machineID)
+	assert.NoError(t, err)
+	assert.NoError(t, dbClient.UpdateMachineIP("10.0.0.1", owner.ID))
+	assert.Equal(t, 1, createAlert())
+	assert.Equal(t, 0, createAlert())
+	assert.NoError(t, dbClient.ValidateMachine(machineID))
+	assert.Equal(t, 1, createAlert())
+
+	/*the unknown machines aren't cached*/
+	assert.NoError(t, dbClient.DeleteWatcher(machineID))
+	assert.Equal(t, 1, createAlert())
+	assert.Equal(t, 1, createAlert())
+
+	dbClient.MachineCacheTTL = -1
+	_, err = dbClient.CreateMachine(&machineID, &password, "127.0.0.1", true, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, createAlert())
+	assert.Equal(t, 1, createAlert())
+}
+
+func TestMachineCacheEviction(t *testing.T) {
+	cache := newMachineCache()
+	now := time.Now()
+	for i, machineID := range []string{"agent1", "agent2", "agent3"} {
+		cache.add(machineID, &ent.Machine{ID: i + 1, MachineId: machineID}, now.Add(time.Minute), 2)
+		if i == 1 {
+			/*agent1 is used, agent2 becomes the least recently used one*/
+			_, ok := cache.get("agent1", now)
+			assert.True(t, ok)
+		}
+	}
+	_, ok := cache.get("agent2", now)
+	assert.False(t, ok)
+	cached, ok := cache.get("agent1", now)
+	assert.True(t, ok)
+	assert.Equal(t, 1, cached.ID)
+
+	/*the entries expire*/
+	_, ok = cache.get("agent3", now.Add(2*time.Minute))
+	assert.False(t, ok)
+	_, ok = cache.get("agent3", now)
+	assert.False(t, ok)
+
+	cache.removeID(1)
+	_, ok = cache.get("agent1", now)
+	assert.False(t, ok)
+}
@@ -1,11 +1,36 @@
 package database
 
 import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"database/sql/driver"
 	"encoding/binary"
+	"encoding/hex"
 	"fmt"
 	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/crowdsecurity/crowdsec/pkg/types"
+	"github.com/go-sql-driver/mysql"
+	"github.com/mattn/go-sqlite3"
+	"github.com/pkg/errors"
 )
 
+/*RFC1918 private ranges (10.0.0.0/8, 172.16.0.0/12 and 192.168.0.0/16), as the dotted prefixes of their addresses*/
+var privateIPPrefixes = []string{
+	"10.",
+	"172.16.", "172.17.", "172.18.", "172.19.", "172.20.", "172.21.", "172.22.", "172.23.",
+	"172.24.", "172.25.", "172.26.", "172.27.", "172.28.", "172.29.", "172.30.", "172.31.",
+	"192.168.",
+}
+
+/*the days and weeks of a duration, that time.ParseDuration doesn't know about*/
+var durationDaysWeeks = regexp.MustCompile(`(\d+(?:\.\d+)?)([dw])`)
+
 func IP2Int(ip net.IP) uint32 {
 	if len(ip) == 16 {
 		return binary.BigEndian.Uint32(ip[12:16])
@@ -19,10 +44,50 @@ func Int2ip(nn uint32) net.IP {
 	return ip
 }
 
+// newBatchID returns a random identifier for a batch of alerts
+func newBatchID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// normalizeScope makes the ip and range scopes case insensitive
+func normalizeScope(scope string) string {
+	scope = strings.TrimSpace(scope)
+	switch strings.ToLower(scope) {
+	case "ip":
+		return types.Ip
+	case "range":
+		return types.Range
+	}
+	return scope
+}
+
+// IsIpv4 reports whether host is a valid IP address, whatever its family : use GetIPFamily to tell IPv4 and IPv6 apart
 func IsIpv4(host string) bool {
 	return net.ParseIP(host) != nil
 }
 
+// IsIpv6 reports whether host is an IPv6 address. IPv4-mapped addresses (ie. ::ffff:1.2.3.4) are IPv4 ones
+func IsIpv6(host string) bool {
+	family, err := GetIPFamily(host)
+	return err == nil && family == 6
+}
+
+// GetIPFamily returns 4 or 6 depending on the family of the host address, IPv4-mapped IPv6 addresses being IPv4 ones
+func GetIPFamily(host string) (int, error) {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return 0, fmt.Errorf("'%s' is not a valid IP", host)
+	}
+	if ip.To4() != nil {
+		return 4, nil
+	}
+	return 6, nil
+}
+
 //Stolen from : https://github.com/llimllib/ipaddress/
 // Return the final address of a net range. Convert to IPv4 if possible,
 // otherwise return an ipv6
@@ -53,7 +118,7 @@ func GetIpsFromIpRange(host string) (int64, int64, error) {
 	var parsedRange *net.IPNet
 
 	if _, parsedRange, err = net.ParseCIDR(host); err != nil {
-		return ipStart, ipEnd, fmt.Errorf("'%s' is not a valid CIDR", host)
+		return ipStart, ipEnd, fmt.Errorf("'%s' is not a valid CIDR: %s", host, explainCIDRError(host))
 	}
 	if parsedRange == nil {
 		return ipStart, ipEnd, fmt.Errorf("unable to parse network : %s", err)
@@ -63,3 +128,159 @@ func GetIpsFromIpRange(host string) (int64, int64, error) {
 
 	return ipStart, ipEnd, nil
 }
+
+// GetIpBoundsFromIpRange is GetIpsFromIpRange for both address families : it returns the size of the addresses (4 or 16 bytes)
+// and the first and last addresses of the range, each split in a network part and a suffix part (see ipToInts)
+func GetIpBoundsFromIpRange(host string) (int, int64, int64, int64, int64, error) {
+	_, parsedRange, err := net.ParseCIDR(host)
+	if err != nil {
+		return 0, 0, 0, 0, 0, fmt.Errorf("'%s' is not a valid CIDR: %s", host, explainCIDRError(host))
+	}
+	ipSize, startIP, startSuffix := ipToInts(parsedRange.IP)
+	_, endIP, endSuffix := ipToInts(LastAddress(parsedRange))
+
+	return ipSize, startIP, startSuffix, endIP, endSuffix, nil
+}
+
+// getIpBoundsFromIp is GetIpBoundsFromIpRange for a single address of either family, ie. a /32 or /128 range
+func getIpBoundsFromIp(host string) (int, int64, int64, int64, int64, error) {
+	family, err := GetIPFamily(host)
+	if err != nil {
+		return 0, 0, 0, 0, 0, err
+	}
+	if family == 4 {
+		/*an IPv4-mapped address would be read as an IPv6 network by ParseCIDR*/
+		return GetIpBoundsFromIpRange(net.ParseIP(host).To4().String() + "/32")
+	}
+	return GetIpBoundsFromIpRange(host + "/128")
+}
+
+// ipToInts returns the size of ip and its value as two int64 that sort like the address itself :
+// an IPv4 address is kept whole in the first one (like IP2Int), an IPv6 one is split in two halves shifted to the int64 range
+func ipToInts(ip net.IP) (int, int64, int64) {
+	if ip4 := ip.To4(); ip4 != nil {
+		return 4, int64(IP2Int(ip4)), 0
+	}
+	ip16 := ip.To16()
+	return 16, uint64ToSortableInt64(binary.BigEndian.Uint64(ip16[:8])), uint64ToSortableInt64(binary.BigEndian.Uint64(ip16[8:]))
+}
+
+func uint64ToSortableInt64(u uint64) int64 {
+	return int64(u ^ 1<<63)
+}
+
+// ParseDurationExtended is time.ParseDuration with days (d) and weeks (w) on top of its units, ie. "30d", "2w" or "1w3d12h"
+func ParseDurationExtended(d string) (time.Duration, error) {
+	hours := durationDaysWeeks.ReplaceAllStringFunc(d, func(daysWeeks string) string {
+		parts := durationDaysWeeks.FindStringSubmatch(daysWeeks)
+		/*the regexp only matches valid numbers*/
+		nb, _ := strconv.ParseFloat(parts[1], 64)
+		if parts[2] == "w" {
+			nb *= 7
+		}
+		return strconv.FormatFloat(nb*24, 'f', -1, 64) + "h"
+	})
+	return time.ParseDuration(hours)
+}
+
+func explainCIDRError(cidr string) string {
+	sep := strings.Index(cidr, "/")
+	if sep < 0 {
+		return "missing prefix length (ie. '/24')"
+	}
+	address, prefix := cidr[:sep], cidr[sep+1:]
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return fmt.Sprintf("'%s' is not a valid ip address", address)
+	}
+	maxPrefix := 128
+	if ip.To4() != nil {
+		maxPrefix = 32
+	}
+	prefixLen, err := strconv.Atoi(prefix)
+	if err != nil || prefixLen < 0 || prefixLen > maxPrefix {
+		return fmt.Sprintf("'%s' is not a valid prefix length (expected 0-%d)", prefix, maxPrefix)
+	}
+	return "invalid CIDR notation"
+}
+
+// isConnectionError tells if err comes from the connection to the database (ie. failover) rather than from the query itself
+func isConnectionError(err error) bool {
+	var netErr net.Error
+
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) || errors.Is(err, mysql.ErrInvalidConn) {
+		return true
+	}
+	return errors.As(err, &netErr)
+}
+
+// aggregatedTime scans the result of an aggregate on a time column (ie. max(created_at)), that the backends don't return the same way :
+// MySQL (with parseTime) and PostgreSQL return a time, SQLite the text it stored, as it doesn't know the type of the result
+type aggregatedTime struct {
+	time.Time
+}
+
+func (t *aggregatedTime) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		t.Time = time.Time{}
+		return nil
+	case time.Time:
+		t.Time = v
+		return nil
+	case []byte:
+		return t.parse(string(v))
+	case string:
+		return t.parse(v)
+	}
+	return fmt.Errorf("unable to scan %T as a time", value)
+}
+
+func (t *aggregatedTime) parse(value string) error {
+	value = strings.TrimSuffix(value, "Z")
+	for _, format := range sqlite3.SQLiteTimestampFormats {
+		if parsed, err := time.ParseInLocation(format, value, time.UTC); err == nil {
+			t.Time = parsed
+			return nil
+		}
+	}
+	return fmt.Errorf("unable to parse '%s' as a time", value)
+}
+
+// isLockedError tells if the error is SQLite's (or one wrapping it) when another connection holds the lock on the database
+func isLockedError(err error) bool {
+	return strings.Contains(err.Error(), "database is locked") || strings.Contains(err.Error(), "database table is locked")
+}
+
+// retryLocked calls fn again while it fails because the database is locked, waiting twice as long after each attempt
+// (up to maxLockedRetryDelay), for at most LockedMaxAttempts attempts. The error of the last attempt is returned.
+func (c *Client) retryLocked(ctx context.Context, fn func() error) error {
+	maxAttempts := c.LockedMaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultLockedMaxAttempts
+	}
+	delay := lockedRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !isLockedError(err) || attempt >= maxAttempts {
+			return err
+		}
+		c.logger().Warningf("database is locked, retrying in %s (attempt %d/%d)", delay, attempt, maxAttempts)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > maxLockedRetryDelay {
+			delay = maxLockedRetryDelay
+		}
+	}
+}
+
+// wrapDBError wraps the error of a database operation with sentinel, unless the connection was lost : it's then a ConnectionError, worth a retry
+func wrapDBError(err error, sentinel error, format string, args ...interface{}) error {
+	if isConnectionError(err) {
+		return errors.Wrapf(ConnectionError, format, args...)
+	}
+	return errors.Wrapf(sentinel, format, args...)
+}
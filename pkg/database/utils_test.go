@@ -0,0 +1,215 @@
+package database
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetIpsFromIpRangeErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		cidr     string
+		expected string
+	}{
+		{
+			name:     "mask too long",
+			cidr:     "10.0.0.0/33",
+			expected: "'10.0.0.0/33' is not a valid CIDR: '33' is not a valid prefix length (expected 0-32)",
+		},
+		{
+			name:     "negative mask",
+			cidr:     "10.0.0.0/-1",
+			expected: "'10.0.0.0/-1' is not a valid CIDR: '-1' is not a valid prefix length (expected 0-32)",
+		},
+		{
+			name:     "bad address",
+			cidr:     "notanip/24",
+			expected: "'notanip/24' is not a valid CIDR: 'notanip' is not a valid ip address",
+		},
+		{
+			name:     "truncated address",
+			cidr:     "10.0.0/24",
+			expected: "'10.0.0/24' is not a valid CIDR: '10.0.0' is not a valid ip address",
+		},
+		{
+			name:     "missing mask",
+			cidr:     "10.0.0.0",
+			expected: "'10.0.0.0' is not a valid CIDR: missing prefix length (ie. '/24')",
+		},
+		{
+			name:     "empty mask",
+			cidr:     "10.0.0.0/",
+			expected: "'10.0.0.0/' is not a valid CIDR: '' is not a valid prefix length (expected 0-32)",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := GetIpsFromIpRange(test.cidr)
+			assert.EqualError(t, err, test.expected)
+		})
+	}
+}
+
+func TestWrapDBError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{name: "bad connection", err: driver.ErrBadConn, expected: ConnectionError},
+		{name: "wrapped bad connection", err: fmt.Errorf("insert: %w", driver.ErrBadConn), expected: ConnectionError},
+		{name: "connection done", err: sql.ErrConnDone, expected: ConnectionError},
+		{name: "network error", err: &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}, expected: ConnectionError},
+		{name: "query error", err: errors.New("UNIQUE constraint failed"), expected: BulkError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := wrapDBError(test.err, BulkError, "creating alerts: %s", test.err)
+			assert.True(t, errors.Is(err, test.expected))
+			assert.Equal(t, test.expected == ConnectionError, !errors.Is(err, BulkError))
+		})
+	}
+}
+
+func TestGetIpBoundsFromIpRange(t *testing.T) {
+	ipSize, startIP, startSuffix, endIP, endSuffix, err := GetIpBoundsFromIpRange("1.2.3.0/24")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, ipSize)
+	assert.Equal(t, int64(IP2Int(net.ParseIP("1.2.3.0"))), startIP)
+	assert.Equal(t, int64(IP2Int(net.ParseIP("1.2.3.255"))), endIP)
+	assert.Equal(t, int64(0), startSuffix)
+	assert.Equal(t, int64(0), endSuffix)
+
+	ipSize, startIP, startSuffix, endIP, endSuffix, err = GetIpBoundsFromIpRange("2001:db8::/64")
+	assert.NoError(t, err)
+	assert.Equal(t, 16, ipSize)
+	assert.Equal(t, startIP, endIP)
+	assert.Equal(t, int64(math.MinInt64), startSuffix)
+	assert.Equal(t, int64(math.MaxInt64), endSuffix)
+
+	/*the halves sort like the addresses*/
+	_, low, _, _, _, err := GetIpBoundsFromIpRange("::1/128")
+	assert.NoError(t, err)
+	_, high, _, _, _, err := GetIpBoundsFromIpRange("ffff::1/128")
+	assert.NoError(t, err)
+	assert.True(t, low < startIP && startIP < high)
+}
+
+func TestParseDurationExtended(t *testing.T) {
+	tests := []struct {
+		duration string
+		expected time.Duration
+	}{
+		{"30d", 30 * 24 * time.Hour},
+		{"2w", 14 * 24 * time.Hour},
+		{"1w3d12h", (10*24 + 12) * time.Hour},
+		{"1.5d", 36 * time.Hour},
+		{"90m", 90 * time.Minute},
+		{"-1d", -24 * time.Hour},
+	}
+	for _, test := range tests {
+		duration, err := ParseDurationExtended(test.duration)
+		assert.NoError(t, err, test.duration)
+		assert.Equal(t, test.expected, duration, test.duration)
+	}
+
+	for _, invalid := range []string{"", "d", "3y", "1w3"} {
+		_, err := ParseDurationExtended(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestGetIPFamily(t *testing.T) {
+	tests := []struct {
+		name   string
+		host   string
+		family int
+		err    bool
+	}{
+		{name: "ipv4", host: "1.2.3.4", family: 4},
+		{name: "ipv6", host: "2001:db8::1", family: 6},
+		{name: "ipv6 loopback", host: "::1", family: 6},
+		{name: "ipv4-mapped ipv6", host: "::ffff:1.2.3.4", family: 4},
+		{name: "ipv4-mapped ipv6 in hex", host: "::ffff:102:304", family: 4},
+		{name: "range", host: "1.2.3.0/24", err: true},
+		{name: "garbage", host: "gruueq", err: true},
+		{name: "empty", host: "", err: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			family, err := GetIPFamily(test.host)
+			if test.err {
+				assert.Error(t, err)
+				assert.False(t, IsIpv6(test.host))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.family, family)
+			assert.Equal(t, test.family == 6, IsIpv6(test.host))
+		})
+	}
+
+	/*a mapped address has the same bounds as the plain IPv4 one*/
+	ipSize, startIP, _, endIP, _, err := getIpBoundsFromIp("::ffff:1.2.3.4")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, ipSize)
+	assert.Equal(t, int64(IP2Int(net.ParseIP("1.2.3.4"))), startIP)
+	assert.Equal(t, startIP, endIP)
+}
+
+func TestPrivateIPPrefixes(t *testing.T) {
+	private := []*net.IPNet{}
+	for _, cidr := range []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16"} {
+		_, network, err := net.ParseCIDR(cidr)
+		assert.NoError(t, err)
+		private = append(private, network)
+	}
+	isPrivate := func(ip string) bool {
+		for _, network := range private {
+			if network.Contains(net.ParseIP(ip)) {
+				return true
+			}
+		}
+		return false
+	}
+	hasPrivatePrefix := func(ip string) bool {
+		for _, prefix := range privateIPPrefixes {
+			if strings.HasPrefix(ip, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+
+	/*the prefixes match exactly the addresses of the private ranges*/
+	for first := 0; first < 256; first++ {
+		for _, second := range []int{0, 15, 16, 31, 32, 168, 255} {
+			ip := fmt.Sprintf("%d.%d.1.1", first, second)
+			assert.Equal(t, isPrivate(ip), hasPrivatePrefix(ip), ip)
+		}
+	}
+}
+
+func TestAggregatedTimeScan(t *testing.T) {
+	expected := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, value := range []interface{}{expected, "2021-01-02 03:04:05+00:00", []byte("2021-01-02 03:04:05"), "2021-01-02T03:04:05Z"} {
+		var scanned aggregatedTime
+		assert.NoError(t, scanned.Scan(value), "%v", value)
+		assert.True(t, expected.Equal(scanned.Time), "%v : %s", value, scanned.Time)
+	}
+	var scanned aggregatedTime
+	assert.NoError(t, scanned.Scan(nil))
+	assert.True(t, scanned.IsZero())
+	assert.Error(t, scanned.Scan("yesterday"))
+	assert.Error(t, scanned.Scan(42))
+}
@@ -51,6 +51,9 @@ type Decision struct {
 	// Required: true
 	Type *string `json:"type"`
 
+	// (optional) absolute expiration of the decision (RFC3339), takes precedence over duration when importing decisions
+	Until string `json:"until,omitempty"`
+
 	// the value of the decision scope : an IP, a range, a username, etc
 	// Required: true
 	Value *string `json:"value"`